@@ -12,4 +12,16 @@ var ErrInvalidPassword = fmt.Errorf("invalid password")
 // scheme used by the hash provided is not supported.
 var ErrUnsupportedScheme = fmt.Errorf("unsupported scheme")
 
+// Indicates that a scheme can only be used to verify existing hashes, and
+// cannot be used to hash new passwords.
+var ErrVerifyOnly = fmt.Errorf("scheme does not support hashing new passwords")
+
+// Indicates that password verification was refused because the hash provided
+// uses a scheme which has been disabled.
+var ErrDisabledScheme = fmt.Errorf("disabled scheme")
+
+// Indicates that password verification was refused because the parameters of
+// the hash provided exceed the configured limits.
+var ErrLimitExceeded = fmt.Errorf("hash exceeds limits")
+
 // © 2014 Hugo Landau <hlandau@devever.net>  MIT License
@@ -32,3 +32,23 @@ type Scheme interface {
 	// Make a stub with the configured defaults. The salt is generated randomly.
 	//MakeStub() (string, error)
 }
+
+// Optionally implemented by a Scheme which can only be used to verify existing
+// hashes, such as a scheme for an obsolete format which is supported only so
+// that users can be upgraded. The Hash method of such a scheme fails with
+// ErrVerifyOnly, and NeedsUpdate always returns true.
+//
+// A passlib Context never uses such a scheme to hash new passwords.
+type VerifyOnlyScheme interface {
+	Scheme
+
+	// Returns true iff this scheme cannot hash new passwords.
+	VerifyOnly() bool
+}
+
+// Returns true iff the scheme implements VerifyOnlyScheme and cannot hash new
+// passwords.
+func IsVerifyOnly(scheme Scheme) bool {
+	vo, ok := scheme.(VerifyOnlyScheme)
+	return ok && vo.VerifyOnly()
+}
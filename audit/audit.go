@@ -0,0 +1,175 @@
+// Package audit produces aggregate reports on collections of password hashes,
+// such as those exported from a user table, to help decide when to move to a
+// new set of defaults (see passlib.UseDefaults).
+package audit
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// Options for Run.
+type Options struct {
+	// The context used to identify hashes and determine whether they need
+	// updating. If nil, the default context is used.
+	Context *passlib.Context
+
+	// The input format and, for CSV and JSON, the name of the column or member
+	// containing the hash.
+	Format Format
+	Field  string
+
+	// The limits used to identify hashes which are a denial of service risk.
+	// If nil, DefaultLimits is used.
+	Limits *Limits
+}
+
+// An aggregate report on a collection of hashes.
+type Report struct {
+	// The number of hashes read.
+	Total int `json:"total"`
+
+	// The number of hashes not supported by any scheme in the context.
+	Malformed int `json:"malformed"`
+
+	// The number of hashes exceeding the limits.
+	ExceedsLimits int `json:"exceedsLimits"`
+
+	// The number of hashes using the preferred scheme of the context, and the
+	// number of hashes which need updating according to the context's policy.
+	Preferred   int `json:"preferred"`
+	NeedsUpdate int `json:"needsUpdate"`
+
+	// The number of hashes per scheme, keyed by scheme name.
+	Schemes map[string]int `json:"schemes"`
+
+	// The number of hashes per parameter set, keyed by the hash identifier and
+	// parameters (see ParseParams), e.g. "argon2i v=19,m=32768,t=4,p=4".
+	ParamSets map[string]int `json:"paramSets"`
+}
+
+// Returns the percentage of the hashes read which use the preferred scheme.
+func (r *Report) PreferredPercent() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+
+	return 100 * float64(r.Preferred) / float64(r.Total)
+}
+
+// Reads hashes from r and produces a report. Hashes are never verified, so
+// Run is safe to use on hashes which exceed the limits.
+func Run(r io.Reader, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = &passlib.DefaultContext
+	}
+
+	limits := opts.Limits
+	if limits == nil {
+		limits = &DefaultLimits
+	}
+
+	report := &Report{
+		Schemes:   map[string]int{},
+		ParamSets: map[string]int{},
+	}
+
+	err := readHashes(r, opts.Format, opts.Field, func(hash string) {
+		report.Total++
+
+		if limits.Exceeded(hash) {
+			report.ExceedsLimits++
+		}
+
+		scheme, preferred := ctx.Identify(hash)
+		if scheme == nil {
+			report.Malformed++
+			return
+		}
+
+		report.Schemes[SchemeName(scheme)]++
+		if preferred {
+			report.Preferred++
+		}
+
+		if ctx.NeedsUpdate(hash) {
+			report.NeedsUpdate++
+		}
+
+		if id, params, ok := ParseParams(hash); ok {
+			s := make([]string, len(params))
+			for i, p := range params {
+				s[i] = p.Name + "=" + p.Value
+			}
+
+			report.ParamSets[strings.TrimSpace(id+" "+strings.Join(s, ","))]++
+		}
+	})
+
+	return report, err
+}
+
+// Returns the name of a scheme, which is its string representation without
+// any parenthesized parameters, e.g. "bcrypt" for "bcrypt(12)".
+func SchemeName(scheme abstract.Scheme) string {
+	s := fmt.Sprintf("%T", scheme)
+	if st, ok := scheme.(fmt.Stringer); ok {
+		s = st.String()
+	}
+
+	if i := strings.IndexByte(s, '('); i > 0 {
+		s = s[0:i]
+	}
+
+	return s
+}
+
+// Writes the report in human-readable form.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "total:          %d\n", r.Total)
+	fmt.Fprintf(&b, "preferred:      %d (%.1f%%)\n", r.Preferred, r.PreferredPercent())
+	fmt.Fprintf(&b, "needs update:   %d\n", r.NeedsUpdate)
+	fmt.Fprintf(&b, "malformed:      %d\n", r.Malformed)
+	fmt.Fprintf(&b, "exceeds limits: %d\n", r.ExceedsLimits)
+
+	b.WriteString("\nschemes:\n")
+	writeCounts(&b, r.Schemes)
+
+	b.WriteString("\nparameter sets:\n")
+	writeCounts(&b, r.ParamSets)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Writes counts in descending order of count, then by key.
+func writeCounts(b *strings.Builder, counts map[string]int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	for _, k := range keys {
+		fmt.Fprintf(b, "  %8d  %s\n", counts[k], k)
+	}
+}
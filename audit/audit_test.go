@@ -0,0 +1,186 @@
+package audit
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+const (
+	hashBcrypt   = "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6"
+	hashMD5      = "$1$12345678$xek.CpjQUVgdf/P2N9KQf/"
+	hashSHA256   = "$5$rounds=6000$abc$def"
+	hashArgon2   = "$argon2i$v=19$m=32768,t=4,p=4$XEfcwb81UQKSzIcxVEIgrw$1lAPOhgJpGJEgGSKxdnd3n3F9S5qPZSf53iKM1/SvTk"
+	hashDoS      = "$5$rounds=99999999$abc$def"
+	hashUnknown  = "garbage"
+	hashBcryptHi = "$2b$31$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6"
+)
+
+func param(name, value string) phc.Param {
+	return phc.Param{Name: name, Value: value}
+}
+
+func TestParseParams(t *testing.T) {
+	cases := []struct {
+		hash   string
+		id     string
+		params []phc.Param
+		ok     bool
+	}{
+		{hashArgon2, "argon2i", []phc.Param{param("v", "19"), param("m", "32768"), param("t", "4"), param("p", "4")}, true},
+		{hashBcrypt, "2a", []phc.Param{param("cost", "04")}, true},
+		{hashSHA256, "5", []phc.Param{param("rounds", "6000")}, true},
+		{hashMD5, "1", nil, true},
+		{"$s2$16384$8$1$c2FsdA==$aGFzaA==", "s2", []phc.Param{param("N", "16384"), param("r", "8"), param("p", "1")}, true},
+		{hashUnknown, "", nil, false},
+		{"$y$j9T$F5Jx5fExrKuPp53xLKQ..1", "y", []phc.Param{param("N", "4096"), param("r", "32"), param("p", "1")}, true},
+		{"$gy$jFT$F5Jx5fExrKuPp53xLKQ..1", "gy", []phc.Param{param("N", "262144"), param("r", "32"), param("p", "1")}, true},
+		{"$7$CU..../....salt$hash", "7", []phc.Param{param("N", "16384"), param("r", "32"), param("p", "1")}, true},
+		{"grub.pbkdf2.sha512.10000.AB.CD", "grub.pbkdf2.sha512", []phc.Param{param("rounds", "10000")}, true},
+		{"$p5k2$2710$salt$hash", "p5k2", []phc.Param{param("rounds", "10000")}, true},
+		{"$p5k2$$salt$hash", "p5k2", []phc.Param{param("rounds", "400")}, true},
+		{"{scrypt}$e0801$c2FsdA==$aGFzaA==", "{scrypt}", []phc.Param{param("N", "16384"), param("r", "8"), param("p", "1")}, true},
+		{"{bcrypt}" + hashBcrypt, "{bcrypt}", []phc.Param{param("cost", "04")}, true},
+		{"$firebase-scrypt$r=8,m=14$c2FsdA$aGFzaA", "firebase-scrypt", []phc.Param{param("N", "16384"), param("r", "8")}, true},
+	}
+
+	for _, c := range cases {
+		id, params, ok := ParseParams(c.hash)
+		if id != c.id || ok != c.ok || !reflect.DeepEqual(params, c.params) {
+			t.Errorf("%q: got %q %v %v, expected %q %v %v", c.hash, id, params, ok, c.id, c.params, c.ok)
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, hash := range []string{
+		hashBcrypt, hashSHA256, hashArgon2, hashMD5,
+		"$y$jFT$F5Jx5fExrKuPp53xLKQ..1",           // 1 GiB
+		"$scrypt$ln=16,r=8,p=1$c2FsdA$aGFzaA",     // 64 MiB
+		"$scrypt$ln=20,r=8,p=16$c2FsdA$aGFzaA",    // 1 GiB
+		"$firebase-scrypt$r=8,m=14$c2FsdA$aGFzaA", // 16 MiB
+		"{scrypt}$e0801$c2FsdA==$aGFzaA==",        // 16 MiB
+	} {
+		if DefaultLimits.Exceeded(hash) {
+			t.Errorf("%q unexpectedly exceeds limits", hash)
+		}
+	}
+
+	for _, hash := range []string{
+		hashDoS, hashBcryptHi, "$5$rounds=99999999999999999999999$abc$def", "$1$" + strings.Repeat("x", 2000),
+		"$y$jbT$F5Jx5fExrKuPp53xLKQ..1",                           // N=2^40
+		"$gy$j9srD$F5Jx5fExrKuPp53xLKQ..1",                        // r=2^12
+		"$7$z6..../....salt$hash",                                 // N=2^63
+		"grub.pbkdf2.sha512.2000000000.AB.CD",                     // rounds
+		"$p5k2$7fffffff$salt$hash",                                // rounds
+		"$p5k2$ffffffffffffffffffff$salt$hash",                    // rounds overflow
+		"{scrypt}$280801$c2FsdA==$aGFzaA==",                       // N=2^40
+		"{bcrypt}" + hashBcryptHi,                                 // cost
+		"$firebase-scrypt$r=8,m=40$c2FsdA$aGFzaA",                 // N=2^40
+		"$firebase-scrypt$r=100000,m=14$c2FsdA$aGFzaA",            // r
+		"{argon2}$argon2id$v=19$m=99999999,t=3,p=1$c2FsdA$aGFzaA", // m
+		"$7$CU.......2.salt$hash",                                 // p=2^20
+		"$scrypt$ln=4,r=1,p=1048576$c2FsdA$aGFzaA",                // p=2^20
+		"$scrypt$ln=20,r=16,p=1$c2FsdA$aGFzaA",                    // 2 GiB
+		"$scrypt$ln=64,r=1,p=1$c2FsdA$aGFzaA",                     // N=2^64
+		"$s2$16777216$4096$1$c2FsdA==$aGFzaA==",                   // 8 TiB
+		"{scrypt}$141001$c2FsdA==$aGFzaA==",                       // 2 GiB
+	} {
+		if !DefaultLimits.Exceeded(hash) {
+			t.Errorf("%q unexpectedly within limits", hash[0:20])
+		}
+	}
+}
+
+func testContext() *passlib.Context {
+	return &passlib.Context{
+		Schemes: []abstract.Scheme{sha2crypt.Crypter512, bcrypt.Crypter, sha2crypt.Crypter256},
+	}
+}
+
+func TestRun(t *testing.T) {
+	hashes := []string{hashBcrypt, hashBcrypt, hashSHA256, hashDoS, hashUnknown}
+	expected := &Report{
+		Total:         5,
+		Malformed:     1,
+		ExceedsLimits: 1,
+		Preferred:     0,
+		NeedsUpdate:   4,
+		Schemes:       map[string]int{"bcrypt": 2, "sha256-crypt": 2},
+		ParamSets: map[string]int{
+			"2a cost=04":        2,
+			"5 rounds=6000":     1,
+			"5 rounds=99999999": 1,
+		},
+	}
+
+	inputs := map[Format]string{
+		Lines: strings.Join(hashes, "\n\n") + "\n",
+		CSV:   "id,password\n1," + strings.Join(hashes, "\n2,") + "\n",
+		JSON:  `[{"password":"` + strings.Join(hashes, `"}, {"password":"`) + `"}]`,
+	}
+
+	for format, input := range inputs {
+		report, err := Run(strings.NewReader(input), &Options{
+			Context: testContext(),
+			Format:  format,
+			Field:   "password",
+		})
+		if err != nil {
+			t.Fatalf("format %d: %v", format, err)
+		}
+
+		if !reflect.DeepEqual(report, expected) {
+			t.Errorf("format %d: got %+v, expected %+v", format, report, expected)
+		}
+	}
+
+	ndjson := `{"password":"` + hashSHA512() + `"}` + "\n" + `{"password":"` + hashBcrypt + `"}` + "\n"
+	report, err := Run(strings.NewReader(ndjson), &Options{Context: testContext(), Format: JSON, Field: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Total != 2 || report.Preferred != 1 || report.PreferredPercent() != 50 {
+		t.Errorf("unexpected report for newline-delimited JSON: %+v", report)
+	}
+
+	var b bytes.Buffer
+	if err := report.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(b.String(), "preferred:      1 (50.0%)\n") {
+		t.Errorf("unexpected text report: %q", b.String())
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	if _, err := Run(strings.NewReader("id,hash\n"), &Options{Format: CSV, Field: "password"}); err == nil {
+		t.Errorf("missing CSV column not detected")
+	}
+
+	if _, err := Run(strings.NewReader(`[{"hash":"x"}`), &Options{Format: JSON, Field: "hash"}); err == nil {
+		t.Errorf("truncated JSON not detected")
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+}
+
+func hashSHA512() string {
+	h, err := sha2crypt.Crypter512.Hash("password")
+	if err != nil {
+		panic(err)
+	}
+
+	return h
+}
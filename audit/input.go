@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The format of the input to Run.
+type Format int
+
+const (
+	// One hash per line. Blank lines are ignored.
+	Lines Format = iota
+
+	// CSV with a header row. The hash is taken from the column named by
+	// Options.Field.
+	CSV
+
+	// A JSON array of objects, or a stream of JSON objects such as
+	// newline-delimited JSON. The hash is taken from the string member named
+	// by Options.Field.
+	JSON
+)
+
+// Parses a format name: "lines", "csv" or "json".
+func ParseFormat(s string) (Format, error) {
+	switch s {
+	case "lines":
+		return Lines, nil
+	case "csv":
+		return CSV, nil
+	case "json":
+		return JSON, nil
+	default:
+		return 0, fmt.Errorf("unknown input format %q", s)
+	}
+}
+
+// Calls f for each hash in the input.
+func readHashes(r io.Reader, format Format, field string, f func(hash string)) error {
+	switch format {
+	case Lines:
+		return readLines(r, f)
+	case CSV:
+		return readCSV(r, field, f)
+	case JSON:
+		return readJSON(r, field, f)
+	default:
+		return fmt.Errorf("unknown input format %d", format)
+	}
+}
+
+func readLines(r io.Reader, f func(string)) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line != "" {
+			f(line)
+		}
+	}
+
+	return s.Err()
+}
+
+func readCSV(r io.Reader, field string, f func(string)) error {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return err
+	}
+
+	col := -1
+	for i, name := range header {
+		if name == field {
+			col = i
+			break
+		}
+	}
+
+	if col < 0 {
+		return fmt.Errorf("CSV column %q not found", field)
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if col < len(record) {
+			f(record[col])
+		} else {
+			f("")
+		}
+	}
+}
+
+func readJSON(r io.Reader, field string, f func(string)) error {
+	br := bufio.NewReader(r)
+
+	// A top-level array is streamed element by element.
+	inArray := false
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' {
+			continue
+		}
+
+		inArray = c == '['
+		br.UnreadByte()
+		break
+	}
+
+	dec := json.NewDecoder(br)
+	if inArray {
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		if inArray && !dec.More() {
+			_, err := dec.Token()
+			return err
+		}
+
+		var obj map[string]json.RawMessage
+		err := dec.Decode(&obj)
+		if err == io.EOF && !inArray {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var hash string
+		if v, ok := obj[field]; ok {
+			json.Unmarshal(v, &hash)
+		}
+
+		f(hash)
+	}
+}
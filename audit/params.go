@@ -0,0 +1,325 @@
+package audit
+
+import (
+	"strconv"
+	"strings"
+
+	pbkdf2raw "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+	scryptraw "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+	springraw "gopkg.in/hlandau/passlib.v1/hash/spring/raw"
+	yraw "gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// Names for the unnamed numeric fields of some modular crypt formats, by
+// identifier.
+var fieldNames = map[string][]string{
+	"2":             {"cost"},
+	"2a":            {"cost"},
+	"2b":            {"cost"},
+	"2y":            {"cost"},
+	"pbkdf2":        {"rounds"},
+	"pbkdf2-sha256": {"rounds"},
+	"pbkdf2-sha512": {"rounds"},
+	"scram":         {"rounds"},
+	"s2":            {"N", "r", "p"},
+}
+
+// Extracts the identifier and parameters of a hash in the PHC string format
+// or a modular crypt format. Returns ok == false for hashes in other formats.
+//
+// For PHC strings, the version, if any, is returned as the parameter "v". For
+// modular crypt formats, fields of the form name=value are returned as
+// parameters, as are the numeric fields of formats with known field names
+// (e.g. "cost" for bcrypt). Salts and hashes are not returned.
+//
+// The costs of the following formats, which do not use named decimal
+// fields, are decoded: yescrypt and gost-yescrypt ("N", "r", "p" and, if
+// present, "t"), $7$ scrypt ("N", "r" and "p"), Firebase scrypt ("N" and "r",
+// the scrypt parameters derived from "m" and "r"), GRUB PBKDF2 and $p5k2$
+// PBKDF2 ("rounds"), and Spring Security {bcrypt}, {scrypt} and {argon2}
+// hashes, whose identifier includes the {id} prefix.
+func ParseParams(hash string) (id string, params []phc.Param, ok bool) {
+	if springID, encoded, ok := springraw.SplitID(hash); ok && springIDs[springID] {
+		id = "{" + springID + "}"
+		if springID == "scrypt" {
+			return id, parseSpringScrypt(encoded), true
+		}
+
+		_, params, _ = ParseParams(encoded)
+		return id, params, true
+	}
+
+	if strings.HasPrefix(hash, "grub.pbkdf2.sha512.") {
+		parts := strings.Split(hash, ".")
+		if len(parts) > 3 && isDigits(parts[3]) {
+			params = append(params, phc.Param{Name: "rounds", Value: parts[3]})
+		}
+
+		return "grub.pbkdf2.sha512", params, true
+	}
+
+	if h, err := phc.Parse(hash); err == nil && (h.Salt != nil || len(h.Params) > 0) {
+		if h.ID == "firebase-scrypt" {
+			return h.ID, parseFirebase(h), true
+		}
+
+		if h.HasVersion {
+			params = append(params, phc.Param{Name: "v", Value: strconv.Itoa(h.Version)})
+		}
+
+		return h.ID, append(params, h.Params...), true
+	}
+
+	if !strings.HasPrefix(hash, "$") {
+		return "", nil, false
+	}
+
+	parts := strings.Split(hash[1:], "$")
+	id = parts[0]
+	if len(parts) > 1 {
+		switch id {
+		case "y", "gy":
+			return id, parseYescrypt(parts[1]), true
+		case "7":
+			return id, parseScrypt7(parts[1]), true
+		case "p5k2":
+			return id, parseP5K2(parts[1]), true
+		}
+	}
+
+	names := fieldNames[id]
+	for i, part := range parts[1:] {
+		// Base64 padding is not a name=value field.
+		if j := strings.IndexByte(part, '='); j > 0 && j < len(part)-1 && !strings.Contains(part[j+1:], "=") && !strings.ContainsRune(part[0:j], ',') {
+			params = append(params, phc.Param{Name: part[0:j], Value: part[j+1:]})
+			continue
+		}
+
+		if len(names) == 0 || !isDigits(part) || i == len(parts)-2 {
+			continue
+		}
+
+		params = append(params, phc.Param{Name: names[0], Value: part})
+		names = names[1:]
+	}
+
+	return id, params, true
+}
+
+// Spring Security encoders whose hashes are parsed.
+var springIDs = map[string]bool{
+	"bcrypt": true,
+	"pbkdf2": true,
+	"scrypt": true,
+	"argon2": true,
+	"noop":   true,
+}
+
+// The decimal representation of 2^64, used for values which do not fit in a
+// uint64 and so exceed any limit.
+const overflow = "18446744073709551616"
+
+// Returns the decimal representation of 2^n.
+func pow2(n uint64) string {
+	if n >= 64 {
+		return overflow
+	}
+
+	return strconv.FormatUint(1<<n, 10)
+}
+
+func scryptParams(nLog2, r, p uint64) []phc.Param {
+	return []phc.Param{
+		{Name: "N", Value: pow2(nLog2)},
+		{Name: "r", Value: strconv.FormatUint(r, 10)},
+		{Name: "p", Value: strconv.FormatUint(p, 10)},
+	}
+}
+
+func parseYescrypt(s string) []phc.Param {
+	p, _, err := yraw.DecodeParams(s)
+	if err != nil {
+		return nil
+	}
+
+	params := []phc.Param{
+		{Name: "N", Value: strconv.FormatUint(p.N, 10)},
+		{Name: "r", Value: strconv.FormatUint(uint64(p.R), 10)},
+		{Name: "p", Value: strconv.FormatUint(uint64(p.P), 10)},
+	}
+	if p.T != 0 {
+		params = append(params, phc.Param{Name: "t", Value: strconv.FormatUint(uint64(p.T), 10)})
+	}
+
+	return params
+}
+
+// Decodes the log2(N), r and p fields at the start of a $7$ hash.
+func parseScrypt7(s string) []phc.Param {
+	nLog2, s, err := yraw.DecodeUint32Fixed(s, 6)
+	if err != nil {
+		return nil
+	}
+
+	r, s, err := yraw.DecodeUint32Fixed(s, 30)
+	if err != nil {
+		return nil
+	}
+
+	p, _, err := yraw.DecodeUint32Fixed(s, 30)
+	if err != nil {
+		return nil
+	}
+
+	return scryptParams(uint64(nLog2), uint64(r), uint64(p))
+}
+
+// Decodes the hexadecimal parameter field of a Spring {scrypt} hash, which
+// holds log2(N), r and p.
+func parseSpringScrypt(encoded string) []phc.Param {
+	parts := strings.Split(encoded, "$")
+	if len(parts) < 2 || parts[0] != "" {
+		return nil
+	}
+
+	v, err := strconv.ParseUint(parts[1], 16, 64)
+	if err != nil {
+		return nil
+	}
+
+	return scryptParams(v>>16, v>>8&0xFF, v&0xFF)
+}
+
+// Firebase scrypt uses N = 2^m and r = rounds.
+func parseFirebase(h *phc.Hash) []phc.Param {
+	var params []phc.Param
+	if m, err := h.ParamUint("m", 64); err == nil {
+		params = append(params, phc.Param{Name: "N", Value: pow2(m)})
+	}
+
+	if r, ok := h.Param("r"); ok {
+		params = append(params, phc.Param{Name: "r", Value: r})
+	}
+
+	return params
+}
+
+// Decodes the hexadecimal rounds field of a $p5k2$ hash, which is empty for
+// the dlitz default.
+func parseP5K2(s string) []phc.Param {
+	if s == "" {
+		return []phc.Param{{Name: "rounds", Value: strconv.Itoa(pbkdf2raw.DlitzDefaultRounds)}}
+	}
+
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		if err.(*strconv.NumError).Err != strconv.ErrRange {
+			return nil
+		}
+
+		return []phc.Param{{Name: "rounds", Value: overflow}}
+	}
+
+	return []phc.Param{{Name: "rounds", Value: strconv.FormatUint(v, 10)}}
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Limits beyond which verifying a hash is considered to be a denial of service
+// risk.
+type Limits struct {
+	// The maximum length of a hash string. Zero means no limit.
+	MaxLength int
+
+	// The maximum value of each named numeric parameter, as returned by
+	// ParseParams.
+	MaxParams map[string]uint64
+
+	// The maximum memory in bytes required by a hash with scrypt parameters
+	// (N or ln, r and p), which is 128·r·(N+p). Zero means no limit.
+	MaxMemory uint64
+}
+
+// Conservative default limits. Verifying a hash within these limits may
+// still take several seconds. The memory limit is that enforced by the
+// scrypt and yescrypt parsers.
+var DefaultLimits = Limits{
+	MaxLength: 1024,
+	MaxParams: map[string]uint64{
+		"rounds": 10000000,        // sha-crypt, pbkdf2, scram
+		"cost":   20,              // bcrypt
+		"t":      1000,            // argon2, balloon
+		"m":      4 * 1024 * 1024, // argon2, in KiB
+		"p":      64,              // scrypt, yescrypt, argon2, balloon
+		"s":      1 << 24,         // balloon, in blocks
+	},
+	MaxMemory: scryptraw.MaxMemory,
+}
+
+// Returns true if the hash exceeds the limits. Parameters which are not
+// decimal integers are ignored.
+func (l *Limits) Exceeded(hash string) bool {
+	if l.MaxLength != 0 && len(hash) > l.MaxLength {
+		return true
+	}
+
+	_, params, _ := ParseParams(hash)
+	values := make(map[string]uint64, len(params))
+	for _, p := range params {
+		v, err := strconv.ParseUint(p.Value, 10, 64)
+		if err != nil && err.(*strconv.NumError).Err == strconv.ErrRange {
+			return true
+		} else if err != nil {
+			continue
+		}
+
+		values[p.Name] = v
+		if max, ok := l.MaxParams[p.Name]; ok && v > max {
+			return true
+		}
+	}
+
+	return l.MaxMemory != 0 && l.exceedsMemory(values)
+}
+
+func (l *Limits) exceedsMemory(values map[string]uint64) bool {
+	N, haveN := values["N"]
+	if ln, ok := values["ln"]; ok && !haveN {
+		if ln > 63 {
+			return true
+		}
+
+		N, haveN = 1<<ln, true
+	}
+
+	r, haveR := values["r"]
+	if !haveN || !haveR {
+		return false
+	}
+
+	p, ok := values["p"]
+	if !ok {
+		p = 1
+	}
+
+	// Dividing avoids overflow: 128·(N+p) cannot overflow once N and p are
+	// known to be within the limit.
+	if N > l.MaxMemory || p > l.MaxMemory {
+		return true
+	}
+
+	return r > l.MaxMemory/(128*(N+p))
+}
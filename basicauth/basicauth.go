@@ -0,0 +1,166 @@
+// Package basicauth provides net/http middleware which authenticates requests
+// using HTTP Basic authentication against password hashes held in a Store.
+//
+// Hashes are verified using a passlib.Context. Upgrade hashes issued by the
+// context are written back to the store, and a dummy verification is
+// performed for unknown users so that they cannot be distinguished by timing.
+package basicauth
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"gopkg.in/hlandau/passlib.v1"
+)
+
+// A store of password hashes, keyed by user name. Implementations must be
+// safe for concurrent use.
+type Store interface {
+	// Returns the hash for a user. ok is false if the user does not exist.
+	Get(ctx context.Context, user string) (hash string, ok bool, err error)
+
+	// Replaces the hash for a user with an upgraded hash.
+	Update(ctx context.Context, user, hash string) error
+}
+
+// Middleware configuration.
+type Config struct {
+	// The store containing password hashes. Required.
+	Store Store
+
+	// The realm sent to clients in the WWW-Authenticate header. Defaults to
+	// "Restricted".
+	Realm string
+
+	// The context used to verify passwords. If nil, the default context is
+	// used.
+	Context *passlib.Context
+
+	// The maximum number of password verifications performed concurrently.
+	// Requests wait for a slot, so that a flood of login attempts cannot
+	// exhaust memory or CPU. Defaults to runtime.NumCPU().
+	MaxConcurrent int
+
+	// Called with errors returned by the store. If nil, such errors are
+	// ignored, though a failure to get a hash still fails the request.
+	ErrorFunc func(r *http.Request, err error)
+}
+
+type middleware struct {
+	cfg     Config
+	ctx     *passlib.Context
+	sem     chan struct{}
+	next    http.Handler
+	header  string
+	dummyMu sync.Mutex
+	dummy   string
+}
+
+type contextKey struct{}
+
+// Returns middleware which requires requests to be authenticated with a user
+// name and password matching the store before passing them to the next
+// handler. Unauthenticated requests receive a 401 response.
+func New(cfg Config) func(next http.Handler) http.Handler {
+	ctx := cfg.Context
+	if ctx == nil {
+		ctx = &passlib.DefaultContext
+	}
+
+	if cfg.Realm == "" {
+		cfg.Realm = "Restricted"
+	}
+
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = runtime.NumCPU()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &middleware{
+			cfg:    cfg,
+			ctx:    ctx,
+			sem:    make(chan struct{}, cfg.MaxConcurrent),
+			next:   next,
+			header: "Basic realm=" + strconv.Quote(cfg.Realm) + `, charset="UTF-8"`,
+		}
+	}
+}
+
+// Returns the authenticated user name for a request passed to the next
+// handler by the middleware, or "" if there is none.
+func User(r *http.Request) string {
+	user, _ := r.Context().Value(contextKey{}).(string)
+	return user
+}
+
+func (m *middleware) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		m.unauthorized(rw)
+		return
+	}
+
+	hash, known, err := m.cfg.Store.Get(req.Context(), user)
+	if err != nil {
+		m.error(req, err)
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	select {
+	case m.sem <- struct{}{}:
+	case <-req.Context().Done():
+		http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		return
+	}
+
+	var newHash string
+	if known {
+		newHash, err = m.ctx.Verify(password, hash)
+	} else {
+		m.ctx.VerifyNoUpgrade(password, m.dummyHash())
+	}
+
+	<-m.sem
+
+	if !known || err != nil {
+		m.unauthorized(rw)
+		return
+	}
+
+	if newHash != "" {
+		if err := m.cfg.Store.Update(req.Context(), user, newHash); err != nil {
+			m.error(req, err)
+		}
+	}
+
+	m.next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), contextKey{}, user)))
+}
+
+// Returns a hash of the empty password using the preferred scheme, which is
+// verified for unknown users so that they take as long to reject as known
+// users.
+func (m *middleware) dummyHash() string {
+	m.dummyMu.Lock()
+	defer m.dummyMu.Unlock()
+
+	if m.dummy == "" {
+		m.dummy, _ = m.ctx.Hash("")
+	}
+
+	return m.dummy
+}
+
+func (m *middleware) unauthorized(rw http.ResponseWriter) {
+	rw.Header().Set("WWW-Authenticate", m.header)
+	http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func (m *middleware) error(req *http.Request, err error) {
+	if m.cfg.ErrorFunc != nil {
+		m.cfg.ErrorFunc(req, err)
+	}
+}
@@ -0,0 +1,124 @@
+package basicauth
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+)
+
+func testContext() *passlib.Context {
+	return &passlib.Context{
+		Schemes: []abstract.Scheme{sha2crypt.NewCrypter256(1000), md5crypt.Crypter},
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Get(ctx context.Context, user string) (string, bool, error) {
+	return "", false, fmt.Errorf("store unavailable")
+}
+
+func (failingStore) Update(ctx context.Context, user, hash string) error {
+	return fmt.Errorf("store unavailable")
+}
+
+func echoUser(rw http.ResponseWriter, req *http.Request) {
+	fmt.Fprint(rw, User(req))
+}
+
+func request(t *testing.T, srv *httptest.Server, user, password string) (*http.Response, string) {
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user != "" {
+		req.SetBasicAuth(user, password)
+	}
+
+	res, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	store := NewMapStore(map[string]string{"alice": "$1$ab$oKsM6dtDD2L1bKowOBX.7."})
+	h := New(Config{Store: store, Realm: "test", Context: testContext()})(http.HandlerFunc(echoUser))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	for _, c := range [][2]string{{"", ""}, {"alice", "wrong"}, {"bob", "password"}} {
+		res, _ := request(t, srv, c[0], c[1])
+		if res.StatusCode != http.StatusUnauthorized || res.Header.Get("WWW-Authenticate") != `Basic realm="test", charset="UTF-8"` {
+			t.Errorf("%q: unexpected response: %v %v", c[0], res.StatusCode, res.Header)
+		}
+	}
+
+	res, body := request(t, srv, "alice", "password")
+	if res.StatusCode != http.StatusOK || body != "alice" {
+		t.Fatalf("valid password rejected: %v %q", res.StatusCode, body)
+	}
+
+	// The md5-crypt hash is upgraded to the preferred scheme.
+	if hash, _, _ := store.Get(context.Background(), "alice"); !strings.HasPrefix(hash, "$5$rounds=1000$") {
+		t.Errorf("hash not upgraded: %q", hash)
+	}
+
+	if res, body := request(t, srv, "alice", "password"); res.StatusCode != http.StatusOK || body != "alice" {
+		t.Errorf("upgraded hash rejected: %v %q", res.StatusCode, body)
+	}
+}
+
+func TestStoreError(t *testing.T) {
+	var errs []error
+	h := New(Config{
+		Store:     failingStore{},
+		ErrorFunc: func(r *http.Request, err error) { errs = append(errs, err) },
+	})(http.HandlerFunc(echoUser))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("alice", "password")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError || len(errs) != 1 {
+		t.Errorf("unexpected response: %v %v", rec.Code, errs)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	store := NewMapStore(map[string]string{"alice": "$1$ab$oKsM6dtDD2L1bKowOBX.7."})
+	h := New(Config{Store: store, Context: testContext(), MaxConcurrent: 1})(http.HandlerFunc(echoUser))
+
+	// Occupy the only verification slot, so that a request waits until it is
+	// cancelled.
+	h.(*middleware).sem <- struct{}{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	req.SetBasicAuth("alice", "password")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("unexpected response: %v", rec.Code)
+	}
+}
@@ -0,0 +1,39 @@
+package basicauth
+
+import (
+	"context"
+	"sync"
+)
+
+// A Store holding hashes in memory, keyed by user name. It is mainly useful
+// for tests and for small, statically configured user lists.
+type MapStore struct {
+	mu     sync.RWMutex
+	hashes map[string]string
+}
+
+// Returns a MapStore containing a copy of the given hashes.
+func NewMapStore(hashes map[string]string) *MapStore {
+	s := &MapStore{hashes: map[string]string{}}
+	for user, hash := range hashes {
+		s.hashes[user] = hash
+	}
+
+	return s
+}
+
+func (s *MapStore) Get(ctx context.Context, user string) (hash string, ok bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, ok = s.hashes[user]
+	return hash, ok, nil
+}
+
+func (s *MapStore) Update(ctx context.Context, user, hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.hashes[user] = hash
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/audit"
+)
+
+func cmdAudit(e *env, args []string) int {
+	fs := e.flagSet("audit")
+	policy := fs.String("policy", "", "policy file describing the context to audit against")
+	defaults := fs.String("defaults", passlib.DefaultsLatest, "defaults date passed to UseDefaults")
+	format := fs.String("format", "lines", "input format: lines, csv or json")
+	field := fs.String("field", "hash", "CSV column or JSON member containing the hash")
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	if fs.Parse(args) != nil {
+		return exitError
+	}
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	f, err := audit.ParseFormat(*format)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	ctx, err := loadContext(*policy, *defaults)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	var r io.Reader = e.stdin
+	if fs.NArg() == 1 {
+		file, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return e.fail(err)
+		}
+		defer file.Close()
+
+		r = file
+	}
+
+	report, err := audit.Run(r, &audit.Options{Context: ctx, Format: f, Field: *field})
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = report.WriteText(e.stdout)
+	}
+	if err != nil {
+		return e.fail(err)
+	}
+
+	return exitOK
+}
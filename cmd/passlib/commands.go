@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/phc"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func cmdHash(e *env, args []string) int {
+	fs := e.flagSet("hash")
+	scheme := fs.String("scheme", "", "scheme specification, e.g. argon2id:t=3")
+	policy := fs.String("policy", "", "policy file whose preferred scheme is used")
+	defaults := fs.String("defaults", passlib.DefaultsLatest, "defaults date passed to UseDefaults")
+	if _, ok := parseArgs(fs, args, 0); !ok {
+		return exitError
+	}
+
+	var ctx *passlib.Context
+	var err error
+	if *scheme != "" {
+		ctx, err = passlib.NewContext(*scheme)
+		if err != nil {
+			if spec, _ := registry.ParseSpec(*scheme); registry.Lookup(spec.Name) == nil {
+				err = fmt.Errorf("%v\navailable schemes: %s", err, strings.Join(registry.Names(), " "))
+			}
+		}
+	} else {
+		ctx, err = loadContext(*policy, *defaults)
+	}
+	if err != nil {
+		return e.fail(err)
+	}
+
+	password, err := e.readPassword(true)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	hash, err := ctx.Hash(password)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	fmt.Fprintln(e.stdout, hash)
+	return exitOK
+}
+
+func cmdVerify(e *env, args []string) int {
+	fs := e.flagSet("verify")
+	policy := fs.String("policy", "", "policy file used to determine whether to upgrade")
+	defaults := fs.String("defaults", passlib.DefaultsLatest, "defaults date passed to UseDefaults")
+	upgrade := fs.Bool("upgrade", false, "print an upgraded hash if the hash needs updating")
+	a, ok := parseArgs(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+
+	hash := a[0]
+	ctx, err := loadContext(*policy, *defaults)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	// Hashes in schemes outside the policy are verified using any registered
+	// scheme which supports them, and always need an upgrade.
+	var candidates []abstract.Scheme
+	if !supports(ctx.Schemes, hash) {
+		_, schemes := registeredSchemes()
+		for _, scheme := range schemes {
+			if scheme.SupportsStub(hash) {
+				candidates = append(candidates, scheme)
+			}
+		}
+
+		if len(candidates) == 0 {
+			return e.fail(abstract.ErrUnsupportedScheme)
+		}
+	}
+
+	password, err := e.readPassword(false)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	var newHash string
+	if candidates == nil {
+		if *upgrade {
+			newHash, err = ctx.Verify(password, hash)
+		} else {
+			err = ctx.VerifyNoUpgrade(password, hash)
+		}
+	} else {
+		for _, scheme := range candidates {
+			if err = scheme.Verify(password, hash); err == nil {
+				break
+			}
+		}
+
+		if err == nil && *upgrade {
+			newHash, err = ctx.Hash(password)
+		}
+	}
+
+	if err == abstract.ErrInvalidPassword {
+		fmt.Fprintln(e.stdout, "invalid")
+		return exitNegative
+	} else if err != nil {
+		return e.fail(err)
+	}
+
+	fmt.Fprintln(e.stdout, "valid")
+	if newHash != "" {
+		fmt.Fprintln(e.stdout, newHash)
+	}
+
+	return exitOK
+}
+
+func cmdIdentify(e *env, args []string) int {
+	fs := e.flagSet("identify")
+	a, ok := parseArgs(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+
+	hash := a[0]
+	names, schemes := registeredSchemes()
+
+	found := false
+	for i, scheme := range schemes {
+		if scheme.SupportsStub(hash) {
+			fmt.Fprintf(e.stdout, "scheme: %s\n", names[i])
+			found = true
+		}
+	}
+
+	if !found {
+		return e.fail(abstract.ErrUnsupportedScheme)
+	}
+
+	for _, f := range describe(hash) {
+		fmt.Fprintf(e.stdout, "%s: %s\n", f[0], f[1])
+	}
+
+	return exitOK
+}
+
+// Returns the fields of a hash in a PHC string or modular crypt format.
+func describe(hash string) [][2]string {
+	var fields [][2]string
+
+	if h, err := phc.Parse(hash); err == nil && (h.Salt != nil || len(h.Params) > 0) {
+		fields = append(fields, [2]string{"id", h.ID})
+		if h.HasVersion {
+			fields = append(fields, [2]string{"version", fmt.Sprint(h.Version)})
+		}
+
+		for _, p := range h.Params {
+			fields = append(fields, [2]string{"param " + p.Name, p.Value})
+		}
+
+		if h.Salt != nil {
+			fields = append(fields, [2]string{"salt", fmt.Sprintf("%s (%d bytes)", phc.EncodeBase64(h.Salt), len(h.Salt))})
+		}
+
+		if h.Hash != nil {
+			fields = append(fields, [2]string{"hash", fmt.Sprintf("%d bytes", len(h.Hash))})
+		}
+
+		return fields
+	}
+
+	if strings.HasPrefix(hash, "$") {
+		parts := strings.Split(hash[1:], "$")
+		fields = append(fields, [2]string{"id", parts[0]})
+		for i, part := range parts[1:] {
+			name := fmt.Sprintf("field %d", i+1)
+			if j := strings.IndexByte(part, '='); j > 0 && !strings.ContainsAny(part[0:j], ",") {
+				name = "param " + part[0:j]
+				part = part[j+1:]
+			}
+
+			fields = append(fields, [2]string{name, part})
+		}
+	}
+
+	return fields
+}
+
+func cmdNeedsUpdate(e *env, args []string) int {
+	fs := e.flagSet("needs-update")
+	policy := fs.String("policy", "", "policy file (required)")
+	a, ok := parseArgs(fs, args, 1)
+	if !ok {
+		return exitError
+	}
+
+	if *policy == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	ctx, err := loadPolicy(*policy)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	if !supports(ctx.Schemes, a[0]) {
+		return e.fail(fmt.Errorf("hash is not supported by any scheme in the policy"))
+	}
+
+	if ctx.NeedsUpdate(a[0]) {
+		fmt.Fprintln(e.stdout, "yes")
+		return exitNegative
+	}
+
+	fmt.Fprintln(e.stdout, "no")
+	return exitOK
+}
+
+func cmdBench(e *env, args []string) int {
+	fs := e.flagSet("bench")
+	defaults := fs.String("defaults", passlib.DefaultsLatest, "defaults date passed to UseDefaults")
+	n := fs.Int("n", 3, "number of iterations per scheme")
+	if _, ok := parseArgs(fs, args, 0); !ok {
+		return exitError
+	}
+
+	if *n < 1 {
+		fs.Usage()
+		return exitError
+	}
+
+	ctx, err := loadContext("", *defaults)
+	if err != nil {
+		return e.fail(err)
+	}
+
+	const password = "benchmark password"
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "scheme\thash\tverify\t")
+	for _, scheme := range ctx.Schemes {
+		if abstract.IsVerifyOnly(scheme) {
+			continue
+		}
+
+		var hash string
+		start := time.Now()
+		for i := 0; i < *n; i++ {
+			hash, err = scheme.Hash(password)
+			if err != nil {
+				return e.fail(fmt.Errorf("%v: %v", scheme, err))
+			}
+		}
+		hashTime := time.Since(start) / time.Duration(*n)
+
+		start = time.Now()
+		for i := 0; i < *n; i++ {
+			if err := scheme.Verify(password, hash); err != nil {
+				return e.fail(fmt.Errorf("%v: %v", scheme, err))
+			}
+		}
+		verifyTime := time.Since(start) / time.Duration(*n)
+
+		fmt.Fprintf(tw, "%v\t%v\t%v\t\n", scheme, hashTime.Round(time.Microsecond), verifyTime.Round(time.Microsecond))
+	}
+
+	tw.Flush()
+	return exitOK
+}
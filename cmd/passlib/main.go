@@ -0,0 +1,190 @@
+// Command passlib hashes and verifies passwords, identifies password hashes
+// and benchmarks password hashing schemes.
+//
+// Usage:
+//
+//	passlib hash [-scheme spec | -policy file] [-defaults date]
+//	passlib verify [-policy file] [-defaults date] [-upgrade] hash
+//	passlib identify hash
+//	passlib needs-update -policy file hash
+//	passlib bench [-defaults date] [-n count]
+//	passlib audit [-policy file] [-defaults date] [-format lines|csv|json] [-field name] [-json] [file]
+//
+// Passwords are read from the terminal with echo disabled or, if standard
+// input is not a terminal, as a single line from standard input.
+//
+// Scheme specifications are in the format accepted by registry.ParseSpec, for
+// example "argon2id:t=3,m=65536". A policy file contains one scheme
+// specification per line, most preferred first; blank lines and lines
+// beginning with '#' are ignored.
+//
+// The exit status is 0 on success, 1 if a password is invalid or a hash needs
+// updating, and 2 on any other error.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/registry"
+
+	// Register all schemes.
+	_ "gopkg.in/hlandau/passlib.v1/hash/aspnet"
+	_ "gopkg.in/hlandau/passlib.v1/hash/balloon"
+	_ "gopkg.in/hlandau/passlib.v1/hash/cisco"
+	_ "gopkg.in/hlandau/passlib.v1/hash/descrypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/mysql"
+	_ "gopkg.in/hlandau/passlib.v1/hash/postgres"
+	_ "gopkg.in/hlandau/passlib.v1/hash/scram"
+	_ "gopkg.in/hlandau/passlib.v1/hash/spring"
+	_ "gopkg.in/hlandau/passlib.v1/hash/windows"
+	_ "gopkg.in/hlandau/passlib.v1/hash/yescrypt"
+)
+
+const (
+	exitOK       = 0
+	exitNegative = 1
+	exitError    = 2
+)
+
+type command struct {
+	name, args string
+	run        func(e *env, args []string) int
+}
+
+var commands = []command{
+	{"hash", "[-scheme spec | -policy file] [-defaults date]", cmdHash},
+	{"verify", "[-policy file] [-defaults date] [-upgrade] hash", cmdVerify},
+	{"identify", "hash", cmdIdentify},
+	{"needs-update", "-policy file hash", cmdNeedsUpdate},
+	{"bench", "[-defaults date] [-n count]", cmdBench},
+	{"audit", "[-policy file] [-defaults date] [-format lines|csv|json] [-field name] [-json] [file]", cmdAudit},
+}
+
+// The standard streams, which are replaced in tests.
+type env struct {
+	stdin          io.Reader
+	stdout, stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(&env{os.Stdin, os.Stdout, os.Stderr}, os.Args[1:]))
+}
+
+func run(e *env, args []string) int {
+	if len(args) > 0 {
+		for _, c := range commands {
+			if c.name == args[0] {
+				return c.run(e, args[1:])
+			}
+		}
+	}
+
+	fmt.Fprintf(e.stderr, "usage:\n")
+	for _, c := range commands {
+		fmt.Fprintf(e.stderr, "  passlib %s %s\n", c.name, c.args)
+	}
+
+	return exitError
+}
+
+func (e *env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("passlib "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+func (e *env) fail(err error) int {
+	fmt.Fprintf(e.stderr, "passlib: %v\n", err)
+	return exitError
+}
+
+// Parses flags and returns the single positional argument, if one is
+// expected.
+func parseArgs(fs *flag.FlagSet, args []string, nargs int) ([]string, bool) {
+	if fs.Parse(args) != nil {
+		return nil, false
+	}
+
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return nil, false
+	}
+
+	return fs.Args(), true
+}
+
+// Returns the context described by the policy file, or if policy is empty,
+// the default context using the defaults for the given date.
+func loadContext(policy, defaults string) (*passlib.Context, error) {
+	if policy != "" {
+		return loadPolicy(policy)
+	}
+
+	if err := passlib.UseDefaults(defaults); err != nil {
+		return nil, err
+	}
+
+	return &passlib.Context{Schemes: passlib.DefaultSchemes}, nil
+}
+
+func loadPolicy(filename string) (*passlib.Context, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		specs = append(specs, line)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("%s: no schemes specified", filename)
+	}
+
+	ctx, err := passlib.NewContext(specs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	return ctx, nil
+}
+
+// Returns a scheme for every registered factory which can be constructed
+// with its default parameters, together with its name.
+func registeredSchemes() (names []string, schemes []abstract.Scheme) {
+	for _, name := range registry.Names() {
+		scheme, err := registry.New(name, nil)
+		if err != nil {
+			continue
+		}
+
+		names = append(names, name)
+		schemes = append(schemes, scheme)
+	}
+
+	return
+}
+
+func supports(schemes []abstract.Scheme, hash string) bool {
+	for _, scheme := range schemes {
+		if scheme.SupportsStub(hash) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runTest(stdin string, args ...string) (code int, stdout, stderr string) {
+	var o, e bytes.Buffer
+	code = run(&env{strings.NewReader(stdin), &o, &e}, args)
+	return code, o.String(), e.String()
+}
+
+func TestHashVerify(t *testing.T) {
+	code, out, errs := runTest("secret\n", "hash", "-scheme", "sha256-crypt:rounds=6000")
+	if code != exitOK || !strings.HasPrefix(out, "$5$rounds=6000$") {
+		t.Fatalf("hash failed: %d %q %q", code, out, errs)
+	}
+
+	hash := strings.TrimSpace(out)
+	if code, out, _ := runTest("secret\n", "verify", hash); code != exitOK || out != "valid\n" {
+		t.Errorf("verify failed: %d %q", code, out)
+	}
+
+	if code, out, _ := runTest("wrong\n", "verify", hash); code != exitNegative || out != "invalid\n" {
+		t.Errorf("invalid password accepted: %d %q", code, out)
+	}
+
+	// NT hashes are not in the default schemes, so an upgrade is always
+	// produced.
+	code, out, _ = runTest("password", "verify", "-upgrade", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+	if lines := strings.Split(out, "\n"); code != exitOK || len(lines) != 3 || lines[0] != "valid" || lines[1] == "" {
+		t.Errorf("verify with upgrade failed: %d %q", code, out)
+	}
+
+	if code, _, _ := runTest("secret\n", "hash", "-scheme", "nonexistent"); code != exitError {
+		t.Errorf("unknown scheme accepted")
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	code, out, _ := runTest("", "identify", "$argon2id$v=19$m=32768,t=4,p=4$c2FsdHNhbHRzYWx0c2FsdA$iOnY2XcL2dFWUpKU+YfZJmhtu49NdOyv0lkqJtxzfBY")
+	if code != exitOK || !strings.Contains(out, "scheme: argon2id\n") || !strings.Contains(out, "param m: 32768\n") {
+		t.Errorf("identify failed: %d %q", code, out)
+	}
+
+	if code, _, _ := runTest("", "identify", "nonsense"); code != exitError {
+		t.Errorf("unknown hash identified")
+	}
+}
+
+func TestNeedsUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "passlib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	policy := filepath.Join(dir, "policy")
+	err = ioutil.WriteFile(policy, []byte("# preferred\nsha512-crypt:rounds=6000\n\nmd5-crypt\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []struct {
+		hash string
+		code int
+	}{
+		{"$6$rounds=6000$FWHSPCjIHIjXxhES$MjTVjFmavGgq6XnmHehmet1YwL6d9v/4hFwibxCYhNb41/YfgVDPV6WUskg5FYLa8BpMZG8yG6rGITbssP5ph1", exitOK},
+		{"$6$rounds=5000$FWHSPCjIHIjXxhES$MjTVjFmavGgq6XnmHehmet1YwL6d9v/4hFwibxCYhNb41/YfgVDPV6WUskg5FYLa8BpMZG8yG6rGITbssP5ph1", exitNegative},
+		{"$1$ab$oKsM6dtDD2L1bKowOBX.7.", exitNegative},
+		{"$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6", exitError},
+	} {
+		if code, out, errs := runTest("", "needs-update", "-policy", policy, v.hash); code != v.code {
+			t.Errorf("unexpected result: %s: %d %q %q", v.hash, code, out, errs)
+		}
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	for _, v := range []struct{ in, out string }{
+		{"abc\n", "abc"},
+		{"abc\r\ndef\n", "abc"},
+		{"abc", "abc"},
+		{"\n", ""},
+	} {
+		b, err := readLine(strings.NewReader(v.in))
+		if err != nil || string(b) != v.out {
+			t.Errorf("mismatch: %q: %v %q", v.in, err, b)
+		}
+	}
+
+	if _, err := readLine(strings.NewReader("")); err == nil {
+		t.Errorf("empty input accepted")
+	}
+}
+
+func TestAudit(t *testing.T) {
+	input := "id,hash\n1,$5$rounds=1000$saltsalt$azOwbpkvuuBKkE82dQPwTsQE8JyT9Fflpr9aKid3aT9\n2,garbage\n"
+	code, out, errs := runTest(input, "audit", "-format", "csv", "-json")
+	if code != exitOK || !strings.Contains(out, `"total": 2`) || !strings.Contains(out, `"sha256-crypt": 1`) || !strings.Contains(out, `"malformed": 1`) {
+		t.Errorf("audit failed: %d %q %q", code, out, errs)
+	}
+
+	if code, _, _ := runTest(input, "audit", "-format", "xml"); code != exitError {
+		t.Errorf("unknown format accepted")
+	}
+}
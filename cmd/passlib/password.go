@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Reads a password. If standard input is a terminal, the user is prompted and
+// echo is disabled; if confirm is true, the password must be entered twice.
+// Otherwise, a single line is read from standard input.
+func (e *env) readPassword(confirm bool) (string, error) {
+	f, ok := e.stdin.(*os.File)
+	if !ok || !isTerminal(f) {
+		b, err := readLine(e.stdin)
+		return string(b), err
+	}
+
+	fmt.Fprint(e.stderr, "Password: ")
+	b, err := readNoEcho(f)
+	fmt.Fprintln(e.stderr)
+	if err != nil {
+		return "", err
+	}
+
+	if confirm {
+		fmt.Fprint(e.stderr, "Confirm password: ")
+		b2, err := readNoEcho(f)
+		fmt.Fprintln(e.stderr)
+		if err != nil {
+			return "", err
+		}
+
+		if string(b) != string(b2) {
+			return "", fmt.Errorf("passwords do not match")
+		}
+	}
+
+	return string(b), nil
+}
+
+// Reads a line, without the line terminator. The input is read a byte at a
+// time so that nothing after the line is consumed.
+func readLine(r io.Reader) ([]byte, error) {
+	var line []byte
+	var buf [1]byte
+	for {
+		n, err := r.Read(buf[:])
+		if n > 0 {
+			if buf[0] == '\n' {
+				break
+			}
+
+			line = append(line, buf[0])
+			continue
+		}
+
+		if err == io.EOF {
+			if len(line) == 0 {
+				return nil, fmt.Errorf("no password given")
+			}
+
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[0 : len(line)-1]
+	}
+
+	return line, nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package main
+
+import "os"
+
+// Echo cannot be disabled on this platform, so passwords are always read as
+// lines from standard input.
+func isTerminal(f *os.File) bool {
+	return false
+}
+
+func readNoEcho(f *os.File) ([]byte, error) {
+	return readLine(f)
+}
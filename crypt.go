@@ -0,0 +1,384 @@
+package passlib
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	argon2 "gopkg.in/hlandau/passlib.v1/hash/argon2/raw"
+	bcrypt "gopkg.in/hlandau/passlib.v1/hash/bcrypt/raw"
+	descrypt "gopkg.in/hlandau/passlib.v1/hash/descrypt/raw"
+	md5crypt "gopkg.in/hlandau/passlib.v1/hash/md5crypt/raw"
+	scrypt "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+	sha2crypt "gopkg.in/hlandau/passlib.v1/hash/sha2crypt/raw"
+	yescrypt "gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// Indicates that CryptGensalt does not support the given prefix.
+var ErrUnsupportedPrefix = fmt.Errorf("unsupported crypt prefix")
+
+// Indicates that the count passed to CryptGensalt is out of range for the
+// given prefix.
+var ErrInvalidCount = fmt.Errorf("invalid crypt count")
+
+// Hashes key using the setting, in the manner of crypt(3). The setting is a
+// salt string as returned by CryptGensalt, or an existing hash, in which case
+// the result equals that hash if key is correct.
+//
+// The method is chosen by the prefix of the setting: "$1$" (md5-crypt), "$5$"
+// and "$6$" (sha-crypt), "$2a$", "$2b$" and "$2y$" (bcrypt), "$y$" and "$gy$"
+// (yescrypt), "$7$" (scrypt), "_" (BSDi extended DES) or none (traditional
+// DES). For these methods the output is identical to that of libxcrypt. In
+// addition, "$argon2i$" and "$argon2id$" settings in the PHC string format are
+// supported.
+//
+// As in C, key is truncated at the first NUL byte.
+//
+// On failure, Crypt returns a string which cannot be a valid hash: "*0", or
+// "*1" if the setting begins with "*0", as libxcrypt does. Callers comparing
+// the result against a stored hash therefore need not check for failure.
+func Crypt(key, setting string) string {
+	if i := strings.IndexByte(key, 0); i >= 0 {
+		key = key[0:i]
+	}
+
+	hash, err := "", errInvalidSetting
+	if isSafeSetting(setting) {
+		hash, err = crypt(key, setting)
+	}
+
+	if err != nil {
+		if strings.HasPrefix(setting, "*0") {
+			return "*1"
+		}
+
+		return "*0"
+	}
+
+	return hash
+}
+
+var errInvalidSetting = fmt.Errorf("invalid crypt setting")
+
+func crypt(key, setting string) (string, error) {
+	switch {
+	case strings.HasPrefix(setting, "$1$"):
+		return md5crypt.Crypt(key, cryptSalt(setting[3:], md5crypt.MaxSaltLength)), nil
+
+	case strings.HasPrefix(setting, "$5$"), strings.HasPrefix(setting, "$6$"):
+		return cryptSHA2(key, setting)
+
+	case strings.HasPrefix(setting, "$2"):
+		return bcrypt.CryptSetting(key, setting)
+
+	case strings.HasPrefix(setting, "$y$"), strings.HasPrefix(setting, "$gy$"):
+		return yescrypt.CryptSetting(key, setting)
+
+	case strings.HasPrefix(setting, "$7$"):
+		// The salt extends to the last "$", and must otherwise consist of crypt
+		// base64 characters.
+		if len(setting) > 14 {
+			salt := setting[14:]
+			if i := strings.LastIndexByte(salt, '$'); i >= 0 {
+				salt = salt[0:i]
+			}
+
+			if !isCryptBase64(strings.Replace(salt, "$", "", -1)) {
+				return "", errInvalidSetting
+			}
+		}
+
+		return scrypt.Crypt7Setting(key, setting)
+
+	case strings.HasPrefix(setting, "$argon2"):
+		return cryptArgon2(key, setting)
+
+	case strings.HasPrefix(setting, "_"):
+		return descrypt.CryptExtended(key, setting)
+
+	case strings.HasPrefix(setting, "$"):
+		return "", errInvalidSetting
+
+	default:
+		return descrypt.Crypt(key, setting)
+	}
+}
+
+// Returns the salt at the start of s, which ends at the first "$" and is
+// truncated to maxLength characters.
+func cryptSalt(s string, maxLength int) string {
+	if i := strings.IndexByte(s, '$'); i >= 0 {
+		s = s[0:i]
+	}
+
+	if len(s) > maxLength {
+		s = s[0:maxLength]
+	}
+
+	return s
+}
+
+// Returns false if the setting contains characters which libxcrypt rejects
+// for all methods: whitespace, control characters, non-ASCII characters, and
+// "!*:;\\".
+func isSafeSetting(setting string) bool {
+	for i := 0; i < len(setting); i++ {
+		if setting[i] <= ' ' || setting[i] >= 0x7f || strings.IndexByte("!*:;\\", setting[i]) >= 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isCryptBase64(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '.' || c == '/' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func cryptSHA2(key, setting string) (string, error) {
+	rest := setting[3:]
+	rounds, explicit := sha2crypt.DefaultRounds, false
+	if strings.HasPrefix(rest, "rounds=") {
+		i := strings.IndexByte(rest, '$')
+		if i < 0 {
+			return "", errInvalidSetting
+		}
+
+		// Leading zeroes are rejected, as by libxcrypt.
+		s := rest[7:i]
+		n, err := strconv.ParseUint(s, 10, 32)
+		if err != nil || s[0] == '0' || n < sha2crypt.MinimumRounds || n > sha2crypt.MaximumRounds {
+			return "", errInvalidSetting
+		}
+
+		rounds, explicit = int(n), true
+		rest = rest[i+1:]
+	}
+
+	salt := cryptSalt(rest, 16)
+
+	var hash string
+	if setting[1] == '5' {
+		hash = sha2crypt.Crypt256(key, salt, rounds)
+	} else {
+		hash = sha2crypt.Crypt512(key, salt, rounds)
+	}
+
+	// The default number of rounds is omitted by sha2crypt.Crypt256 and
+	// Crypt512, but is retained if given explicitly.
+	if explicit && rounds == sha2crypt.DefaultRounds {
+		hash = hash[0:3] + "rounds=" + strconv.Itoa(rounds) + "$" + hash[3:]
+	}
+
+	return hash, nil
+}
+
+func cryptArgon2(key, setting string) (string, error) {
+	variant, salt, _, version, time, memory, threads, err := argon2.ParseVariant(setting)
+	if err != nil {
+		return "", err
+	}
+
+	if version != 0x13 || memory < 8*uint32(threads) {
+		return "", errInvalidSetting
+	}
+
+	if variant == "argon2id" {
+		return argon2.Argon2ID(key, salt, time, memory, threads), nil
+	}
+
+	return argon2.Argon2(key, salt, time, memory, threads), nil
+}
+
+// Generates a setting string for Crypt, in the manner of libxcrypt's
+// crypt_gensalt. prefix selects the method as described for Crypt, with ""
+// selecting traditional DES. count selects the cost; zero selects the
+// default, and its meaning otherwise depends on the method:
+//
+//	"$1$", ""                 must be zero
+//	"$5$", "$6$"              rounds, clamped to 1000-999999999 (default 5000)
+//	"$2a$", "$2b$", "$2y$"    log2 of rounds, 4-31 (default 5)
+//	"$y$", "$gy$", "$7$"      libxcrypt cost level, 1-11 for yescrypt and
+//	                          6-11 for scrypt (default 5 and 7)
+//	"_"                       rounds, made odd and clamped to 16777215
+//	                          (default 725)
+//	"$argon2i$", "$argon2id$" time cost (default 4)
+//
+// Random bytes for the salt are read from rng, or from crypto/rand if rng is
+// nil. For the methods supported by libxcrypt, the output is identical to
+// that of crypt_gensalt given the same random bytes.
+func CryptGensalt(prefix string, count uint64, rng io.Reader) (string, error) {
+	if rng == nil {
+		rng = rand.Reader
+	}
+
+	read := func(n int) ([]byte, error) {
+		b := make([]byte, n)
+		_, err := io.ReadFull(rng, b)
+		return b, err
+	}
+
+	switch prefix {
+	case "":
+		if count != 0 {
+			return "", ErrInvalidCount
+		}
+
+		b, err := read(2)
+		if err != nil {
+			return "", err
+		}
+
+		return string([]byte{cryptAlphabet[b[0]&63], cryptAlphabet[b[1]&63]}), nil
+
+	case "_":
+		if count == 0 {
+			count = 725
+		} else if count > descrypt.MaxExtendedCount {
+			count = descrypt.MaxExtendedCount
+		}
+
+		// Even counts reveal weak DES keys.
+		count |= 1
+
+		b, err := read(3)
+		if err != nil {
+			return "", err
+		}
+
+		return "_" + encode24(uint32(count)) + yescrypt.EncodeBase64(b), nil
+
+	case "$1$":
+		if count != 0 {
+			return "", ErrInvalidCount
+		}
+
+		b, err := read(6)
+		if err != nil {
+			return "", err
+		}
+
+		return "$1$" + yescrypt.EncodeBase64(b), nil
+
+	case "$5$", "$6$":
+		b, err := read(12)
+		if err != nil {
+			return "", err
+		}
+
+		if count == 0 || count == sha2crypt.DefaultRounds {
+			return prefix + yescrypt.EncodeBase64(b), nil
+		}
+
+		if count < sha2crypt.MinimumRounds {
+			count = sha2crypt.MinimumRounds
+		} else if count > sha2crypt.MaximumRounds {
+			count = sha2crypt.MaximumRounds
+		}
+
+		return prefix + "rounds=" + strconv.FormatUint(count, 10) + "$" + yescrypt.EncodeBase64(b), nil
+
+	case "$2a$", "$2b$", "$2y$":
+		if count == 0 {
+			count = 5
+		} else if count < bcrypt.MinCost || count > bcrypt.MaxCost {
+			return "", ErrInvalidCount
+		}
+
+		b, err := read(bcrypt.SaltBytes)
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("%s%02d$%s", prefix, count, bcrypt.EncodeSalt(b)), nil
+
+	case "$y$", "$gy$":
+		if count == 0 {
+			count = 5
+		} else if count > 11 {
+			return "", ErrInvalidCount
+		}
+
+		params := yescrypt.Params{Flags: yescrypt.FlagDefaults, N: 1 << (count + 7), R: 32, P: 1}
+		if count < 3 {
+			params.N, params.R = 1<<(count+9), 8
+		}
+
+		p, err := yescrypt.EncodeParams(params)
+		if err != nil {
+			return "", err
+		}
+
+		b, err := read(16)
+		if err != nil {
+			return "", err
+		}
+
+		return prefix + p + "$" + yescrypt.EncodeBase64(b), nil
+
+	case "$7$":
+		if count == 0 {
+			count = 7
+		} else if count < 6 || count > 11 {
+			return "", ErrInvalidCount
+		}
+
+		b, err := read(16)
+		if err != nil {
+			return "", err
+		}
+
+		return "$7$" + yescrypt.EncodeUint32Fixed(uint32(count+7), 6) +
+			yescrypt.EncodeUint32Fixed(32, 30) + yescrypt.EncodeUint32Fixed(1, 30) +
+			yescrypt.EncodeBase64(b), nil
+
+	case "$argon2i$", "$argon2id$":
+		if count == 0 {
+			count = uint64(argon2.RecommendedTime)
+		} else if count > 1<<32-1 {
+			return "", ErrInvalidCount
+		}
+
+		b, err := read(16)
+		if err != nil {
+			return "", err
+		}
+
+		h := phc.Hash{
+			ID:         strings.Trim(prefix, "$"),
+			Version:    0x13,
+			HasVersion: true,
+			Salt:       b,
+		}
+		h.AddParamUint("m", uint64(argon2.RecommendedMemory))
+		h.AddParamUint("t", count)
+		h.AddParamUint("p", uint64(argon2.RecommendedThreads))
+		return h.String(), nil
+
+	default:
+		return "", ErrUnsupportedPrefix
+	}
+}
+
+const cryptAlphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Encodes a 24-bit value as four characters, least significant first.
+func encode24(v uint32) string {
+	b := make([]byte, 4)
+	for i := range b {
+		b[i] = cryptAlphabet[v&63]
+		v >>= 6
+	}
+
+	return string(b)
+}
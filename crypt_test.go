@@ -0,0 +1,148 @@
+package passlib
+
+import (
+	"testing"
+)
+
+// Generated using libxcrypt's crypt(3).
+var cryptTests = []struct {
+	key, setting, hash string
+}{
+	{"password", "/0", "/0qEvA/.5nt3g"},
+	{"password", "abcdefg", "abJnggxhB/yWI"},
+	{"password", "_J9../6k.", "_J9../6k.HldWRQau6j."},
+	{"password", "$1$", "$1$$I2o9Z7NcvQAKp7wyCTlia0"},
+	{"password", "$1$abcdefghijkl", "$1$abcdefgh$G//4keteveJp0qb8z2DxG/"},
+	{"password", "$1$a-b", "$1$a-b$mYEX3ttKP2TZH5PSWeInM/"},
+	{"password", "$1$ab$cd$ef", "$1$ab$oKsM6dtDD2L1bKowOBX.7."},
+	{"password\x00ignored", "$1$ab$cd$ef", "$1$ab$oKsM6dtDD2L1bKowOBX.7."},
+	{"password", "$5$rounds=5000$ab", "$5$rounds=5000$ab$qeQJSoyiYLyCNJ4nhnpINuEqxziLz7BmT6ldjwrEtl9"},
+	{"password", "$5$rounds=1000$ab", "$5$rounds=1000$ab$1w1vy5uCLDQWfIMwmaMatnSGmBa34fZnvo3JO2w3CP1"},
+	{"password", "$5$abcdefghijklmnopqrstu", "$5$abcdefghijklmnop$ieyonWfl7MR75BuN79Fkt2PqhPI43TsNZYGUObDGVI/"},
+	{"password", "$6$ab$", "$6$ab$WfYjcVtm04.lEYV07CdYGA5G9xet7/eU/m3ApNyi7sD.pE7qFDG1ek7dRQpI2KCf9ESl1WoIH04x.DMDvmIed1"},
+	{"password", "$6$ab$$$", "$6$ab$WfYjcVtm04.lEYV07CdYGA5G9xet7/eU/m3ApNyi7sD.pE7qFDG1ek7dRQpI2KCf9ESl1WoIH04x.DMDvmIed1"},
+	{"password", "$2b$05$.OGB/.SE/ueHAeqKBO2NC.", "$2b$05$.OGB/.SE/ueHAeqKBO2NC.l.rLVibUznFAk1jsn2/OhryTtvR79Iu"},
+	{"password", "$y$j9T$", "$y$j9T$$8GphBPUYahATxqgj0nfonf6iSyOHvCy5v.9VnYW6c15"},
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC"},
+	{"password", "$y$j75$/6k.2IU/5UE08g.1Bsk1E.", "$y$j75$/6k.2IU/5UE08g.1Bsk1E.$1fLhRx4S3PyIgtIxm2Yk0xKxaPETdqETPd3X3ZJikE9"},
+	{"password", "$7$CU..../....ab", "$7$CU..../....ab$4A55KAlzbPi/J3hBHfOo9KGY241BFLQ6dV4No23GMC8"},
+	{"password", "$7$CU..../..../6k.2IU/5UE08g.1Bsk1E.", "$7$CU..../..../6k.2IU/5UE08g.1Bsk1E.$auhyPkjmOoQGSm3k5sd92tBtEFdeHpjOzy3lMHcLH0/"},
+
+	// The salt of yescrypt and scrypt settings extends to the last "$".
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$junk", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC"},
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC"},
+	{"password", "$y$j9T$$", "$y$j9T$$8GphBPUYahATxqgj0nfonf6iSyOHvCy5v.9VnYW6c15"},
+	{"password", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$junk", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$Dogv.jai3UfiqXFIeQV0FWiA2xx/QPuuov.EGnMByDD"},
+	{"password", "$7$CU..../....abc$def", "$7$CU..../....abc$aq9nTbuadDKl/OmAH9ktvpXiAjiuBYpB578rVYQ/6K/"},
+	{"password", "$7$CU..../....abc$def$g", "$7$CU..../....abc$def$Kt7HJl6LwDo64nzIUtbyB1IkeogGylUsKcCg8vPNtT2"},
+	{"password", "$7$CU..../....$", "$7$CU..../....$s6Yineq86dpZCjVFyzh1ONTvHbyVyJm4HAlOUGkkDtD"},
+	{"password", "$2b$04$abcdefghijklmnopqrstuu$x", "$2b$04$abcdefghijklmnopqrstuughE8Ev8uGFaUgY2cNEySvxngrb/Jzdm"},
+
+	{"password", "", "*0"},
+	{"password", "$", "*0"},
+	{"password", "a", "*0"},
+	{"password", "a!", "*0"},
+	{"password", "*0", "*1"},
+	{"password", "*1", "*0"},
+	{"password", "$1$a:b", "*0"},
+	{"password", "$5$rounds=999$ab", "*0"},
+	{"password", "$5$rounds=01000$ab", "*0"},
+	{"password", "$5$rounds=1000000000$ab", "*0"},
+	{"password", "$2$04$abcdefghijklmnopqrstuu", "*0"},
+	{"password", "$2b$4$abcdefghijklmnopqrstuu", "*0"},
+	{"password", "$2b$04$abcdefghijklmnopqrstu", "*0"},
+	{"password", "$y$j75$abc", "*0"},
+	{"password", "$7$CU..../....a!", "*0"},
+	{"password", "$7$CU..../....a-b", "*0"},
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$junk$x", "*0"},
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$ju:nk", "*0"},
+	{"password", "$1$ab$c:d", "*0"},
+	{"password", "$6$rounds=1000$ab$c d", "*0"},
+	{"password", "_J9..CCCC:", "*0"},
+	{"password", "ab:", "*0"},
+	{"password", "$x$", "*0"},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, tst := range cryptTests {
+		h := Crypt(tst.key, tst.setting)
+		if h != tst.hash {
+			t.Errorf("Crypt(%q, %q): got %q, expected %q", tst.key, tst.setting, h, tst.hash)
+		}
+	}
+}
+
+func TestCryptArgon2(t *testing.T) {
+	for _, setting := range []string{"$argon2i$v=19$m=64,t=1,p=1$c2FsdHNhbHQ", "$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ"} {
+		h := Crypt("password", setting)
+		if len(h) <= len(setting) || h[0:len(setting)] != setting || Crypt("password", h) != h {
+			t.Errorf("Crypt(%q): got %q", setting, h)
+		}
+	}
+
+	if h := Crypt("password", "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ"); h != "*0" {
+		t.Errorf("unsupported version accepted: %q", h)
+	}
+}
+
+// A reader returning the bytes 1, 2, 3, ...
+type countingReader struct {
+	n byte
+}
+
+func (r *countingReader) Read(b []byte) (int, error) {
+	for i := range b {
+		r.n++
+		b[i] = r.n
+	}
+
+	return len(b), nil
+}
+
+// Generated using libxcrypt's crypt_gensalt(3) with the same random bytes.
+var gensaltTests = []struct {
+	prefix  string
+	count   uint64
+	setting string
+}{
+	{"", 0, "/0"},
+	{"_", 0, "_J9../6k."},
+	{"_", 726, "_L9../6k."},
+	{"_", 1 << 24, "_zzzz/6k."},
+	{"$1$", 0, "$1$/6k.2IU/"},
+	{"$5$", 0, "$5$/6k.2IU/5UE08g.1"},
+	{"$5$", 5000, "$5$/6k.2IU/5UE08g.1"},
+	{"$6$", 10000, "$6$rounds=10000$/6k.2IU/5UE08g.1"},
+	{"$6$", 10, "$6$rounds=1000$/6k.2IU/5UE08g.1"},
+	{"$2b$", 0, "$2b$05$.OGB/.SE/ueHAeqKBO2NC."},
+	{"$2y$", 12, "$2y$12$.OGB/.SE/ueHAeqKBO2NC."},
+	{"$y$", 0, "$y$j9T$/6k.2IU/5UE08g.1Bsk1E."},
+	{"$y$", 1, "$y$j75$/6k.2IU/5UE08g.1Bsk1E."},
+	{"$gy$", 11, "$gy$jFT$/6k.2IU/5UE08g.1Bsk1E."},
+	{"$7$", 0, "$7$CU..../..../6k.2IU/5UE08g.1Bsk1E."},
+	{"$7$", 11, "$7$GU..../..../6k.2IU/5UE08g.1Bsk1E."},
+	{"$argon2id$", 3, "$argon2id$v=19$m=32768,t=3,p=4$AQIDBAUGBwgJCgsMDQ4PEA"},
+}
+
+func TestCryptGensalt(t *testing.T) {
+	for _, tst := range gensaltTests {
+		setting, err := CryptGensalt(tst.prefix, tst.count, &countingReader{})
+		if err != nil || setting != tst.setting {
+			t.Errorf("CryptGensalt(%q, %d): got %q, %v, expected %q", tst.prefix, tst.count, setting, err, tst.setting)
+		}
+	}
+
+	for _, tst := range []struct {
+		prefix string
+		count  uint64
+	}{{"", 1}, {"$1$", 1}, {"$2b$", 3}, {"$2b$", 32}, {"$y$", 12}, {"$7$", 5}, {"$7$", 12}, {"$", 0}, {"$3$", 0}} {
+		if setting, err := CryptGensalt(tst.prefix, tst.count, nil); err == nil {
+			t.Errorf("CryptGensalt(%q, %d): expected error, got %q", tst.prefix, tst.count, setting)
+		}
+	}
+
+	setting, err := CryptGensalt("$6$", 0, nil)
+	if err != nil || Crypt("password", setting)[0:len(setting)] != setting {
+		t.Errorf("cannot use generated setting %q: %v", setting, err)
+	}
+}
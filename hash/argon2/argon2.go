@@ -1,5 +1,6 @@
 // Package argon2 implements the argon2 password hashing mechanism, wrapped in
-// the argon2 encoded format.
+// the argon2 encoded format. Both the Argon2i and Argon2id variants are
+// supported.
 package argon2
 
 import (
@@ -18,6 +19,11 @@ import (
 // Uses the recommended values for time, memory and threads defined in raw.
 var Crypter abstract.Scheme
 
+// An implementation of Scheme performing argon2id hashing.
+//
+// Uses the recommended values for time, memory and threads defined in raw.
+var IDCrypter abstract.Scheme
+
 const saltLength = 16
 
 func init() {
@@ -26,12 +32,32 @@ func init() {
 		raw.RecommendedMemory,
 		raw.RecommendedThreads,
 	)
+	IDCrypter = NewID(
+		raw.RecommendedTime,
+		raw.RecommendedMemory,
+		raw.RecommendedThreads,
+	)
 }
 
 // Returns an implementation of Scheme implementing argon2
 // with the specified parameters.
 func New(time, memory uint32, threads uint8) abstract.Scheme {
 	return &scheme{
+		variant: "argon2i",
+		time:    time,
+		memory:  memory,
+		threads: threads,
+	}
+}
+
+// Returns an implementation of Scheme implementing argon2id
+// with the specified parameters.
+//
+// Hashes with any key length are verified, so that hashes produced by other
+// implementations, such as Spring Security, can be used.
+func NewID(time, memory uint32, threads uint8) abstract.Scheme {
+	return &scheme{
+		variant: "argon2id",
 		time:    time,
 		memory:  memory,
 		threads: threads,
@@ -39,6 +65,7 @@ func New(time, memory uint32, threads uint8) abstract.Scheme {
 }
 
 type scheme struct {
+	variant      string
 	time, memory uint32
 	threads      uint8
 }
@@ -51,7 +78,7 @@ func (c *scheme) SetParams(time, memory uint32, threads uint8) error {
 }
 
 func (c *scheme) SupportsStub(stub string) bool {
-	return strings.HasPrefix(stub, "$argon2i$")
+	return strings.HasPrefix(stub, "$"+c.variant+"$")
 }
 
 func (c *scheme) Hash(password string) (string, error) {
@@ -66,6 +93,9 @@ func (c *scheme) Hash(password string) (string, error) {
 }
 
 func (c *scheme) Verify(password, hash string) (err error) {
+	if c.variant != "argon2i" {
+		return c.verifyKey(password, hash)
+	}
 
 	_, newHash, _, _, _, _, _, err := c.hash(password, hash)
 	if err == nil && !abstract.SecureCompare(hash, newHash) {
@@ -75,9 +105,31 @@ func (c *scheme) Verify(password, hash string) (err error) {
 	return
 }
 
-func (c *scheme) NeedsUpdate(stub string) bool {
-	salt, _, version, time, memory, threads, err := raw.Parse(stub)
+func (c *scheme) verifyKey(password, hash string) error {
+	variant, salt, key, version, time, memory, threads, err := raw.ParseVariant(hash)
 	if err != nil {
+		return err
+	}
+
+	if variant != c.variant || version != argon2.Version || len(key) == 0 {
+		return raw.ErrInvalidStub
+	}
+
+	newKey, err := raw.Key(variant, []byte(password), salt, time, memory, threads, uint32(len(key)))
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(key), string(newKey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	variant, salt, _, version, time, memory, threads, err := raw.ParseVariant(stub)
+	if err != nil || variant != c.variant {
 		return false // ...
 	}
 
@@ -90,12 +142,22 @@ func (c *scheme) needsUpdate(salt []byte, version int, time, memory uint32, thre
 
 func (c *scheme) hash(password, stub string) (oldHashRaw []byte, newHash string, salt []byte, version int, memory, time uint32, threads uint8, err error) {
 
-	salt, oldHashRaw, version, time, memory, threads, err = raw.Parse(stub)
+	var variant string
+	variant, salt, oldHashRaw, version, time, memory, threads, err = raw.ParseVariant(stub)
+	if err == nil && variant != c.variant {
+		err = raw.ErrInvalidStub
+	}
 	if err != nil {
 		return
 	}
 
-	return oldHashRaw, raw.Argon2(password, salt, time, memory, threads), salt, version, memory, time, threads, nil
+	if variant == "argon2id" {
+		newHash = raw.Argon2ID(password, salt, time, memory, threads)
+	} else {
+		newHash = raw.Argon2(password, salt, time, memory, threads)
+	}
+
+	return oldHashRaw, newHash, salt, version, memory, time, threads, nil
 }
 
 func (c *scheme) makeStub() (string, error) {
@@ -107,9 +169,14 @@ func (c *scheme) makeStub() (string, error) {
 
 	salt := base64.RawStdEncoding.EncodeToString(buf)
 
-	return fmt.Sprintf("$argon2i$v=%d$m=%d,t=%d,p=%d$%s$", argon2.Version, c.memory, c.time, c.threads, salt), nil
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$", c.variant, argon2.Version, c.memory, c.time, c.threads, salt), nil
 }
 
 func (c *scheme) String() string {
-	return fmt.Sprintf("argon2(%d,%d,%d,%d)", argon2.Version, c.memory, c.time, c.threads)
+	name := "argon2"
+	if c.variant == "argon2id" {
+		name = "argon2id"
+	}
+
+	return fmt.Sprintf("%s(%d,%d,%d,%d)", name, argon2.Version, c.memory, c.time, c.threads)
 }
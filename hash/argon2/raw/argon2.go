@@ -1,12 +1,12 @@
-// Package raw provides a raw implementation of the modular-crypt-wrapped Argon2i primitive.
+// Package raw provides a raw implementation of the modular-crypt-wrapped Argon2i
+// and Argon2id primitives.
 package raw
 
 import (
-	"encoding/base64"
 	"fmt"
+
 	"golang.org/x/crypto/argon2"
-	"strconv"
-	"strings"
+	"gopkg.in/hlandau/passlib.v1/phc"
 )
 
 // The current recommended time value for interactive logins.
@@ -28,23 +28,55 @@ const RecommendedThreads uint8 = 4
 //
 // Returns an argon2 encoded hash.
 func Argon2(password string, salt []byte, time, memory uint32, threads uint8) string {
-	passwordb := []byte(password)
+	return encode("argon2i", password, salt, time, memory, threads)
+}
+
+// Like Argon2, but uses the Argon2id variant.
+func Argon2ID(password string, salt []byte, time, memory uint32, threads uint8) string {
+	return encode("argon2id", password, salt, time, memory, threads)
+}
 
-	hash := argon2.Key(passwordb, salt, time, memory, threads, 32)
+func encode(variant, password string, salt []byte, time, memory uint32, threads uint8) string {
+	hash, _ := Key(variant, []byte(password), salt, time, memory, threads, 32)
 
-	hstr := base64.RawStdEncoding.EncodeToString(hash)
-	sstr := base64.RawStdEncoding.EncodeToString(salt)
+	h := phc.Hash{
+		ID:         variant,
+		Version:    argon2.Version,
+		HasVersion: true,
+		Salt:       salt,
+		Hash:       hash,
+	}
+	h.AddParamUint("m", uint64(memory))
+	h.AddParamUint("t", uint64(time))
+	h.AddParamUint("p", uint64(threads))
 
-	return fmt.Sprintf("$argon2i$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, memory, time, threads, sstr, hstr)
+	return h.String()
+}
+
+// Derives a raw argon2 key of keyLen bytes using the given variant, which
+// must be "argon2i" or "argon2id".
+func Key(variant string, password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) ([]byte, error) {
+	switch variant {
+	case "argon2i":
+		return argon2.Key(password, salt, time, memory, threads, keyLen), nil
+	case "argon2id":
+		return argon2.IDKey(password, salt, time, memory, threads, keyLen), nil
+	default:
+		return nil, ErrInvalidStub
+	}
 }
 
 // Indicates that a password hash or stub is invalid.
 var ErrInvalidStub = fmt.Errorf("invalid argon2 password stub")
 
 // Indicates that a key-value pair in the configuration part is malformed.
+//
+// Deprecated: No longer returned; malformed strings fail with phc.ErrInvalid.
 var ErrInvalidKeyValuePair = fmt.Errorf("invalid argon2 key-value pair")
 
 // Indicates that the version part had the wrong number of parameters.
+//
+// Deprecated: No longer returned; malformed strings fail with phc.ErrInvalid.
 var ErrParseVersion = fmt.Errorf("version section has wrong number of parameters")
 
 // Indicates that the hash config part had the wrong number of parameters.
@@ -70,117 +102,82 @@ var ErrMissingParallelism = fmt.Errorf("parallelism parameter (p) is missing")
 //
 // The format is as follows:
 //
-//   $argon2i$v=version$m=memory,t=time,p=threads$salt$hash   // hash
-//   $argon2i$v=version$m=memory,t=time,p=threads$salt        // stub
+//	$argon2i$v=version$m=memory,t=time,p=threads$salt$hash   // hash
+//	$argon2i$v=version$m=memory,t=time,p=threads$salt        // stub
 //
+// This is a PHC string; see package phc.
 func Parse(stub string) (salt, hash []byte, version int, time, memory uint32, parallelism uint8, err error) {
-	if len(stub) < 26 || !strings.HasPrefix(stub, "$argon2i$") {
+	variant, salt, hash, version, time, memory, parallelism, err := ParseVariant(stub)
+	if err == nil && variant != "argon2i" {
 		err = ErrInvalidStub
-		return
 	}
 
-	// $argon2i$  v=version$m=memory,t=time,p=threads$salt-base64$hash-base64
-	parts := strings.Split(stub[9:], "$")
-
-	// version-params$hash-config-params$salt[$hash]
-	if len(parts) < 3 || len(parts) > 4 {
-		err = ErrInvalidStub
-		return
-	}
+	return
+}
 
-	// Parse the first configuration part, the version parameters.
-	versionParams, err := parseKeyValuePair(parts[0])
+// Like Parse, but also accepts Argon2id hashes, which have the identifier
+// "argon2id", and returns the identifier.
+func ParseVariant(stub string) (variant string, salt, hash []byte, version int, time, memory uint32, parallelism uint8, err error) {
+	h, err := phc.Parse(stub)
 	if err != nil {
 		return
 	}
 
-	// Must be exactly one parameter in the version part.
-	if len(versionParams) != 1 {
-		err = ErrParseVersion
+	if (h.ID != "argon2i" && h.ID != "argon2id") || h.Salt == nil {
+		err = ErrInvalidStub
 		return
 	}
 
-	// It must be "v".
-	val, ok := versionParams["v"]
-	if !ok {
+	variant = h.ID
+
+	if !h.HasVersion {
 		err = ErrMissingVersion
 		return
 	}
 
-	version = int(val)
-
-	// Parse the second configuration part, the hash config parameters.
-	hashParams, err := parseKeyValuePair(parts[1])
-	if err != nil {
-		return
-	}
+	version = h.Version
 
 	// It must have exactly three parameters.
-	if len(hashParams) != 3 {
+	if len(h.Params) != 3 {
 		err = ErrParseConfig
 		return
 	}
 
-	// Memory parameter.
-	val, ok = hashParams["m"]
-	if !ok {
+	val, err := h.ParamUint("m", 32)
+	if err == phc.ErrMissingParam {
 		err = ErrMissingMemory
+	}
+	if err != nil {
 		return
 	}
 
 	memory = uint32(val)
 
-	// Time parameter.
-	val, ok = hashParams["t"]
-	if !ok {
+	val, err = h.ParamUint("t", 32)
+	if err == phc.ErrMissingParam {
 		err = ErrMissingTime
+	}
+	if err != nil {
 		return
 	}
 
 	time = uint32(val)
 
-	// Parallelism parameter.
-	val, ok = hashParams["p"]
-	if !ok {
+	val, err = h.ParamUint("p", 8)
+	if err == phc.ErrMissingParam {
 		err = ErrMissingParallelism
-		return
 	}
-
-	parallelism = uint8(val)
-
-	// Decode salt.
-	salt, err = base64.RawStdEncoding.DecodeString(parts[2])
 	if err != nil {
 		return
 	}
 
-	// Decode hash if present.
-	if len(parts) >= 4 {
-		hash, err = base64.RawStdEncoding.DecodeString(parts[3])
-	}
-
-	return
-}
-
-func parseKeyValuePair(pairs string) (result map[string]uint64, err error) {
-	result = map[string]uint64{}
-
-	parameterParts := strings.Split(pairs, ",")
-
-	for _, parameter := range parameterParts {
-		parts := strings.SplitN(parameter, "=", 2)
-		if len(parts) != 2 {
-			err = ErrInvalidKeyValuePair
-			return
-		}
-
-		parsedi, err := strconv.ParseUint(parts[1], 10, 32)
-		if err != nil {
-			return result, err
-		}
+	parallelism = uint8(val)
 
-		result[parts[0]] = parsedi
+	// golang.org/x/crypto/argon2 panics on these.
+	if time < 1 || parallelism < 1 {
+		err = ErrInvalidStub
+		return
 	}
 
-	return result, nil
+	return variant, h.Salt, h.Hash, version, time, memory, parallelism, nil
 }
@@ -0,0 +1,22 @@
+package raw
+
+import "testing"
+
+func TestParseVariant(t *testing.T) {
+	variant, salt, hash, version, time, memory, threads, err := ParseVariant("$argon2id$v=19$m=32768,t=4,p=4$c2FsdHNhbHRzYWx0c2FsdA$iOnY2XcL2dFWUpKU+YfZJmhtu49NdOyv0lkqJtxzfBY")
+	if err != nil || variant != "argon2id" || string(salt) != "saltsaltsaltsalt" || len(hash) != 32 ||
+		version != 19 || time != 4 || memory != 32768 || threads != 4 {
+		t.Errorf("cannot parse: %v", err)
+	}
+
+	for _, s := range []string{
+		"$argon2id$v=19$m=32768,t=0,p=4$c2FsdHNhbHRzYWx0c2FsdA", // t = 0
+		"$argon2id$v=19$m=32768,t=4,p=0$c2FsdHNhbHRzYWx0c2FsdA", // p = 0
+		"$argon2i$v=19$m=32768,t=0,p=4$c2FsdHNhbHRzYWx0c2FsdA",  // t = 0
+		"$argon2i$v=19$m=32768,t=4,p=0$c2FsdHNhbHRzYWx0c2FsdA",  // p = 0
+	} {
+		if _, _, _, _, _, _, _, err := ParseVariant(s); err != ErrInvalidStub {
+			t.Errorf("invalid parameters accepted: %q: %v", s, err)
+		}
+	}
+}
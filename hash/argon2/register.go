@@ -0,0 +1,34 @@
+package argon2
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/argon2/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+var registryParams = []registry.Param{
+	{Name: "t", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedTime)), Min: 1, Max: 1<<32 - 1, Doc: "time cost (passes)"},
+	{Name: "m", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedMemory)), Min: 8, Max: 1<<32 - 1, Doc: "memory cost in KiB"},
+	{Name: "p", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedThreads)), Min: 1, Max: 255, Doc: "parallelism (threads)"},
+}
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name:   "argon2i",
+		Doc:    "Argon2i in the argon2 encoded format",
+		Params: registryParams,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(uint32(args.Int("t")), uint32(args.Int("m")), uint8(args.Int("p"))), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "argon2id",
+		Doc:    "Argon2id in the argon2 encoded format",
+		Params: registryParams,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewID(uint32(args.Int("t")), uint32(args.Int("m")), uint8(args.Int("p"))), nil
+		},
+	})
+}
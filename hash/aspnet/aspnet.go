@@ -0,0 +1,90 @@
+// Package aspnet implements the password hash formats produced by the ASP.NET
+// Identity PasswordHasher.
+//
+// Both the version 2 (ASP.NET Identity 2) and version 3 (ASP.NET Core
+// Identity) formats are verified; new hashes are always produced in the
+// version 3 format.
+package aspnet
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/aspnet/raw"
+)
+
+// An implementation of Scheme producing ASP.NET Identity version 3 hashes.
+//
+// Uses raw.RecommendedPRF and raw.RecommendedIterations.
+var Crypter abstract.Scheme
+
+func init() {
+	Crypter = New(raw.RecommendedPRF, raw.RecommendedIterations)
+}
+
+// Returns an implementation of Scheme producing ASP.NET Identity version 3
+// hashes with the given PRF (e.g. raw.PRFSHA512) and iteration count.
+func New(prf, iterations int) abstract.Scheme {
+	return &scheme{
+		prf:        prf,
+		iterations: iterations,
+	}
+}
+
+type scheme struct {
+	prf, iterations int
+}
+
+func (c *scheme) SetParams(prf, iterations int) error {
+	c.prf = prf
+	c.iterations = iterations
+	return nil
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	_, _, _, _, _, err := raw.Parse(stub)
+	return err == nil
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	salt := make([]byte, raw.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.V3(password, salt, c.prf, c.iterations)
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	_, prf, iterations, salt, subkey, err := raw.Parse(hash)
+	if err != nil {
+		return err
+	}
+
+	newSubkey, err := raw.Key(password, salt, prf, iterations, len(subkey))
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(subkey), string(newSubkey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// Version 2 hashes always need an update.
+func (c *scheme) NeedsUpdate(stub string) bool {
+	version, prf, iterations, salt, _, err := raw.Parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return version < 3 || prf != c.prf || iterations < c.iterations || len(salt) < raw.SaltLength
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("aspnet-identity-v3(%d,%d)", c.prf, c.iterations)
+}
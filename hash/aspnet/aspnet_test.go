@@ -0,0 +1,50 @@
+package aspnet
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/aspnet/raw"
+)
+
+func TestScheme(t *testing.T) {
+	for _, h := range []string{
+		"AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ==",
+		"AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q==",
+		"AQAAAAIAAYagAAAAEAABAgMEBQYHCAkKCwwNDg/73hTTOMxvghBX8/SnisILxwGxHjepOzeQw1EOAZRz8w==",
+	} {
+		if !Crypter.SupportsStub(h) {
+			t.Errorf("stub not supported: %q", h)
+		}
+
+		if err := Crypter.Verify("password", h); err != nil {
+			t.Errorf("err verifying known good hash: %v (%q)", err, h)
+		}
+
+		if err := Crypter.Verify("passwore", h); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v", err)
+		}
+	}
+
+	if !Crypter.NeedsUpdate("AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ==") {
+		t.Errorf("expected update for version 2 hash")
+	}
+
+	if Crypter.SupportsStub("$2a$10$abc") {
+		t.Errorf("unexpected stub support")
+	}
+
+	c := New(raw.PRFSHA256, 1000)
+	h, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Verify("password", h); err != nil || c.NeedsUpdate(h) {
+		t.Errorf("cannot verify new hash: %v (%q)", err, h)
+	}
+
+	if !Crypter.NeedsUpdate(h) {
+		t.Errorf("expected update for weaker parameters")
+	}
+}
@@ -0,0 +1,147 @@
+// Package raw provides a raw implementation of the password hash formats used
+// by the ASP.NET Identity PasswordHasher.
+package raw
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"hash"
+
+	pbkdf2 "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// Pseudo-random functions which may be used with the version 3 format. The
+// values are those of the .NET KeyDerivationPrf enumeration.
+const (
+	PRFSHA1   = 0
+	PRFSHA256 = 1
+	PRFSHA512 = 2
+)
+
+// The recommended PRF for the version 3 format. This is the ASP.NET Core
+// Identity default since .NET 7.
+const RecommendedPRF = PRFSHA512
+
+// The recommended iteration count for the version 3 format. This is the
+// ASP.NET Core Identity default since .NET 7.
+const RecommendedIterations = 100000
+
+// The iteration count used by the version 2 format.
+const V2Iterations = 1000
+
+// The salt length used for new hashes, in bytes.
+const SaltLength = 16
+
+// The subkey length used for new hashes, in bytes.
+const SubkeyLength = 32
+
+// Indicates that a password hash is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid ASP.NET Identity password hash")
+
+func prfHash(prf int) (func() hash.Hash, bool) {
+	switch prf {
+	case PRFSHA1:
+		return sha1.New, true
+	case PRFSHA256:
+		return sha256.New, true
+	case PRFSHA512:
+		return sha512.New, true
+	default:
+		return nil, false
+	}
+}
+
+// Derives a subkey of keyLen bytes using PBKDF2 with the given PRF.
+func Key(password string, salt []byte, prf, iterations, keyLen int) ([]byte, error) {
+	hf, ok := prfHash(prf)
+	if !ok || iterations < 1 {
+		return nil, ErrInvalidStub
+	}
+
+	return pbkdf2.Key([]byte(password), salt, iterations, keyLen, hf), nil
+}
+
+// Calculates a hash in the version 2 format, which is the base64 encoding
+// of a zero byte, a 16-byte salt and a 32-byte PBKDF2-HMAC-SHA1 subkey
+// derived with 1000 iterations.
+func V2(password string, salt []byte) (string, error) {
+	if len(salt) != 16 {
+		return "", ErrInvalidStub
+	}
+
+	subkey, _ := Key(password, salt, PRFSHA1, V2Iterations, 32)
+
+	b := append([]byte{0}, salt...)
+	b = append(b, subkey...)
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Calculates a hash in the version 3 format, which is the base64 encoding of
+// the following:
+//
+//	0x01
+//	PRF (uint32, big endian)
+//	iteration count (uint32, big endian)
+//	salt length (uint32, big endian)
+//	salt
+//	PBKDF2 subkey
+func V3(password string, salt []byte, prf, iterations int) (string, error) {
+	subkey, err := Key(password, salt, prf, iterations, SubkeyLength)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 13, 13+len(salt)+len(subkey))
+	b[0] = 1
+	binary.BigEndian.PutUint32(b[1:], uint32(prf))
+	binary.BigEndian.PutUint32(b[5:], uint32(iterations))
+	binary.BigEndian.PutUint32(b[9:], uint32(len(salt)))
+	b = append(b, salt...)
+	b = append(b, subkey...)
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// Parses a hash in the version 2 or version 3 format. For version 2 hashes,
+// PRFSHA1 and V2Iterations are returned.
+func Parse(hash string) (version, prf, iterations int, salt, subkey []byte, err error) {
+	b, err := base64.StdEncoding.DecodeString(hash)
+	if err != nil || len(b) == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	switch b[0] {
+	case 0:
+		if len(b) != 1+16+32 {
+			err = ErrInvalidStub
+			return
+		}
+
+		return 2, PRFSHA1, V2Iterations, b[1:17], b[17:], nil
+
+	case 1:
+		if len(b) < 13 {
+			err = ErrInvalidStub
+			return
+		}
+
+		p := binary.BigEndian.Uint32(b[1:])
+		n := binary.BigEndian.Uint32(b[5:])
+		saltLen := binary.BigEndian.Uint32(b[9:])
+		if _, ok := prfHash(int(p)); !ok || n < 1 || n > 0x7FFFFFFF ||
+			saltLen < 16 || uint64(len(b)) < 13+uint64(saltLen)+16 {
+			err = ErrInvalidStub
+			return
+		}
+
+		return 3, int(p), int(n), b[13 : 13+saltLen], b[13+saltLen:], nil
+
+	default:
+		err = ErrInvalidStub
+		return
+	}
+}
@@ -0,0 +1,51 @@
+package raw
+
+import (
+	"testing"
+)
+
+// Generated using Python's hashlib module.
+var tests = []struct {
+	prf, iterations int
+	hash            string
+}{
+	{PRFSHA256, 10000, "AQAAAAEAACcQAAAAEAABAgMEBQYHCAkKCwwNDg/rbIFTVZIgPAkrFY+NOQlnI2Km9dvQDZgoBEy6qLJS6Q=="},
+	{PRFSHA512, 100000, "AQAAAAIAAYagAAAAEAABAgMEBQYHCAkKCwwNDg/73hTTOMxvghBX8/SnisILxwGxHjepOzeQw1EOAZRz8w=="},
+	{PRFSHA1, 10000, "AQAAAAAAACcQAAAAEAABAgMEBQYHCAkKCwwNDg+OPi9zw+tjkKgau8gQHANDsBenr//7WrZeE08JCdzKLA=="},
+}
+
+var salt = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+func TestV2(t *testing.T) {
+	const expected = "AAABAgMEBQYHCAkKCwwNDg8DCeL+Tgvf59D+SCjUHCNEFuLZv7Yc3Y9kOhHPv9/BGQ=="
+	h, err := V2("password", salt)
+	if err != nil || h != expected {
+		t.Errorf("mismatch: got %q (%v), expected %q", h, err, expected)
+	}
+
+	version, prf, iterations, _, _, err := Parse(h)
+	if err != nil || version != 2 || prf != PRFSHA1 || iterations != V2Iterations {
+		t.Errorf("cannot parse: %v", err)
+	}
+}
+
+func TestV3(t *testing.T) {
+	for _, tst := range tests {
+		h, err := V3("password", salt, tst.prf, tst.iterations)
+		if err != nil || h != tst.hash {
+			t.Errorf("mismatch: got %q (%v), expected %q", h, err, tst.hash)
+		}
+
+		version, prf, iterations, s, subkey, err := Parse(h)
+		if err != nil || version != 3 || prf != tst.prf || iterations != tst.iterations ||
+			string(s) != string(salt) || len(subkey) != SubkeyLength {
+			t.Errorf("cannot parse: %v", err)
+		}
+	}
+
+	for _, bad := range []string{"", "AQ==", "Ag==", "AQAAAAMAACcQAAAAEAABAgMEBQYHCAkKCwwNDg+OPi9zw+tjkKgau8gQHANDsBenr//7WrZeE08JCdzKLA==", "AQAAAAAAACcQAAAAMAABAgMEBQYHCAkKCwwNDg+OPi9zw+tjkKgau8gQHANDsBenr//7WrZeE08JCdzKLA=="} {
+		if _, _, _, _, _, err := Parse(bad); err == nil {
+			t.Errorf("invalid hash accepted: %q", bad)
+		}
+	}
+}
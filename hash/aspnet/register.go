@@ -0,0 +1,29 @@
+package aspnet
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/aspnet/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+var prfNames = map[string]int{
+	"sha1":   raw.PRFSHA1,
+	"sha256": raw.PRFSHA256,
+	"sha512": raw.PRFSHA512,
+}
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "aspnet-identity",
+		Doc:  "ASP.NET Identity v3 (v2 verified)",
+		Params: []registry.Param{
+			{Name: "prf", Type: registry.String, Default: "sha512", Choices: []string{"sha1", "sha256", "sha512"}, Doc: "PBKDF2 PRF"},
+			{Name: "iterations", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedIterations), Min: 1, Max: 1<<31 - 1, Doc: "number of PBKDF2 iterations"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(prfNames[args.String("prf")], args.Int("iterations")), nil
+		},
+	})
+}
@@ -0,0 +1,154 @@
+// Package balloon implements the Balloon memory-hard password hashing
+// function and its parallel variant, Balloon-M, over SHA-256 or SHA-512,
+// wrapped in a PHC-style encoded format.
+//
+// Balloon hashing has proven memory-hardness guarantees and relies only on a
+// standard cryptographic hash function.
+package balloon
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/balloon/raw"
+)
+
+// An implementation of Scheme performing sequential Balloon hashing.
+//
+// Uses the recommended space cost, time cost and hash function defined in
+// raw.
+var Crypter abstract.Scheme
+
+// An implementation of Scheme performing parallel Balloon-M hashing.
+//
+// Uses the recommended space cost, time cost, parallelism and hash function
+// defined in raw.
+var MCrypter abstract.Scheme
+
+const saltLength = 16
+
+func init() {
+	Crypter = &scheme{
+		params: raw.Params{
+			SpaceCost: raw.RecommendedSpaceCost,
+			TimeCost:  raw.RecommendedTimeCost,
+			Hash:      raw.RecommendedHash,
+		},
+	}
+	MCrypter = &scheme{
+		params: raw.Params{
+			SpaceCost:   raw.RecommendedSpaceCost,
+			TimeCost:    raw.RecommendedTimeCost,
+			Parallelism: raw.RecommendedParallelism,
+			Hash:        raw.RecommendedHash,
+		},
+	}
+}
+
+// Returns an implementation of Scheme implementing Balloon hashing with the
+// specified parameters. hashName is "sha256" or "sha512". The costs must be
+// at least 1 and must not require more than raw.MaxMemory.
+func New(spaceCost, timeCost uint32, hashName string) (abstract.Scheme, error) {
+	return newScheme(raw.Params{
+		SpaceCost: spaceCost,
+		TimeCost:  timeCost,
+		Hash:      hashName,
+	})
+}
+
+// Returns an implementation of Scheme implementing Balloon-M hashing with the
+// specified parameters. parallelism must be at least 1, and the parameters
+// are otherwise as for New.
+func NewM(spaceCost, timeCost uint32, parallelism uint8, hashName string) (abstract.Scheme, error) {
+	if parallelism < 1 {
+		return nil, raw.ErrInvalidParams
+	}
+
+	return newScheme(raw.Params{
+		SpaceCost:   spaceCost,
+		TimeCost:    timeCost,
+		Parallelism: parallelism,
+		Hash:        hashName,
+	})
+}
+
+func newScheme(p raw.Params) (abstract.Scheme, error) {
+	if err := raw.CheckParams(p); err != nil {
+		return nil, err
+	}
+
+	return &scheme{params: p}, nil
+}
+
+type scheme struct {
+	params raw.Params
+}
+
+func (c *scheme) prefix() string {
+	if c.params.Parallelism == 0 {
+		return "$balloon$"
+	}
+
+	return "$balloon-m$"
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, c.prefix())
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Crypt(password, salt, c.params)
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	salt, key, p, err := c.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	newKey, err := raw.Key(password, salt, p)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(key), string(newKey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) parse(stub string) (salt, key []byte, p raw.Params, err error) {
+	salt, key, p, err = raw.Parse(stub)
+	if err == nil && (p.Parallelism == 0) != (c.params.Parallelism == 0) {
+		err = raw.ErrInvalidStub
+	}
+
+	return
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	salt, _, p, err := c.parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return len(salt) < saltLength || p.SpaceCost < c.params.SpaceCost || p.TimeCost < c.params.TimeCost ||
+		p.Parallelism < c.params.Parallelism || p.Hash != c.params.Hash
+}
+
+func (c *scheme) String() string {
+	if c.params.Parallelism == 0 {
+		return fmt.Sprintf("balloon(%d,%d,%s)", c.params.SpaceCost, c.params.TimeCost, c.params.Hash)
+	}
+
+	return fmt.Sprintf("balloon-m(%d,%d,%d,%s)", c.params.SpaceCost, c.params.TimeCost, c.params.Parallelism, c.params.Hash)
+}
@@ -0,0 +1,90 @@
+package balloon
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+func mustNew(c abstract.Scheme, err error) abstract.Scheme {
+	if err != nil {
+		panic(err)
+	}
+
+	return c
+}
+
+func TestBalloon(t *testing.T) {
+	for _, c := range []abstract.Scheme{
+		mustNew(New(64, 2, "sha256")),
+		mustNew(New(64, 2, "sha512")),
+		mustNew(NewM(64, 2, 3, "sha256")),
+	} {
+		h, err := c.Hash("password")
+		if err != nil {
+			t.Fatalf("%v: %v", c, err)
+		}
+
+		if !c.SupportsStub(h) {
+			t.Errorf("%v: own hash not supported: %s", c, h)
+		}
+
+		if err := c.Verify("password", h); err != nil {
+			t.Errorf("%v: valid password not accepted: %v", c, err)
+		}
+
+		if err := c.Verify("Password", h); err != abstract.ErrInvalidPassword {
+			t.Errorf("%v: invalid password accepted: %v", c, err)
+		}
+
+		if c.NeedsUpdate(h) {
+			t.Errorf("%v: new hash reported as needing update", c)
+		}
+	}
+
+	h := "$balloon$s=64,t=2,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI"
+	if err := mustNew(New(1, 1, "sha256")).Verify("password", h); err != nil {
+		t.Errorf("known hash not verified: %v", err)
+	}
+
+	for _, v := range []struct {
+		c     abstract.Scheme
+		needs bool
+	}{
+		{mustNew(New(64, 2, "sha256")), false},
+		{mustNew(New(128, 2, "sha256")), true},
+		{mustNew(New(64, 3, "sha256")), true},
+		{mustNew(New(64, 2, "sha512")), true},
+		{mustNew(NewM(64, 2, 1, "sha256")), false}, // different variant
+	} {
+		if v.c.NeedsUpdate(h) != v.needs {
+			t.Errorf("%v: unexpected NeedsUpdate result", v.c)
+		}
+	}
+
+	if MCrypter.SupportsStub(h) || Crypter.SupportsStub("$balloon-m$") {
+		t.Errorf("variants not discriminated")
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(64, 2, "md5"); err == nil {
+		t.Errorf("unknown hash function accepted")
+	}
+
+	if _, err := NewM(64, 2, 3, "sha3"); err == nil {
+		t.Errorf("unknown hash function accepted")
+	}
+
+	if _, err := New(0, 2, "sha256"); err == nil {
+		t.Errorf("zero space cost accepted")
+	}
+
+	if _, err := NewM(64, 2, 0, "sha256"); err == nil {
+		t.Errorf("zero parallelism accepted")
+	}
+
+	if _, err := NewM(1<<32-1, 1, 255, "sha512"); err == nil {
+		t.Errorf("excessive memory accepted")
+	}
+}
@@ -0,0 +1,173 @@
+// Package raw provides a raw implementation of Balloon hashing and its
+// parallel variant, as described in "Balloon Hashing: A Memory-Hard Function
+// Providing Provable Protection Against Sequential Attacks" (Boneh,
+// Corrigan-Gibbs and Schechter, 2016).
+package raw
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// The recommended space cost, in blocks. Each block is the size of the
+// output of the hash function, so this is 512 KiB with SHA-256.
+const RecommendedSpaceCost uint32 = 16384
+
+// The recommended time cost, in rounds.
+const RecommendedTimeCost uint32 = 3
+
+// The recommended parallelism for the parallel variant.
+const RecommendedParallelism uint8 = 4
+
+// The recommended hash function.
+const RecommendedHash = "sha256"
+
+// The number of dependencies per block used by encoded hashes. This is the
+// value recommended by the paper and is fixed.
+const Delta = 3
+
+// Returns the hash function with the given name, which may be "sha256" or
+// "sha512".
+func HashFunc(name string) (func() hash.Hash, bool) {
+	switch name {
+	case "sha256":
+		return sha256.New, true
+	case "sha512":
+		return sha512.New, true
+	default:
+		return nil, false
+	}
+}
+
+// Computes the Balloon function over the given hash function. spaceCost is
+// the number of blocks in the buffer, timeCost is the number of mixing rounds
+// and delta is the number of dependencies per block. spaceCost and timeCost
+// must be at least 1.
+//
+// Integers are encoded as 8-byte little-endian values, as in the reference
+// implementation.
+func Balloon(hf func() hash.Hash, password, salt []byte, spaceCost, timeCost uint32, delta int) []byte {
+	h := hf()
+	n := h.Size()
+	s := uint64(spaceCost)
+	buf := make([]byte, int(s)*n)
+	block := func(i uint64) []byte {
+		return buf[int(i)*n : int(i+1)*n]
+	}
+
+	var ib [8]byte
+	writeInt := func(v uint64) {
+		binary.LittleEndian.PutUint64(ib[:], v)
+		h.Write(ib[:])
+	}
+
+	var cnt uint64
+
+	// Expand.
+	h.Reset()
+	writeInt(cnt)
+	h.Write(password)
+	h.Write(salt)
+	h.Sum(block(0)[:0])
+	cnt++
+
+	for i := uint64(1); i < s; i++ {
+		h.Reset()
+		writeInt(cnt)
+		h.Write(block(i - 1))
+		h.Sum(block(i)[:0])
+		cnt++
+	}
+
+	// Mix.
+	idx := make([]byte, 0, n)
+	for t := uint64(0); t < uint64(timeCost); t++ {
+		for m := uint64(0); m < s; m++ {
+			prev := block((m + s - 1) % s)
+			cur := block(m)
+
+			h.Reset()
+			writeInt(cnt)
+			h.Write(prev)
+			h.Write(cur)
+			h.Sum(cur[:0])
+			cnt++
+
+			for i := uint64(0); i < uint64(delta); i++ {
+				h.Reset()
+				writeInt(t)
+				writeInt(m)
+				writeInt(i)
+				idx = h.Sum(idx[:0])
+
+				h.Reset()
+				writeInt(cnt)
+				h.Write(salt)
+				h.Write(idx)
+				idx = h.Sum(idx[:0])
+				cnt++
+
+				other := block(modLE(idx, s))
+
+				h.Reset()
+				writeInt(cnt)
+				h.Write(cur)
+				h.Write(other)
+				h.Sum(cur[:0])
+				cnt++
+			}
+		}
+	}
+
+	// Extract.
+	return append([]byte(nil), block(s-1)...)
+}
+
+// Interprets b as a little-endian integer and returns it modulo m, where m
+// is at most 2**32.
+func modLE(b []byte, m uint64) uint64 {
+	var r uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		r = (r<<8 | uint64(b[i])) % m
+	}
+
+	return r
+}
+
+// Computes the parallel Balloon function (Balloon-M). parallelism instances
+// of Balloon are run concurrently, each with the salt suffixed by its 1-based
+// instance number; their outputs are XORed together and hashed with the
+// password and salt.
+func BalloonM(hf func() hash.Hash, password, salt []byte, spaceCost, timeCost uint32, parallelism uint8, delta int) []byte {
+	outputs := make([][]byte, parallelism)
+
+	var wg sync.WaitGroup
+	for i := range outputs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			s := make([]byte, len(salt)+8)
+			copy(s, salt)
+			binary.LittleEndian.PutUint64(s[len(salt):], uint64(i+1))
+			outputs[i] = Balloon(hf, password, s, spaceCost, timeCost, delta)
+		}(i)
+	}
+	wg.Wait()
+
+	h := hf()
+	out := make([]byte, h.Size())
+	for _, o := range outputs {
+		for i := range out {
+			out[i] ^= o[i]
+		}
+	}
+
+	h.Write(password)
+	h.Write(salt)
+	h.Write(out)
+	return h.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package raw
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBalloon(t *testing.T) {
+	// From the balloon-hashing Python reference implementation.
+	password := []byte("buildmeupbuttercup")
+	salt := []byte("JqMcHqUcjinFhQKJ")
+
+	key := hex.EncodeToString(Balloon(sha256.New, password, salt, 16, 20, 4))
+	if key != "2ec8d833db5f88e584ab793950ecfb21657a3816edea8d9e73ea23c13ba2b740" {
+		t.Errorf("balloon mismatch: %s", key)
+	}
+
+	key = hex.EncodeToString(BalloonM(sha256.New, password, salt, 16, 20, 4, 4))
+	if key != "284d3bca481ce04847fc478b6d92a9fb874c4a942cbe19aeeeac4ee0b154140f" {
+		t.Errorf("balloon-m mismatch: %s", key)
+	}
+}
+
+func TestCrypt(t *testing.T) {
+	salt := []byte("saltsaltsaltsalt")
+
+	for _, v := range []struct {
+		p      string
+		params Params
+		h      string
+	}{
+		{"", Params{64, 2, 0, "sha256"}, "$balloon$s=64,t=2,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$D0vD94vKUec8Am2LBkdSUjPDmHmaehS7hkoFRSUOZqU"},
+		{"password", Params{64, 2, 0, "sha256"}, "$balloon$s=64,t=2,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI"},
+		{"password", Params{64, 2, 0, "sha512"}, "$balloon$s=64,t=2,h=sha512$c2FsdHNhbHRzYWx0c2FsdA$lx7qeatKOsxIY5O/eWq5A2qg3ZPbJ81+dGNff/UKoXdqTVH2Q155RukxzMtXU1Jr7zPNOqPgYl5/ClAA3zUC1w"},
+		{"password", Params{64, 2, 3, "sha256"}, "$balloon-m$s=64,t=2,p=3,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$N1VliruQ/3ZvrdbOHYna5E9v0Y7wKFPSbwD6LMrttiE"},
+	} {
+		h, err := Crypt(v.p, salt, v.params)
+		if err != nil || h != v.h {
+			t.Errorf("crypt mismatch: %q %v %s", v.p, err, h)
+		}
+
+		s, _, p, err := Parse(v.h)
+		if err != nil || string(s) != string(salt) || p != v.params {
+			t.Errorf("parse mismatch: %s %v %v", v.h, err, p)
+		}
+	}
+
+	for _, h := range []string{
+		"$balloon$s=64,t=2,p=3,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI",
+		"$balloon$s=64,t=2,h=md5$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI",
+		"$balloon$s=0,t=2,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI",
+		"$balloon$s=64,t=2,h=sha512$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI",
+		"$balloon-m$s=64,t=2,p=0,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$x3zj4XlhlF9YiPDaW0KYkiP2PPzg1imC/7ny/70mlvI",
+		"$balloon$s=64,t=2,h=sha256$c2FsdHNhbHRzYWx0c2FsdA",
+	} {
+		if _, _, _, err := Parse(h); err == nil {
+			t.Errorf("invalid hash accepted: %s", h)
+		}
+	}
+}
+
+func TestMaxMemory(t *testing.T) {
+	for _, v := range []struct {
+		params Params
+		ok     bool
+	}{
+		{Params{1 << 26, 1, 0, "sha256"}, true},
+		{Params{1<<26 + 1, 1, 0, "sha256"}, false},
+		{Params{1 << 25, 1, 0, "sha512"}, true},
+		{Params{1 << 26, 1, 0, "sha512"}, false},
+		{Params{1 << 24, 1, 4, "sha256"}, true},
+		{Params{1 << 24, 1, 5, "sha256"}, false},
+		{Params{1<<32 - 1, 1, 255, "sha512"}, false},
+	} {
+		if err := CheckParams(v.params); (err == nil) != v.ok {
+			t.Errorf("unexpected result checking %+v: %v", v.params, err)
+		}
+	}
+
+	h := "$balloon-m$s=4294967295,t=1,p=255,h=sha256$c2FsdHNhbHRzYWx0c2FsdA$N1VliruQ/3ZvrdbOHYna5E9v0Y7wKFPSbwD6LMrttiE"
+	if _, _, _, err := Parse(h); err != ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+
+	if _, err := Key("password", nil, Params{1<<32 - 1, 1, 255, "sha256"}); err != ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+}
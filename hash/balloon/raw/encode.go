@@ -0,0 +1,171 @@
+package raw
+
+import (
+	"fmt"
+
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// Indicates that a Balloon hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid balloon password stub")
+
+// Indicates that an unknown hash function or out of range cost was
+// specified.
+var ErrInvalidParams = fmt.Errorf("invalid balloon parameters")
+
+// Parameters for a Balloon hash.
+type Params struct {
+	// The number of blocks in the buffer.
+	SpaceCost uint32
+
+	// The number of mixing rounds.
+	TimeCost uint32
+
+	// The number of parallel instances. If zero, the sequential Balloon
+	// function is used and the hash is encoded with the "balloon" identifier;
+	// otherwise Balloon-M is used and the "balloon-m" identifier is used.
+	Parallelism uint8
+
+	// The name of the hash function; see HashFunc.
+	Hash string
+}
+
+// The maximum memory in bytes which a hash may require, beyond which Parse
+// rejects it, so that verifying an untrusted hash cannot exhaust memory.
+// Balloon-M runs its instances concurrently, each with its own buffer.
+const MaxMemory = 1 << 31
+
+// Checks that the parameters name a supported hash function, have positive
+// costs and do not require more than MaxMemory.
+func CheckParams(p Params) error {
+	hf, ok := HashFunc(p.Hash)
+	if !ok || p.SpaceCost < 1 || p.TimeCost < 1 {
+		return ErrInvalidParams
+	}
+
+	instances := uint64(p.Parallelism)
+	if instances == 0 {
+		instances = 1
+	}
+
+	if uint64(p.SpaceCost)*uint64(hf().Size())*instances > MaxMemory {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+func (p *Params) id() string {
+	if p.Parallelism == 0 {
+		return "balloon"
+	}
+
+	return "balloon-m"
+}
+
+// Derives the raw key for the given password, salt and parameters.
+func Key(password string, salt []byte, p Params) ([]byte, error) {
+	if err := CheckParams(p); err != nil {
+		return nil, err
+	}
+
+	hf, _ := HashFunc(p.Hash)
+	if p.Parallelism == 0 {
+		return Balloon(hf, []byte(password), salt, p.SpaceCost, p.TimeCost, Delta), nil
+	}
+
+	return BalloonM(hf, []byte(password), salt, p.SpaceCost, p.TimeCost, p.Parallelism, Delta), nil
+}
+
+// Calculates a Balloon hash and encodes it as a $balloon$ or $balloon-m$
+// string.
+//
+// password should be a UTF-8 plaintext password. salt should be a random salt
+// value in binary form.
+func Crypt(password string, salt []byte, p Params) (string, error) {
+	key, err := Key(password, salt, p)
+	if err != nil {
+		return "", err
+	}
+
+	h := phc.Hash{ID: p.id(), Salt: salt, Hash: key}
+	h.AddParamUint("s", uint64(p.SpaceCost))
+	h.AddParamUint("t", uint64(p.TimeCost))
+	if p.Parallelism != 0 {
+		h.AddParamUint("p", uint64(p.Parallelism))
+	}
+	h.Params = append(h.Params, phc.Param{Name: "h", Value: p.Hash})
+
+	return h.String(), nil
+}
+
+// Parses a $balloon$ or $balloon-m$ hash.
+//
+// The format is as follows:
+//
+//	$balloon$s=spaceCost,t=timeCost,h=hash$salt$key
+//	$balloon-m$s=spaceCost,t=timeCost,p=parallelism,h=hash$salt$key
+//
+// This is a PHC string; see package phc. ErrInvalidParams is returned if the
+// parameters require more than MaxMemory.
+func Parse(stub string) (salt, key []byte, p Params, err error) {
+	h, err := phc.Parse(stub)
+	if err != nil {
+		return
+	}
+
+	if h.HasVersion || h.Salt == nil || h.Hash == nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	switch h.ID {
+	case "balloon":
+		if !h.HasOnlyParams("s", "t", "h") {
+			err = ErrInvalidStub
+			return
+		}
+	case "balloon-m":
+		if !h.HasOnlyParams("s", "t", "p", "h") {
+			err = ErrInvalidStub
+			return
+		}
+
+		var v uint64
+		if v, err = h.ParamUint("p", 8); err != nil {
+			return
+		}
+		if v == 0 {
+			err = ErrInvalidParams
+			return
+		}
+		p.Parallelism = uint8(v)
+	default:
+		err = ErrInvalidStub
+		return
+	}
+
+	var s, t uint64
+	if s, err = h.ParamUint("s", 32); err != nil {
+		return
+	}
+	if t, err = h.ParamUint("t", 32); err != nil {
+		return
+	}
+
+	p.SpaceCost = uint32(s)
+	p.TimeCost = uint32(t)
+	p.Hash, _ = h.Param("h")
+
+	if err = CheckParams(p); err != nil {
+		return
+	}
+
+	hf, _ := HashFunc(p.Hash)
+	if len(h.Hash) != hf().Size() {
+		err = ErrInvalidStub
+		return
+	}
+
+	return h.Salt, h.Hash, p, nil
+}
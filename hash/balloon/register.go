@@ -0,0 +1,34 @@
+package balloon
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/balloon/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	s := registry.Param{Name: "s", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedSpaceCost)), Min: 1, Max: 1<<32 - 1, Doc: "space cost in blocks"}
+	t := registry.Param{Name: "t", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedTimeCost)), Min: 1, Max: 1<<32 - 1, Doc: "time cost (rounds)"}
+	h := registry.Param{Name: "h", Type: registry.String, Default: raw.RecommendedHash, Choices: []string{"sha256", "sha512"}, Doc: "hash function"}
+
+	registry.Register(&registry.Factory{
+		Name:   "balloon",
+		Doc:    "Balloon hashing",
+		Params: []registry.Param{s, t, h},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(uint32(args.Int("s")), uint32(args.Int("t")), args.String("h"))
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "balloon-m",
+		Doc:  "parallel Balloon hashing (Balloon-M)",
+		Params: []registry.Param{s, t, {
+			Name: "p", Type: registry.Int, Default: strconv.Itoa(int(raw.RecommendedParallelism)), Min: 1, Max: 255, Doc: "parallelism",
+		}, h},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewM(uint32(args.Int("s")), uint32(args.Int("t")), uint8(args.Int("p")), args.String("h"))
+		},
+	})
+}
@@ -0,0 +1,141 @@
+// Package raw provides a raw implementation of the bcrypt primitive which,
+// unlike golang.org/x/crypto/bcrypt, allows the salt to be specified.
+package raw
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blowfish"
+)
+
+// Minimum and maximum permitted bcrypt cost.
+const (
+	MinCost = 4
+	MaxCost = 31
+)
+
+// Length of an encoded bcrypt salt.
+const SaltLength = 22
+
+// Number of raw bytes in a bcrypt salt.
+const SaltBytes = 16
+
+// Indicates that a bcrypt salt or cost is invalid.
+var ErrInvalidParams = fmt.Errorf("invalid bcrypt parameters")
+
+const alphabet = "./ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var bcryptBase64 = base64.NewEncoding(alphabet).WithPadding(base64.NoPadding)
+
+var magicCipherData = []byte("OrpheanBeholderScryDoubt")
+
+// Encodes raw salt bytes into the bcrypt base64 alphabet.
+func EncodeSalt(salt []byte) string {
+	return bcryptBase64.EncodeToString(salt)
+}
+
+// Calculates a bcrypt hash.
+//
+// password is the password in binary form; as with all bcrypt
+// implementations, only the first 72 bytes are used.
+// ident is the bcrypt variant, e.g. "2b".
+// salt is a 22 character bcrypt salt string.
+//
+// Returns a modular crypt hash of the form $ident$cost$saltsum.
+func Crypt(password []byte, ident string, cost int, salt string) (string, error) {
+	if cost < MinCost || cost > MaxCost || len(salt) != SaltLength {
+		return "", ErrInvalidParams
+	}
+
+	csalt, err := bcryptBase64.DecodeString(salt)
+	if err != nil {
+		return "", ErrInvalidParams
+	}
+
+	// As with C implementations, the trailing NUL is part of the key.
+	key := make([]byte, len(password), len(password)+1)
+	copy(key, password)
+	key = append(key, 0)
+	if len(key) > 72 {
+		key = key[0:72]
+	}
+
+	c, err := blowfish.NewSaltedCipher(key, csalt)
+	if err != nil {
+		return "", err
+	}
+
+	for i := uint64(0); i < 1<<uint(cost); i++ {
+		blowfish.ExpandKey(key, c)
+		blowfish.ExpandKey(csalt, c)
+	}
+
+	data := make([]byte, len(magicCipherData))
+	copy(data, magicCipherData)
+	for i := 0; i < len(data); i += 8 {
+		for j := 0; j < 64; j++ {
+			c.Encrypt(data[i:i+8], data[i:i+8])
+		}
+	}
+
+	// Only 23 of the 24 bytes are encoded, for compatibility.
+	return fmt.Sprintf("$%s$%02d$%s%s", ident, cost, salt, bcryptBase64.EncodeToString(data[0:23])), nil
+}
+
+// Calculates a bcrypt hash using a setting string (or existing hash) of the
+// form "$2b$05$" followed by at least SaltLength characters of salt. Only the
+// "2a", "2b" and "2y" variants are accepted; they differ only in the bugs of
+// historical implementations, and produce identical output here.
+//
+// As with libxcrypt, the salt is re-encoded in canonical form in the output.
+func CryptSetting(password, setting string) (string, error) {
+	ident, cost, salt, err := Parse(setting)
+	if err != nil {
+		return "", err
+	}
+
+	return Crypt([]byte(password), ident, cost, salt)
+}
+
+// Parses a bcrypt setting string or hash, returning the variant, cost and
+// canonically encoded salt. Any characters following the salt are ignored.
+func Parse(setting string) (ident string, cost int, salt string, err error) {
+	if len(setting) < 7+SaltLength || setting[0] != '$' || setting[3] != '$' || setting[6] != '$' {
+		err = ErrInvalidParams
+		return
+	}
+
+	ident = setting[1:3]
+	if ident != "2a" && ident != "2b" && ident != "2y" {
+		err = ErrInvalidParams
+		return
+	}
+
+	if setting[4] < '0' || setting[4] > '9' || setting[5] < '0' || setting[5] > '9' {
+		err = ErrInvalidParams
+		return
+	}
+
+	cost = int(setting[4]-'0')*10 + int(setting[5]-'0')
+	if cost < MinCost || cost > MaxCost {
+		err = ErrInvalidParams
+		return
+	}
+
+	salt = setting[7 : 7+SaltLength]
+	if strings.IndexByte(salt, '$') >= 0 {
+		err = ErrInvalidParams
+		return
+	}
+
+	b, err := bcryptBase64.DecodeString(salt)
+	if err != nil {
+		err = ErrInvalidParams
+		return
+	}
+
+	salt = EncodeSalt(b)
+	return
+}
@@ -0,0 +1,64 @@
+package raw
+
+import (
+	"strings"
+	"testing"
+)
+
+// Generated using libxcrypt's crypt(3).
+var tests = []struct {
+	password string
+	hash     string
+}{
+	{"", "$2b$05$abcdefghijklmnopqrstuu0oImNDIy4flhldV9YqunRgBAePKmw7m"},
+	{"password", "$2b$05$abcdefghijklmnopqrstuuWG29KuyeAicPCJODk1zjyGvyQUU2awu"},
+	{"U*U*U*U*", "$2b$05$abcdefghijklmnopqrstuuF1QNFce4DfYnYJUcafR1v2/7XbWeLjK"},
+	{strings.Repeat("x", 100), "$2a$04$R1lJ2gkNaoPGdafE.H.16.ZNLPuwzUucEIelFg3QDkD6yIb0E/M9a"},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, tst := range tests {
+		cost := int(tst.hash[4]-'0')*10 + int(tst.hash[5]-'0')
+		out, err := Crypt([]byte(tst.password), tst.hash[1:3], cost, tst.hash[7:29])
+		if err != nil {
+			t.Errorf("error: %v (%#v)", err, tst.hash)
+		} else if out != tst.hash {
+			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n",
+				out, tst.hash, tst.password)
+		}
+	}
+
+	if _, err := Crypt(nil, "2b", 3, "abcdefghijklmnopqrstuu"); err == nil {
+		t.Errorf("expected error for low cost")
+	}
+
+	if _, err := Crypt(nil, "2b", 4, "abcdefghijklmnopqrstu"); err == nil {
+		t.Errorf("expected error for short salt")
+	}
+}
+
+// Generated using libxcrypt's crypt(3).
+var settingTests = []struct {
+	password, setting, hash string
+}{
+	{"password", "$2b$04$abcdefghijklmnopqrstuu", "$2b$04$abcdefghijklmnopqrstuughE8Ev8uGFaUgY2cNEySvxngrb/Jzdm"},
+	{"password", "$2y$04$abcdefghijklmnopqrstuz", "$2y$04$abcdefghijklmnopqrstuughE8Ev8uGFaUgY2cNEySvxngrb/Jzdm"},
+	{"password", "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6", "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6"},
+	{strings.Repeat("a", 72), "$2b$04$abcdefghijklmnopqrstuu", "$2b$04$abcdefghijklmnopqrstuuBzzIgyKkz7xMWYSzkIjUSnxEQFQ0WNe"},
+	{strings.Repeat("a", 80), "$2b$04$abcdefghijklmnopqrstuu", "$2b$04$abcdefghijklmnopqrstuuBzzIgyKkz7xMWYSzkIjUSnxEQFQ0WNe"},
+}
+
+func TestCryptSetting(t *testing.T) {
+	for _, tst := range settingTests {
+		h, err := CryptSetting(tst.password, tst.setting)
+		if err != nil || h != tst.hash {
+			t.Errorf("mismatch: %q: got %q, %v, expected %q", tst.setting, h, err, tst.hash)
+		}
+	}
+
+	for _, bad := range []string{"$2$04$abcdefghijklmnopqrstuu", "$2x$04$abcdefghijklmnopqrstuu", "$2b$4$abcdefghijklmnopqrstuu", "$2b$03$abcdefghijklmnopqrstuu", "$2b$32$abcdefghijklmnopqrstuu", "$2b$04$abcdefghijklmnopqrstu", "$2b$04$abcdefghijklmnopqrst$u"} {
+		if _, err := CryptSetting("password", bad); err == nil {
+			t.Errorf("invalid setting accepted: %q", bad)
+		}
+	}
+}
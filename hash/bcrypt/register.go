@@ -0,0 +1,19 @@
+package bcrypt
+
+import "strconv"
+import "golang.org/x/crypto/bcrypt"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+import "gopkg.in/hlandau/passlib.v1/registry"
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "bcrypt",
+		Doc:  "bcrypt",
+		Params: []registry.Param{
+			{Name: "cost", Type: registry.Int, Default: strconv.Itoa(RecommendedCost), Min: int64(bcrypt.MinCost), Max: int64(bcrypt.MaxCost), Doc: "log2 of the number of rounds"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(args.Int("cost")), nil
+		},
+	})
+}
@@ -6,7 +6,10 @@ package bcryptsha256
 
 import "gopkg.in/hlandau/passlib.v1/abstract"
 import "gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+import "gopkg.in/hlandau/passlib.v1/hash/bcrypt/raw"
 import "encoding/base64"
+import "crypto/hmac"
+import "crypto/rand"
 import "crypto/sha256"
 import "strings"
 import "fmt"
@@ -19,6 +22,10 @@ type scheme struct {
 // An implementation of Scheme implementing Python passlib's `$bcrypt-sha256$`
 // bcrypt variant. This is bcrypt with a SHA256 prehash, which removes bcrypt's
 // password length limitation.
+//
+// New hashes use the version 2 format introduced in passlib 1.7.3, which uses
+// HMAC-SHA256 keyed with the salt as the prehash. Version 1 hashes are still
+// verified, but are reported as needing an update.
 var Crypter abstract.Scheme
 
 // The recommended cost for bcrypt-sha256. This may change with subsequent releases.
@@ -38,59 +45,110 @@ func New(cost int) abstract.Scheme {
 	}
 }
 
+// Indicates that a bcrypt-sha256 hash is malformed.
+var ErrInvalidStub = fmt.Errorf("invalid bcrypt-sha256 password stub")
+
 func (s *scheme) Hash(password string) (string, error) {
-	p := s.prehash(password)
-	h, err := s.underlying.Hash(p)
+	buf := make([]byte, raw.SaltBytes)
+	_, err := rand.Read(buf)
 	if err != nil {
 		return "", err
 	}
 
-	return mangle(h), nil
+	salt := raw.EncodeSalt(buf)
+	h, err := raw.Crypt([]byte(prehashV2(password, salt)), "2b", s.cost, salt)
+	if err != nil {
+		return "", err
+	}
+
+	// $2b$12$salt  hash
+	return fmt.Sprintf("$bcrypt-sha256$v=2,t=2b,r=%d$%s$%s", s.cost, salt, h[7+raw.SaltLength:]), nil
 }
 
 func (s *scheme) Verify(password, hash string) error {
-	p := s.prehash(password)
-	return s.underlying.Verify(p, demangle(hash))
+	version, salt, h, err := demangle(hash)
+	if err != nil {
+		return err
+	}
+
+	p := prehashV2(password, salt)
+	if version == 1 {
+		p = prehashV1(password)
+	}
+
+	return s.underlying.Verify(p, h)
 }
 
-func (s *scheme) prehash(password string) string {
+// Version 1 hashes use a plain SHA256 prehash.
+func prehashV1(password string) string {
 	h := sha256.New()
 	h.Write([]byte(password))
 	v := base64.StdEncoding.EncodeToString(h.Sum(nil))
 	return v
 }
 
+// Version 2 hashes use HMAC-SHA256 keyed with the bcrypt salt string.
+func prehashV2(password, salt string) string {
+	h := hmac.New(sha256.New, []byte(salt))
+	h.Write([]byte(password))
+	v := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return v
+}
+
 func (s *scheme) SupportsStub(stub string) bool {
-	return strings.HasPrefix(stub, "$bcrypt-sha256$") && s.underlying.SupportsStub(demangle(stub))
+	_, _, h, err := demangle(stub)
+	return err == nil && s.underlying.SupportsStub(h)
 }
 
 func (s *scheme) NeedsUpdate(stub string) bool {
-	return s.underlying.NeedsUpdate(demangle(stub))
+	version, _, h, err := demangle(stub)
+	if err != nil {
+		return false
+	}
+
+	return version < 2 || s.underlying.NeedsUpdate(h)
 }
 
 func (s *scheme) String() string {
 	return fmt.Sprintf("bcrypt-sha256(%d)", s.cost)
 }
 
-func demangle(stub string) string {
-	if strings.HasPrefix(stub, "$bcrypt-sha256$2") {
-		parts := strings.Split(stub[15:], "$")
-		// 0: 2a,12
-		// 1: salt
-		// 2: hash
-		parts0 := strings.Split(parts[0], ",")
-		return "$" + parts0[0] + "$" + fmt.Sprintf("%02s", parts0[1]) + "$" + parts[1] + parts[2]
-	} else {
-		return stub
+// Converts a bcrypt-sha256 hash into the equivalent bcrypt hash, also
+// returning the format version and the bcrypt salt. The formats are:
+//
+//	$bcrypt-sha256$2a,12$salt$hash          // version 1
+//	$bcrypt-sha256$v=2,t=2b,r=12$salt$hash  // version 2
+func demangle(stub string) (version int, salt, hash string, err error) {
+	if !strings.HasPrefix(stub, "$bcrypt-sha256$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(stub[15:], "$")
+	// 0: 2a,12 or v=2,t=2b,r=12
+	// 1: salt
+	// 2: hash
+	if len(parts) != 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	params := strings.Split(parts[0], ",")
+	var ident, cost string
+	switch {
+	case len(params) == 2:
+		version = 1
+		ident, cost = params[0], params[1]
+	case len(params) == 3 && params[0] == "v=2" &&
+		strings.HasPrefix(params[1], "t=") && strings.HasPrefix(params[2], "r="):
+		version = 2
+		ident, cost = params[1][2:], params[2][2:]
+	default:
+		err = ErrInvalidStub
+		return
 	}
-}
 
-func mangle(hash string) string {
-	parts := strings.Split(hash[1:], "$")
-	// 0: 2a
-	// 1: rounds
-	// 2: salt + hash
-	salt := parts[2][0:22]
-	h := parts[2][22:]
-	return "$bcrypt-sha256$" + parts[0] + "," + parts[1] + "$" + salt + "$" + h
+	salt = parts[1]
+	hash = "$" + ident + "$" + fmt.Sprintf("%02s", cost) + "$" + salt + parts[2]
+	return
 }
@@ -0,0 +1,35 @@
+package bcryptsha256
+
+import "testing"
+
+func TestNeedsUpdate(t *testing.T) {
+	c := New(5)
+
+	h, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !c.SupportsStub(h) || c.NeedsUpdate(h) {
+		t.Errorf("unexpected result for new hash: %#v", h)
+	}
+
+	if err := c.Verify("password", h); err != nil {
+		t.Errorf("err verifying: %v (%#v)", err, h)
+	}
+
+	v1 := "$bcrypt-sha256$2a,5$5Hg1DKFqPE8C2aflZ5vVoe$12BjNE0p7axMg55.Y/mHsYiVuFBDQyu"
+	if !c.SupportsStub(v1) || !c.NeedsUpdate(v1) {
+		t.Errorf("version 1 hash should need update")
+	}
+
+	for _, s := range []string{
+		"$bcrypt-sha256$2a,5$5Hg1DKFqPE8C2aflZ5vVoe",
+		"$bcrypt-sha256$v=3,t=2b,r=5$5Hg1DKFqPE8C2aflZ5vVoe$12BjNE0p7axMg55.Y/mHsYiVuFBDQyu",
+		"$2b$05$5Hg1DKFqPE8C2aflZ5vVoe12BjNE0p7axMg55.Y/mHsYiVuFBDQyu",
+	} {
+		if c.SupportsStub(s) {
+			t.Errorf("should not support stub: %#v", s)
+		}
+	}
+}
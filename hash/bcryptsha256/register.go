@@ -0,0 +1,18 @@
+package bcryptsha256
+
+import "strconv"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+import "gopkg.in/hlandau/passlib.v1/registry"
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "bcrypt-sha256",
+		Doc:  "Python passlib's bcrypt-sha256",
+		Params: []registry.Param{
+			{Name: "cost", Type: registry.Int, Default: strconv.Itoa(RecommendedCost), Min: 4, Max: 31, Doc: "log2 of the number of rounds"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(args.Int("cost")), nil
+		},
+	})
+}
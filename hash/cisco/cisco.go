@@ -0,0 +1,90 @@
+// Package cisco implements the Cisco IOS type 5, type 8 and type 9 password
+// hash formats, as used by "enable secret" and "username ... secret".
+//
+// Type 5 is md5-crypt and type 8 and type 9 are PBKDF2-HMAC-SHA256 and scrypt
+// respectively, each with a Cisco-specific encoding. The reversible type 7
+// obfuscation can be decoded using raw.DecodeType7.
+package cisco
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/cisco/raw"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+)
+
+// An implementation of Scheme producing type 5 hashes. These are md5-crypt
+// hashes with 4-character salts, as generated by IOS.
+var Type5Crypter abstract.Scheme
+
+// An implementation of Scheme producing type 8 hashes.
+var Type8Crypter abstract.Scheme
+
+// An implementation of Scheme producing type 9 hashes.
+var Type9Crypter abstract.Scheme
+
+func init() {
+	Type5Crypter = md5crypt.New(4)
+	Type8Crypter = &scheme{typ: 8}
+	Type9Crypter = &scheme{typ: 9}
+}
+
+type scheme struct {
+	typ int
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	typ, _, _, err := raw.Parse(stub)
+	return err == nil && typ == c.typ
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	buf := make([]byte, 11)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return c.hash(password, raw.Encoding.EncodeToString(buf)[0:raw.SaltLength])
+}
+
+func (c *scheme) hash(password, salt string) (string, error) {
+	if c.typ == 8 {
+		return raw.Type8(password, salt), nil
+	}
+
+	return raw.Type9(password, salt)
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	typ, salt, _, err := raw.Parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if typ != c.typ {
+		return raw.ErrInvalidStub
+	}
+
+	newHash, err := c.hash(password, salt)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, newHash) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// The type 8 and type 9 parameters are fixed, so hashes never need an update.
+func (c *scheme) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("cisco-type%d", c.typ)
+}
@@ -0,0 +1,44 @@
+package cisco
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+func TestSchemes(t *testing.T) {
+	for _, tst := range []struct {
+		scheme abstract.Scheme
+		hash   string
+	}{
+		{Type5Crypter, "$1$mERr$hx5rVt7rPNoS4wqbXKX7m0"},
+		{Type8Crypter, "$8$saltsaltsaltsa$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV2"},
+		{Type9Crypter, "$9$saltsaltsaltsa$x41LZkJebZBa0MAlDAaan33pTvyNBOnKoABAogAuBNg"},
+	} {
+		if !tst.scheme.SupportsStub(tst.hash) {
+			t.Errorf("stub not supported: %q", tst.hash)
+		}
+
+		if err := tst.scheme.Verify("cisco", tst.hash); err != nil {
+			t.Errorf("err verifying known good hash: %v (%q)", err, tst.hash)
+		}
+
+		if err := tst.scheme.Verify("ciscp", tst.hash); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v", err)
+		}
+
+		h, err := tst.scheme.Hash("cisco")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if err := tst.scheme.Verify("cisco", h); err != nil || tst.scheme.NeedsUpdate(h) {
+			t.Errorf("cannot verify new hash: %v (%q)", err, h)
+		}
+	}
+
+	if Type9Crypter.SupportsStub("$8$saltsaltsaltsa$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV2") ||
+		Type9Crypter.SupportsStub("$9$LbHX-wg4Z") {
+		t.Errorf("type 9 scheme supports foreign hash")
+	}
+}
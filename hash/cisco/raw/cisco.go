@@ -0,0 +1,104 @@
+// Package raw provides raw implementations of the Cisco IOS type 8 and type 9
+// password hashes, and of the reversible type 7 obfuscation.
+package raw
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+	pbkdf2 "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// The number of PBKDF2-HMAC-SHA256 iterations used by type 8.
+const Type8Iterations = 20000
+
+// The scrypt parameters used by type 9.
+const (
+	Type9N = 16384
+	Type9r = 1
+	Type9p = 1
+)
+
+// The length of type 8 and type 9 salts.
+const SaltLength = 14
+
+const keyLength = 32
+
+// Indicates that a password hash is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid Cisco password hash")
+
+const alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// The base64 encoding used by type 8 and type 9. This is the standard
+// big-endian base64 encoding with the crypt alphabet and without padding.
+var Encoding = base64.NewEncoding(alphabet).WithPadding(base64.NoPadding).Strict()
+
+// Calculates a type 8 hash, which is PBKDF2-HMAC-SHA256 with
+// Type8Iterations iterations.
+//
+// The salt should consist of SaltLength characters from the crypt base64
+// alphabet. The salt characters themselves, rather than their decoded value,
+// are used as the PBKDF2 salt.
+//
+// The format is as follows:
+//
+//	$8$salt$hash
+func Type8(password, salt string) string {
+	key := pbkdf2.Key([]byte(password), []byte(salt), Type8Iterations, keyLength, sha256.New)
+	return "$8$" + salt + "$" + Encoding.EncodeToString(key)
+}
+
+// Calculates a type 9 hash, which is scrypt with the parameters Type9N,
+// Type9r and Type9p. The salt is as for Type8.
+//
+// The format is as follows:
+//
+//	$9$salt$hash
+func Type9(password, salt string) (string, error) {
+	key, err := scrypt.Key([]byte(password), []byte(salt), Type9N, Type9r, Type9p, keyLength)
+	if err != nil {
+		return "", err
+	}
+
+	return "$9$" + salt + "$" + Encoding.EncodeToString(key), nil
+}
+
+// Parses a type 8 or type 9 hash, returning the type (8 or 9), the salt and
+// the decoded hash.
+func Parse(hash string) (typ int, salt string, key []byte, err error) {
+	if len(hash) != 3+SaltLength+1+43 || hash[0] != '$' || hash[2] != '$' || hash[3+SaltLength] != '$' {
+		err = ErrInvalidStub
+		return
+	}
+
+	switch hash[1] {
+	case '8':
+		typ = 8
+	case '9':
+		typ = 9
+	default:
+		err = ErrInvalidStub
+		return
+	}
+
+	salt = hash[3 : 3+SaltLength]
+	for i := 0; i < len(salt); i++ {
+		if !isAlphabet(salt[i]) {
+			err = ErrInvalidStub
+			return
+		}
+	}
+
+	if key, err = Encoding.DecodeString(hash[4+SaltLength:]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	return typ, salt, key, nil
+}
+
+func isAlphabet(c byte) bool {
+	return c == '.' || c == '/' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
@@ -0,0 +1,66 @@
+package raw
+
+import "testing"
+
+func TestType8And9(t *testing.T) {
+	for _, tst := range []struct {
+		password, salt, type8, type9 string
+	}{
+		// hashcat modes 9200 and 9300 examples.
+		{"hashcat", "TnGX/fE4KGHOVU", "$8$TnGX/fE4KGHOVU$pEhnEvxrvaynpi8j4f.EMHr6M.FzU8xnZnBr/tJdFWk", ""},
+		{"hashcat", "2MJBozw/9R3UsU", "", "$9$2MJBozw/9R3UsU$2lFhcKvpghcyw8deP25GOfyZaagyUOGBymkryvOdfo6"},
+		// Generated using Python's hashlib module.
+		{"cisco", "saltsaltsaltsa", "$8$saltsaltsaltsa$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV2", "$9$saltsaltsaltsa$x41LZkJebZBa0MAlDAaan33pTvyNBOnKoABAogAuBNg"},
+	} {
+		if tst.type8 != "" {
+			if h := Type8(tst.password, tst.salt); h != tst.type8 {
+				t.Errorf("type 8 mismatch: got %q, expected %q", h, tst.type8)
+			}
+
+			if typ, salt, key, err := Parse(tst.type8); err != nil || typ != 8 || salt != tst.salt || len(key) != 32 {
+				t.Errorf("cannot parse: %q: %v", tst.type8, err)
+			}
+		}
+
+		if tst.type9 != "" {
+			if h, err := Type9(tst.password, tst.salt); err != nil || h != tst.type9 {
+				t.Errorf("type 9 mismatch: got %q (%v), expected %q", h, err, tst.type9)
+			}
+
+			if typ, salt, key, err := Parse(tst.type9); err != nil || typ != 9 || salt != tst.salt || len(key) != 32 {
+				t.Errorf("cannot parse: %q: %v", tst.type9, err)
+			}
+		}
+	}
+
+	for _, bad := range []string{
+		"$7$saltsaltsaltsa$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV2",
+		"$8$saltsaltsalt+a$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV2",
+		"$8$saltsaltsaltsa$ClWYAoAJtwxxY0tbkDLTT.Mtjxajfq2Js7lfSNKdgV",
+		"$9$LbHX-wg4Z",
+	} {
+		if _, _, _, err := Parse(bad); err == nil {
+			t.Errorf("invalid hash accepted: %q", bad)
+		}
+	}
+}
+
+func TestType7(t *testing.T) {
+	for _, s := range []string{"0822455D0A16", "02050D480809", "070C285F4D06", "094F471A1A0A"} {
+		p, err := DecodeType7(s)
+		if err != nil || p != "cisco" {
+			t.Errorf("cannot decode %q: %q (%v)", s, p, err)
+		}
+
+		offset := int(s[0]-'0')*10 + int(s[1]-'0')
+		if e, err := EncodeType7("cisco", offset); err != nil || e != s {
+			t.Errorf("encode mismatch: got %q (%v), expected %q", e, err, s)
+		}
+	}
+
+	for _, bad := range []string{"", "0", "99AA", "08ZZ", "0822455D0A1"} {
+		if _, err := DecodeType7(bad); err == nil {
+			t.Errorf("invalid string accepted: %q", bad)
+		}
+	}
+}
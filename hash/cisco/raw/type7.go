@@ -0,0 +1,56 @@
+package raw
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The key used by the type 7 obfuscation.
+const type7Key = "dsfd;kfoA,.iyewrkldJKDHSUBsgvca69834ncxv9873254k;fg87"
+
+// Indicates that a type 7 string is malformed.
+var ErrInvalidType7 = fmt.Errorf("invalid Cisco type 7 string")
+
+// Decodes a type 7 password. Type 7 is a reversible obfuscation which offers
+// no security; it is supported so that configurations can be audited.
+//
+// The format is a two-digit decimal offset into the key followed by the hex
+// encoding of the password XORed with the key.
+func DecodeType7(s string) (string, error) {
+	if len(s) < 2 || len(s)%2 != 0 {
+		return "", ErrInvalidType7
+	}
+
+	offset, err := strconv.ParseUint(s[0:2], 10, 8)
+	if err != nil || offset >= uint64(len(type7Key)) {
+		return "", ErrInvalidType7
+	}
+
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return "", ErrInvalidType7
+	}
+
+	for i := range b {
+		b[i] ^= type7Key[(int(offset)+i)%len(type7Key)]
+	}
+
+	return string(b), nil
+}
+
+// Encodes a password using the type 7 obfuscation with the given key offset,
+// which must be in the range 0 to 15 for compatibility with IOS.
+func EncodeType7(password string, offset int) (string, error) {
+	if offset < 0 || offset > 15 {
+		return "", ErrInvalidType7
+	}
+
+	b := []byte(password)
+	for i := range b {
+		b[i] ^= type7Key[(offset+i)%len(type7Key)]
+	}
+
+	return fmt.Sprintf("%02d", offset) + strings.ToUpper(hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,26 @@
+package cisco
+
+import (
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	for _, v := range []struct {
+		name, doc string
+		scheme    *abstract.Scheme
+	}{
+		{"cisco-type5", "Cisco IOS type 5", &Type5Crypter},
+		{"cisco-type8", "Cisco IOS type 8", &Type8Crypter},
+		{"cisco-type9", "Cisco IOS type 9", &Type9Crypter},
+	} {
+		scheme := v.scheme
+		registry.Register(&registry.Factory{
+			Name: v.name,
+			Doc:  v.doc,
+			New: func(args registry.Args) (abstract.Scheme, error) {
+				return *scheme, nil
+			},
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package descrypt implements verification of traditional DES-based crypt(3)
+// hashes, which are still found in old htpasswd and passwd files.
+//
+// These hashes use at most eight password characters and a 12-bit salt, so
+// this scheme is verify-only (see abstract.VerifyOnlyScheme).
+package descrypt
+
+import (
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/descrypt/raw"
+)
+
+// An implementation of Scheme verifying traditional DES-based crypt hashes.
+var Crypter abstract.Scheme
+
+func init() {
+	Crypter = &scheme{}
+}
+
+type scheme struct{}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	_, _, err := raw.Parse(stub)
+	return err == nil
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	return "", abstract.ErrVerifyOnly
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	salt, _, err := raw.Parse(hash)
+	if err != nil {
+		return err
+	}
+
+	newHash, err := raw.Crypt(password, salt)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, newHash) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (c *scheme) VerifyOnly() bool {
+	return true
+}
+
+func (c *scheme) String() string {
+	return "des-crypt"
+}
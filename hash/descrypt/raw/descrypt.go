@@ -0,0 +1,167 @@
+// Package raw provides a raw implementation of the traditional DES-based
+// crypt(3) primitive and of the BSDi extended DES-based variant.
+package raw
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The length of a salt in characters.
+const SaltLength = 2
+
+// The length of a hash, including the salt, in characters.
+const HashLength = 13
+
+// The maximum number of password characters used. Longer passwords are
+// truncated.
+const MaxPasswordLength = 8
+
+// The length of a BSDi extended setting: "_", four characters encoding the
+// iteration count and four characters of salt.
+const ExtendedSettingLength = 9
+
+// The length of a BSDi extended hash, including the setting, in characters.
+const ExtendedHashLength = 20
+
+// The maximum iteration count of a BSDi extended hash.
+const MaxExtendedCount = 1<<24 - 1
+
+// Indicates that a password hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid des-crypt password stub")
+
+const alphabet = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Calculates traditional DES-based crypt. Only the low seven bits of the
+// first MaxPasswordLength bytes of the password are used, and the password is
+// truncated at the first NUL byte.
+//
+// The salt must consist of SaltLength characters from the crypt base64
+// alphabet. Any further characters (such as those of an existing hash) are
+// ignored.
+func Crypt(password, salt string) (string, error) {
+	if len(salt) < SaltLength {
+		return "", ErrInvalidStub
+	}
+
+	s, ok := decodeSalt(salt[0:SaltLength])
+	if !ok {
+		return "", ErrInvalidStub
+	}
+
+	if i := strings.IndexByte(password, 0); i >= 0 {
+		password = password[0:i]
+	}
+
+	var key uint64
+	for i := 0; i < MaxPasswordLength; i++ {
+		key <<= 8
+		if i < len(password) {
+			key |= uint64(password[i] << 1)
+		}
+	}
+
+	return salt[0:SaltLength] + encode(encrypt(key, s, 0, 25)), nil
+}
+
+// Calculates BSDi extended DES-based crypt, which uses the whole password,
+// a 24-bit salt and a variable iteration count. The password is truncated at
+// the first NUL byte. A count of zero is treated as one, as by libxcrypt.
+//
+// The setting consists of ExtendedSettingLength characters as described for
+// ExtendedSettingLength. Any further characters (such as those of an existing
+// hash) are ignored.
+func CryptExtended(password, setting string) (string, error) {
+	if len(setting) < ExtendedSettingLength || setting[0] != '_' {
+		return "", ErrInvalidStub
+	}
+
+	count, ok := decodeSalt(setting[1:5])
+	if !ok {
+		return "", ErrInvalidStub
+	}
+
+	s, ok := decodeSalt(setting[5:9])
+	if !ok {
+		return "", ErrInvalidStub
+	}
+
+	if count == 0 {
+		count = 1
+	}
+
+	if i := strings.IndexByte(password, 0); i >= 0 {
+		password = password[0:i]
+	}
+
+	// The first eight characters form the initial key. Each further group of
+	// up to eight characters is folded in by encrypting the key with itself
+	// and XORing in the group.
+	var key uint64
+	for i := 0; i < 8; i++ {
+		key <<= 8
+		if i < len(password) {
+			key |= uint64(password[i] << 1)
+		}
+	}
+
+	for i := 8; i < len(password); i += 8 {
+		key = encrypt(key, 0, key, 1)
+		for j := 0; j < 8 && i+j < len(password); j++ {
+			key ^= uint64(password[i+j]<<1) << uint(56-8*j)
+		}
+	}
+
+	return setting[0:ExtendedSettingLength] + encode(encrypt(key, s, 0, int(count))), nil
+}
+
+// Parses a DES-based crypt hash, which consists of the salt followed by the
+// encoded hash. The stub is just the salt.
+func Parse(stub string) (salt, hash string, err error) {
+	if (len(stub) != SaltLength && len(stub) != HashLength) || !isValid(stub) {
+		err = ErrInvalidStub
+		return
+	}
+
+	return stub[0:SaltLength], stub[SaltLength:], nil
+}
+
+// Decodes up to four characters of the crypt base64 alphabet, least
+// significant first.
+func decodeSalt(s string) (salt uint32, ok bool) {
+	for i := len(s) - 1; i >= 0; i-- {
+		v := strings.IndexByte(alphabet, s[i])
+		if v < 0 {
+			return 0, false
+		}
+
+		salt = salt<<6 | uint32(v)
+	}
+
+	return salt, true
+}
+
+// Encodes a 64-bit block as 11 characters, most significant bits first.
+func encode(x uint64) string {
+	b := make([]byte, 11)
+	for i := range b {
+		shift := 58 - 6*i
+		if shift >= 0 {
+			b[i] = alphabet[(x>>uint(shift))&63]
+		} else {
+			b[i] = alphabet[(x<<uint(-shift))&63]
+		}
+	}
+
+	return string(b)
+}
+
+func isValid(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(alphabet, s[i]) < 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,74 @@
+package raw
+
+import "testing"
+
+// Generated using libxcrypt.
+var tests = []struct {
+	password, salt, hash string
+}{
+	{"password", "ab", "abJnggxhB/yWI"},
+	{"test", "aa", "aaqPiZY5xR5l."},
+	{"", "..", "..X8NBuQ4l6uQ"},
+	{"abcdefghijkl", "zZ", "zZx8AiUhD/THE"},
+	{"pässwörd", "9.", "9.JN1SKAvX.b2"},
+	{"password", "abJnggxhB/yWI", "abJnggxhB/yWI"},
+	{"test\x00ignored", "aa", "aaqPiZY5xR5l."},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, tst := range tests {
+		h, err := Crypt(tst.password, tst.salt)
+		if err != nil || h != tst.hash {
+			t.Errorf("mismatch: %q: got %q, %v, expected %q", tst.password, h, err, tst.hash)
+		}
+
+		salt, _, err := Parse(tst.hash)
+		if err != nil || salt != tst.hash[0:2] {
+			t.Errorf("cannot parse: %q: %v", tst.hash, err)
+		}
+	}
+
+	for _, bad := range []string{"", "a", "a!", "abJnggxhB/yW", "abJnggxhB/yWI$", "$1$ab$oKsM6dtDD2L1b"} {
+		if _, _, err := Parse(bad); err == nil {
+			t.Errorf("invalid stub accepted: %q", bad)
+		}
+	}
+
+	if _, err := Crypt("password", "a!"); err == nil {
+		t.Errorf("invalid salt accepted")
+	}
+}
+
+// Generated using libxcrypt.
+var testsExtended = []struct {
+	password, setting, hash string
+}{
+	{"password", "_J9..CCCC", "_J9..CCCC.MOp/ZbelpA"},
+	{"", "_J9..CCCC", "_J9..CCCCBeguG7nmIew"},
+	{"aaaaaaaaaaaaaaaaaaaa", "_J9..SALT", "_J9..SALTBl.IRLJL10o"},
+	{"password", "_/...abcd", "_/...abcdJZJP1o1hSpg"},
+	{"password", "_....abcd", "_....abcdJZJP1o1hSpg"},
+	{"password", "_J9..CCCCxxxxxxxxxxxxxx", "_J9..CCCC.MOp/ZbelpA"},
+}
+
+func TestCryptExtended(t *testing.T) {
+	for _, tst := range testsExtended {
+		h, err := CryptExtended(tst.password, tst.setting)
+		if err != nil || h != tst.hash {
+			t.Errorf("mismatch: %q: got %q, %v, expected %q", tst.password, h, err, tst.hash)
+		}
+	}
+
+	for _, bad := range []string{"", "_J9..CCC", "J9..CCCCC", "_J9.!CCCC", "_J9..CC!C"} {
+		if _, err := CryptExtended("password", bad); err == nil {
+			t.Errorf("invalid setting accepted: %q", bad)
+		}
+	}
+}
+
+func TestDES(t *testing.T) {
+	// The worked example from the DES literature.
+	if c := encrypt(0x133457799BBCDFF1, 0, 0x0123456789ABCDEF, 1); c != 0x85E813540F0AB405 {
+		t.Errorf("DES mismatch: got %016X", c)
+	}
+}
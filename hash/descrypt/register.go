@@ -0,0 +1,16 @@
+package descrypt
+
+import (
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "des-crypt",
+		Doc:  "traditional DES-based crypt (verify only)",
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return Crypter, nil
+		},
+	})
+}
@@ -0,0 +1,132 @@
+// Package juniper implements the reversible Juniper JunOS "$9$" password
+// obfuscation.
+//
+// This is not a password hash and offers no security. It is supported so that
+// configurations can be audited and generated. Note that Juniper "$9$"
+// strings are unrelated to Cisco type 9 hashes, which also begin with "$9$".
+package juniper
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+)
+
+var family = [...]string{"QzF3n6/9CAtpu0O", "B1IREhcSyrleKvMW8LXx", "7N-dVbwsY2g4oaJZGUDj", "iHkq.mPf5T"}
+
+var encoding = [...][]int{
+	{1, 4, 32},
+	{1, 16, 32},
+	{1, 8, 32},
+	{1, 64},
+	{1, 32},
+	{1, 4, 16, 128},
+	{1, 32, 64},
+}
+
+var numAlpha = strings.Join(family[:], "")
+
+var alphaNum [256]int
+var extra [256]int
+
+func init() {
+	for i := range alphaNum {
+		alphaNum[i] = -1
+	}
+
+	for i := 0; i < len(numAlpha); i++ {
+		alphaNum[numAlpha[i]] = i
+	}
+
+	for i, f := range family {
+		for j := 0; j < len(f); j++ {
+			extra[f[j]] = 3 - i
+		}
+	}
+}
+
+// Indicates that a "$9$" string is malformed.
+var ErrInvalid = fmt.Errorf("invalid Juniper $9$ string")
+
+// Decodes a Juniper "$9$" string, returning the plaintext.
+func Decode(s string) (string, error) {
+	if !strings.HasPrefix(s, "$9$") || len(s) < 4 {
+		return "", ErrInvalid
+	}
+
+	s = s[3:]
+	for i := 0; i < len(s); i++ {
+		if alphaNum[s[i]] < 0 {
+			return "", ErrInvalid
+		}
+	}
+
+	prev := s[0]
+	s = s[1:]
+	if len(s) < extra[prev] {
+		return "", ErrInvalid
+	}
+
+	s = s[extra[prev]:]
+
+	var out []byte
+	for len(s) > 0 {
+		dec := encoding[len(out)%len(encoding)]
+		if len(s) < len(dec) {
+			return "", ErrInvalid
+		}
+
+		n := 0
+		for i, m := range dec {
+			gap := (alphaNum[s[i]]-alphaNum[prev]+len(numAlpha))%len(numAlpha) - 1
+			n += gap * m
+			prev = s[i]
+		}
+
+		out = append(out, byte(n))
+		s = s[len(dec):]
+	}
+
+	return string(out), nil
+}
+
+// Encodes a plaintext as a Juniper "$9$" string using a random salt.
+func Encode(plaintext string) (string, error) {
+	buf := make([]byte, 4)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	salt := numAlpha[int(buf[0])%len(numAlpha)]
+	r := make([]byte, extra[salt])
+	for i := range r {
+		r[i] = numAlpha[int(buf[1+i])%len(numAlpha)]
+	}
+
+	return encode(plaintext, salt, r), nil
+}
+
+func encode(plaintext string, salt byte, r []byte) string {
+	out := append([]byte("$9$"), salt)
+	out = append(out, r...)
+
+	prev := salt
+	for pos := 0; pos < len(plaintext); pos++ {
+		enc := encoding[pos%len(encoding)]
+		c := int(plaintext[pos])
+
+		gaps := make([]int, len(enc))
+		for i := len(enc) - 1; i >= 0; i-- {
+			gaps[i] = c / enc[i]
+			c %= enc[i]
+		}
+
+		for _, gap := range gaps {
+			prev = numAlpha[(gap+alphaNum[prev]+1)%len(numAlpha)]
+			out = append(out, prev)
+		}
+	}
+
+	return string(out)
+}
@@ -0,0 +1,31 @@
+package juniper
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	// From the documentation of the Perl Crypt::Juniper module.
+	if p, err := Decode("$9$LbHX-wg4Z"); err != nil || p != "lc" {
+		t.Errorf("decode mismatch: %q (%v)", p, err)
+	}
+
+	if e := encode("password", 'Q', []byte("sss")); e != "$9$Qsssz/tu0IcrvBIwgJDmPBIEhSe" {
+		t.Errorf("encode mismatch: %q", e)
+	}
+
+	for _, bad := range []string{"", "$9$", "$1$LbHX-wg4Z", "$9$LbHX-wg4", "$9$LbHX-wg4Z!", "$9$Q"} {
+		if _, err := Decode(bad); err == nil {
+			t.Errorf("invalid string accepted: %q", bad)
+		}
+	}
+
+	for _, p := range []string{"", "a", "password", "Juniper123!$9$ \x7f\xff"} {
+		e, err := Encode(p)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if d, err := Decode(e); err != nil || d != p {
+			t.Errorf("round trip mismatch: %q -> %q -> %q (%v)", p, e, d, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package md5crypt implements md5-crypt, which is also used for Cisco type 5
+// passwords, and Apache's apr1 variant of it.
+//
+// md5-crypt is obsolete and should only be used to verify existing hashes.
+package md5crypt
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt/raw"
+	sha2crypt "gopkg.in/hlandau/passlib.v1/hash/sha2crypt/raw"
+)
+
+// An implementation of Scheme performing md5-crypt with 8-character salts.
+var Crypter abstract.Scheme
+
+// An implementation of Scheme performing Apache's apr1 variant of md5-crypt
+// with 8-character salts.
+var APR1Crypter abstract.Scheme
+
+func init() {
+	Crypter = New(raw.MaxSaltLength)
+	APR1Crypter = NewAPR1(raw.MaxSaltLength)
+}
+
+// Returns an implementation of Scheme performing md5-crypt with salts of the
+// given length, which must not exceed raw.MaxSaltLength.
+func New(saltLength int) abstract.Scheme {
+	return &scheme{
+		saltLength: saltLength,
+		crypt:      raw.Crypt,
+		parse:      raw.Parse,
+		prefix:     "$1$",
+		name:       "md5-crypt",
+	}
+}
+
+// Like New, but performs apr1.
+func NewAPR1(saltLength int) abstract.Scheme {
+	return &scheme{
+		saltLength: saltLength,
+		crypt:      raw.CryptAPR1,
+		parse:      raw.ParseAPR1,
+		prefix:     "$apr1$",
+		name:       "apr-md5-crypt",
+	}
+}
+
+type scheme struct {
+	saltLength   int
+	crypt        func(password, salt string) string
+	parse        func(stub string) (salt, hash string, err error)
+	prefix, name string
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, c.prefix)
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	buf := make([]byte, 9)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	salt := sha2crypt.EncodeBase64(buf)
+	if c.saltLength < len(salt) {
+		salt = salt[0:c.saltLength]
+	}
+
+	return c.crypt(password, salt), nil
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	salt, _, err := c.parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, c.crypt(password, salt)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	salt, _, err := c.parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return len(salt) < c.saltLength
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("%s(%d)", c.name, c.saltLength)
+}
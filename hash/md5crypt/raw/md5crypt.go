@@ -0,0 +1,145 @@
+// Package raw provides a raw implementation of the md5-crypt primitive.
+package raw
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strings"
+
+	sha2crypt "gopkg.in/hlandau/passlib.v1/hash/sha2crypt/raw"
+)
+
+// The maximum salt length. Longer salts are truncated.
+const MaxSaltLength = 8
+
+// Indicates that a password hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid md5-crypt password stub")
+
+// Calculates md5-crypt. The password must be in plaintext and be a UTF-8
+// string.
+//
+// The salt should consist of up to MaxSaltLength characters from the crypt
+// base64 alphabet. Longer salts are truncated.
+//
+// The output is in modular crypt format.
+func Crypt(password, salt string) string {
+	return crypt(password, salt, "$1$")
+}
+
+// Calculates Apache's apr1 variant of md5-crypt, which differs only in its
+// identifier, "$apr1$". The arguments and output are as for Crypt.
+func CryptAPR1(password, salt string) string {
+	return crypt(password, salt, "$apr1$")
+}
+
+func crypt(password, salt, magic string) string {
+	if len(salt) > MaxSaltLength {
+		salt = salt[0:MaxSaltLength]
+	}
+
+	p, s := []byte(password), []byte(salt)
+
+	h := md5.New()
+	h.Write(p)
+	h.Write(s)
+	h.Write(p)
+	final := h.Sum(nil)
+
+	h = md5.New()
+	h.Write(p)
+	h.Write([]byte(magic))
+	h.Write(s)
+	for pl := len(p); pl > 0; pl -= 16 {
+		if pl > 16 {
+			h.Write(final)
+		} else {
+			h.Write(final[0:pl])
+		}
+	}
+
+	for i := len(p); i != 0; i >>= 1 {
+		if (i & 1) != 0 {
+			h.Write([]byte{0})
+		} else {
+			h.Write(p[0:1])
+		}
+	}
+
+	final = h.Sum(nil)
+
+	for i := 0; i < 1000; i++ {
+		h = md5.New()
+		if (i & 1) != 0 {
+			h.Write(p)
+		} else {
+			h.Write(final)
+		}
+		if (i % 3) != 0 {
+			h.Write(s)
+		}
+		if (i % 7) != 0 {
+			h.Write(p)
+		}
+		if (i & 1) != 0 {
+			h.Write(final)
+		} else {
+			h.Write(p)
+		}
+		final = h.Sum(nil)
+	}
+
+	t := []byte{
+		final[12], final[6], final[0],
+		final[13], final[7], final[1],
+		final[14], final[8], final[2],
+		final[15], final[9], final[3],
+		final[5], final[10], final[4],
+		final[11],
+	}
+
+	return magic + salt + "$" + sha2crypt.EncodeBase64(t)
+}
+
+// Parses an md5-crypt modular crypt stub or hash.
+//
+// The format is as follows:
+//
+//	$1$salt$hash   // hash
+//	$1$salt        // stub
+func Parse(stub string) (salt, hash string, err error) {
+	return parse(stub, "$1$")
+}
+
+// Parses an apr1 modular crypt stub or hash, which has the same format as
+// md5-crypt with the identifier "$apr1$".
+func ParseAPR1(stub string) (salt, hash string, err error) {
+	return parse(stub, "$apr1$")
+}
+
+func parse(stub, magic string) (salt, hash string, err error) {
+	if !strings.HasPrefix(stub, magic) {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(stub[len(magic):], "$")
+	switch len(parts) {
+	case 1:
+		salt = parts[0]
+	case 2:
+		salt, hash = parts[0], parts[1]
+		if len(hash) != 22 {
+			err = ErrInvalidStub
+			return
+		}
+	default:
+		err = ErrInvalidStub
+		return
+	}
+
+	if len(salt) > MaxSaltLength {
+		err = ErrInvalidStub
+	}
+
+	return
+}
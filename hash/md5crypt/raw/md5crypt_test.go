@@ -0,0 +1,58 @@
+package raw
+
+import "testing"
+
+// Generated using libxcrypt.
+var tests = []struct {
+	password, salt, hash string
+}{
+	{"password", "ab", "$1$ab$oKsM6dtDD2L1bKowOBX.7."},
+	{"cisco", "mERr", "$1$mERr$hx5rVt7rPNoS4wqbXKX7m0"},
+	{"", "12345678", "$1$12345678$xek.CpjQUVgdf/P2N9KQf/"},
+	{"x", "1234567890", "$1$12345678$7y7mHQRucjgVYVF1mZqKC1"},
+	{"pässwördpässwördpässwördpässwörd", "salt", "$1$salt$mEdiRCb7fA4b9QL2yXR201"},
+}
+
+func TestCrypt(t *testing.T) {
+	for _, tst := range tests {
+		if h := Crypt(tst.password, tst.salt); h != tst.hash {
+			t.Errorf("mismatch: %q: got %q, expected %q", tst.password, h, tst.hash)
+		}
+
+		salt, hash, err := Parse(tst.hash)
+		if err != nil || Crypt(tst.password, salt) != tst.hash || len(hash) != 22 {
+			t.Errorf("cannot parse: %q: %v", tst.hash, err)
+		}
+	}
+
+	for _, bad := range []string{"$5$ab", "$1$ab$short", "$1$123456789$oKsM6dtDD2L1bKowOBX.7.", "$1$ab$oKsM6dtDD2L1bKowOBX.7.$"} {
+		if _, _, err := Parse(bad); err == nil {
+			t.Errorf("invalid stub accepted: %q", bad)
+		}
+	}
+}
+
+// Generated using OpenSSL.
+var testsAPR1 = []struct {
+	password, salt, hash string
+}{
+	{"password", "ab", "$apr1$ab$vZXhMKiOqO1yMl8FLQFrs0"},
+	{"", "12345678", "$apr1$12345678$sHuPAw7VA9xjRbJz7zKV7/"},
+}
+
+func TestCryptAPR1(t *testing.T) {
+	for _, tst := range testsAPR1 {
+		if h := CryptAPR1(tst.password, tst.salt); h != tst.hash {
+			t.Errorf("mismatch: %q: got %q, expected %q", tst.password, h, tst.hash)
+		}
+
+		salt, _, err := ParseAPR1(tst.hash)
+		if err != nil || salt != tst.salt {
+			t.Errorf("cannot parse: %q: %v", tst.hash, err)
+		}
+
+		if _, _, err := Parse(tst.hash); err == nil {
+			t.Errorf("apr1 hash accepted as md5-crypt: %q", tst.hash)
+		}
+	}
+}
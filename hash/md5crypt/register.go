@@ -0,0 +1,32 @@
+package md5crypt
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "md5-crypt",
+		Doc:  "md5-crypt ($1$)",
+		Params: []registry.Param{
+			{Name: "salt_size", Type: registry.Int, Default: strconv.Itoa(raw.MaxSaltLength), Min: 0, Max: raw.MaxSaltLength, Doc: "salt length in characters"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(args.Int("salt_size")), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "apr-md5-crypt",
+		Doc:  "Apache apr1 md5-crypt ($apr1$)",
+		Params: []registry.Param{
+			{Name: "salt_size", Type: registry.Int, Default: strconv.Itoa(raw.MaxSaltLength), Min: 0, Max: raw.MaxSaltLength, Doc: "salt length in characters"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewAPR1(args.Int("salt_size")), nil
+		},
+	})
+}
@@ -0,0 +1,159 @@
+// Package mysql implements the password hash formats stored by MySQL in the
+// authentication_string column of mysql.user.
+//
+// The pre-4.1 format is supported for verification only, so that such
+// accounts can be upgraded.
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/mysql/raw"
+)
+
+// An implementation of Scheme producing MySQL 4.1+ (mysql_native_password)
+// hashes.
+var Crypter41 abstract.Scheme
+
+// An implementation of Scheme verifying pre-4.1 MySQL hashes. This is a
+// verify-only scheme (see abstract.VerifyOnlyScheme).
+var Crypter323 abstract.Scheme
+
+// An implementation of Scheme producing MySQL caching_sha2_password hashes.
+//
+// Uses raw.DefaultIterations.
+var CachingSHA2Crypter abstract.Scheme
+
+func init() {
+	Crypter41 = &mysql41Crypter{}
+	Crypter323 = &mysql323Crypter{}
+	CachingSHA2Crypter = NewCachingSHA2(raw.DefaultIterations)
+}
+
+type mysql41Crypter struct{}
+
+func (c *mysql41Crypter) SupportsStub(stub string) bool {
+	return raw.IsMySQL41(stub)
+}
+
+func (c *mysql41Crypter) Hash(password string) (string, error) {
+	return raw.MySQL41(password), nil
+}
+
+func (c *mysql41Crypter) Verify(password, hash string) error {
+	if !raw.IsMySQL41(hash) {
+		return raw.ErrInvalidStub
+	}
+
+	if !abstract.SecureCompare(strings.ToUpper(hash), raw.MySQL41(password)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *mysql41Crypter) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (c *mysql41Crypter) String() string {
+	return "mysql41"
+}
+
+type mysql323Crypter struct{}
+
+func (c *mysql323Crypter) SupportsStub(stub string) bool {
+	return raw.IsMySQL323(stub)
+}
+
+func (c *mysql323Crypter) Hash(password string) (string, error) {
+	return "", abstract.ErrVerifyOnly
+}
+
+func (c *mysql323Crypter) Verify(password, hash string) error {
+	if !raw.IsMySQL323(hash) {
+		return raw.ErrInvalidStub
+	}
+
+	if !abstract.SecureCompare(strings.ToLower(hash), raw.MySQL323(password)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *mysql323Crypter) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (c *mysql323Crypter) VerifyOnly() bool {
+	return true
+}
+
+func (c *mysql323Crypter) String() string {
+	return "mysql323"
+}
+
+// Returns an implementation of Scheme producing caching_sha2_password hashes
+// with the given iteration count. Each iteration is 1000 rounds of
+// sha256-crypt.
+func NewCachingSHA2(iterations int) abstract.Scheme {
+	return &cachingSHA2Crypter{
+		iterations: iterations,
+	}
+}
+
+type cachingSHA2Crypter struct {
+	iterations int
+}
+
+func (c *cachingSHA2Crypter) SetParams(iterations int) error {
+	c.iterations = iterations
+	return nil
+}
+
+func (c *cachingSHA2Crypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$A$")
+}
+
+func (c *cachingSHA2Crypter) Hash(password string) (string, error) {
+	salt, err := raw.GenerateSalt()
+	if err != nil {
+		return "", err
+	}
+
+	return raw.CachingSHA2(password, salt, c.iterations)
+}
+
+func (c *cachingSHA2Crypter) Verify(password, hash string) error {
+	salt, digest, iterations, err := raw.ParseCachingSHA2(hash)
+	if err != nil {
+		return err
+	}
+
+	newHash, err := raw.CachingSHA2(password, salt, iterations)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(digest, newHash[len(newHash)-len(digest):]) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *cachingSHA2Crypter) NeedsUpdate(stub string) bool {
+	_, _, iterations, err := raw.ParseCachingSHA2(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return iterations < c.iterations
+}
+
+func (c *cachingSHA2Crypter) String() string {
+	return fmt.Sprintf("mysql-caching-sha2(%d)", c.iterations)
+}
@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+func TestSchemes(t *testing.T) {
+	for _, tst := range []struct {
+		scheme abstract.Scheme
+		hash   string
+	}{
+		{Crypter41, "*6C8989366EAF75BB670AD8EA7A7FC1176A95CEF4"},
+		{Crypter41, "*6c8989366eaf75bb670ad8ea7a7fc1176a95cef4"},
+		{Crypter323, "6f8c114b58f2ce9e"},
+		{Crypter323, "6F8C114B58F2CE9E"},
+		{CachingSHA2Crypter, "$A$005$saltsaltsaltsaltsaltAqT3KKQ1IEvoYJAspsaQPYYmgLNRKSkHfBuJwHPDSc."},
+	} {
+		if !tst.scheme.SupportsStub(tst.hash) {
+			t.Errorf("stub not supported: %q", tst.hash)
+		}
+
+		if err := tst.scheme.Verify("mypass", tst.hash); err != nil {
+			t.Errorf("err verifying known good hash: %v (%q)", err, tst.hash)
+		}
+
+		if err := tst.scheme.Verify("mypasx", tst.hash); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v", err)
+		}
+	}
+
+	if _, err := Crypter323.Hash("mypass"); err != abstract.ErrVerifyOnly {
+		t.Errorf("unexpected result hashing with mysql323: %v", err)
+	}
+
+	if !Crypter323.NeedsUpdate("6f8c114b58f2ce9e") {
+		t.Errorf("expected mysql323 to need update")
+	}
+
+	for _, c := range []abstract.Scheme{Crypter41, CachingSHA2Crypter} {
+		h, err := c.Hash("mypass")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if err := c.Verify("mypass", h); err != nil || c.NeedsUpdate(h) {
+			t.Errorf("cannot verify new hash: %v (%q)", err, h)
+		}
+	}
+
+	if !NewCachingSHA2(10).NeedsUpdate("$A$005$saltsaltsaltsaltsaltAqT3KKQ1IEvoYJAspsaQPYYmgLNRKSkHfBuJwHPDSc.") {
+		t.Errorf("expected update for higher iteration count")
+	}
+}
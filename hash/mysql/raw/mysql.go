@@ -0,0 +1,64 @@
+// Package raw provides raw implementations of the password hash formats used
+// by MySQL for the mysql_native_password, pre-4.1 and caching_sha2_password
+// authentication plugins.
+package raw
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Indicates that a password hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid MySQL password hash")
+
+// Calculates a MySQL 4.1+ (mysql_native_password) hash, which is "*" followed
+// by the uppercase hex encoding of SHA1(SHA1(password)).
+func MySQL41(password string) string {
+	h1 := sha1.Sum([]byte(password))
+	h2 := sha1.Sum(h1[:])
+	return "*" + strings.ToUpper(hex.EncodeToString(h2[:]))
+}
+
+// Returns true if the string has the form of a MySQL 4.1+ hash.
+func IsMySQL41(hash string) bool {
+	return len(hash) == 41 && hash[0] == '*' && isHex(hash[1:])
+}
+
+// Calculates a pre-4.1 MySQL (OLD_PASSWORD) hash, which is 16 lowercase hex
+// digits. Spaces and tabs in the password are ignored.
+//
+// This hash is trivially broken and should only be used for verification.
+func MySQL323(password string) string {
+	nr, nr2, add := uint32(1345345333), uint32(0x12345671), uint32(7)
+	for i := 0; i < len(password); i++ {
+		c := password[i]
+		if c == ' ' || c == '\t' {
+			continue
+		}
+
+		tmp := uint32(c)
+		nr ^= (((nr & 63) + add) * tmp) + (nr << 8)
+		nr2 += (nr2 << 8) ^ nr
+		add += tmp
+	}
+
+	return fmt.Sprintf("%08x%08x", nr&0x7FFFFFFF, nr2&0x7FFFFFFF)
+}
+
+// Returns true if the string has the form of a pre-4.1 MySQL hash.
+func IsMySQL323(hash string) bool {
+	return len(hash) == 16 && isHex(hash)
+}
+
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+
+	return true
+}
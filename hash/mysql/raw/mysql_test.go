@@ -0,0 +1,68 @@
+package raw
+
+import "testing"
+
+// Generated using a Python implementation of the MySQL algorithms.
+var mysqlTests = []struct {
+	password, mysql41, mysql323 string
+}{
+	{"mypass", "*6C8989366EAF75BB670AD8EA7A7FC1176A95CEF4", "6f8c114b58f2ce9e"},
+	{"", "*BE1BDEC0AA74B4DCB079943E70528096CCA985F8", "5030573512345671"},
+	{"pass word", "*EDBBEA7F4E7B5D8B0BC8D7AC5D1936FB7DA10611", "5d2e19393cc5ef67"},
+	{"été", "*2D008F04D2AD0916292B17D78838895DCBE0FC32", "4c9d27e26596c3dd"},
+}
+
+func TestMySQL(t *testing.T) {
+	for _, tst := range mysqlTests {
+		if h := MySQL41(tst.password); h != tst.mysql41 || !IsMySQL41(h) {
+			t.Errorf("mysql41 mismatch: %q: got %q, expected %q", tst.password, h, tst.mysql41)
+		}
+
+		if h := MySQL323(tst.password); h != tst.mysql323 || !IsMySQL323(h) {
+			t.Errorf("mysql323 mismatch: %q: got %q, expected %q", tst.password, h, tst.mysql323)
+		}
+	}
+}
+
+var cachingSHA2Tests = []struct {
+	password   string
+	salt       string
+	iterations int
+	hash       string
+}{
+	// hashcat mode 7401 example.
+	{"hashcat", "\xf9\xcc\x98\xce\x08\x89)$\xf5\n!;k\xc5q\xa2\xc1\x17x\xc5", 5, "$A$005$\xf9\xcc\x98\xce\x08\x89)$\xf5\n!;k\xc5q\xa2\xc1\x17x\xc5bTy95Y99eAME1dwEkHOA1ndHGBWz.1bxSSRkuTXFGV/"},
+	{"password", "saltsaltsaltsaltsalt", 5, "$A$005$saltsaltsaltsaltsalt5SZd752QTW8/mT/5h.Rqvp/3mfPJ3Ut06Xumw96laKC"},
+	{"password", "saltsaltsaltsaltsalt", 10, "$A$00A$saltsaltsaltsaltsaltKjm7T3VL0IpVNZQzERSKJMztqi2ymL72fD6yiN2.d6A"},
+}
+
+func TestCachingSHA2(t *testing.T) {
+	for _, tst := range cachingSHA2Tests {
+		h, err := CachingSHA2(tst.password, []byte(tst.salt), tst.iterations)
+		if err != nil || h != tst.hash {
+			t.Errorf("hash mismatch: got %q (%v), expected %q", h, err, tst.hash)
+		}
+
+		salt, _, iterations, err := ParseCachingSHA2(tst.hash)
+		if err != nil || string(salt) != tst.salt || iterations != tst.iterations {
+			t.Errorf("parse mismatch: %q", tst.hash)
+		}
+	}
+
+	for _, bad := range []string{"$A$000$saltsaltsaltsaltsalt5SZd752QTW8/mT/5h.Rqvp/3mfPJ3Ut06Xumw96laKC", "$A$005$salt", "$5$saltsaltsaltsaltsalt5SZd752QTW8/mT/5h.Rqvp/3mfPJ3Ut06Xumw96laKC"} {
+		if _, _, _, err := ParseCachingSHA2(bad); err == nil {
+			t.Errorf("invalid hash accepted: %q", bad)
+		}
+	}
+
+	salt, err := GenerateSalt()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, c := range salt {
+		if c == 0 || c == '$' || c >= 0x80 {
+			t.Errorf("invalid salt character: %v", c)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package raw
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"strings"
+
+	sha2crypt "gopkg.in/hlandau/passlib.v1/hash/sha2crypt/raw"
+)
+
+// The default iteration count for caching_sha2_password hashes, which is
+// also the MySQL default.
+const DefaultIterations = 5
+
+// The maximum iteration count representable in a caching_sha2_password hash.
+const MaxIterations = 0xFFF
+
+// The number of sha256-crypt rounds per unit of the iteration count.
+const RoundsPerIteration = 1000
+
+// The length of a caching_sha2_password salt in bytes.
+const SaltLength = 20
+
+// The length of the encoded digest in a caching_sha2_password hash.
+const hashLength = 43
+
+// Indicates that the iteration count specified is not in the valid range.
+var ErrInvalidIterations = fmt.Errorf("invalid caching_sha2_password iteration count")
+
+// Calculates a MySQL caching_sha2_password hash in the $A$ format.
+//
+// The format is as follows:
+//
+//	$A$iii$salthash
+//
+// where iii is the iteration count as three uppercase hex digits, salt is
+// SaltLength bytes and hash is a 43-character sha256-crypt digest computed
+// over iii*1000 rounds using the full salt.
+//
+// salt must be SaltLength bytes which are neither NUL nor '$'; see
+// GenerateSalt. iterations must be in the range 1 <= iterations <=
+// MaxIterations.
+func CachingSHA2(password string, salt []byte, iterations int) (string, error) {
+	if iterations < 1 || iterations > MaxIterations {
+		return "", ErrInvalidIterations
+	}
+
+	if len(salt) != SaltLength {
+		return "", ErrInvalidStub
+	}
+
+	hash := sha2crypt.CryptHash256([]byte(password), salt, iterations*RoundsPerIteration)
+	return fmt.Sprintf("$A$%03X$%s%s", iterations, salt, hash), nil
+}
+
+// Generates a random salt in the form used by MySQL, which consists of
+// printable and non-printable 7-bit characters other than NUL and '$'.
+func GenerateSalt() ([]byte, error) {
+	salt := make([]byte, SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range salt {
+		salt[i] &= 0x7F
+		if salt[i] == 0 || salt[i] == '$' {
+			salt[i]++
+		}
+	}
+
+	return salt, nil
+}
+
+// Parses a caching_sha2_password hash in the $A$ format.
+func ParseCachingSHA2(stub string) (salt []byte, hash string, iterations int, err error) {
+	if !strings.HasPrefix(stub, "$A$") || len(stub) != 7+SaltLength+hashLength || stub[6] != '$' {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(stub[3:6], 16, 16)
+	if err != nil || n < 1 {
+		err = ErrInvalidIterations
+		return
+	}
+
+	return []byte(stub[7 : 7+SaltLength]), stub[7+SaltLength:], int(n), nil
+}
@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/mysql/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "mysql41",
+		Doc:  "MySQL mysql_native_password",
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return Crypter41, nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "mysql323",
+		Doc:  "MySQL pre-4.1 (verify only)",
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return Crypter323, nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "mysql-caching-sha2",
+		Doc:  "MySQL caching_sha2_password ($A$)",
+		Params: []registry.Param{
+			{Name: "iterations", Type: registry.Int, Default: strconv.Itoa(raw.DefaultIterations), Min: 1, Max: raw.MaxIterations, Doc: "thousands of SHA-256 rounds"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewCachingSHA2(args.Int("iterations")), nil
+		},
+	})
+}
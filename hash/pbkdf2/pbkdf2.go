@@ -2,6 +2,10 @@
 // PBKDF2-SHA256 and PBKDF-SHA512.
 //
 // The format is the same as that used by Python's passlib and is compatible.
+//
+// A number of other PBKDF2-based formats are also supported: Atlassian
+// {PKCS5S2} hashes, GRUB 2 grub.pbkdf2.sha512 hashes, and the cta and dlitz
+// $p5k2$ formats.
 package pbkdf2
 
 import (
@@ -92,3 +96,7 @@ func (s *scheme) NeedsUpdate(stub string) bool {
 	_, rounds, salt, _, err := raw.Parse(stub)
 	return err == raw.ErrInvalidRounds || rounds < s.Rounds || len(salt) < SaltLength
 }
+
+func (s *scheme) String() string {
+	return fmt.Sprintf("%s(%d)", strings.Trim(s.Ident, "$"), s.Rounds)
+}
@@ -11,5 +11,11 @@ const (
 )
 
 func Hash(password, salt []byte, rounds int, hf func() hash.Hash) (hash string) {
-	return Base64Encode(pbkdf2.Key(password, salt, rounds, hf().Size(), hf))
+	return Base64Encode(Key(password, salt, rounds, hf().Size(), hf))
+}
+
+// Derives a raw key of keyLen bytes using PBKDF2 with the given hash
+// function.
+func Key(password, salt []byte, rounds, keyLen int, hf func() hash.Hash) []byte {
+	return pbkdf2.Key(password, salt, rounds, keyLen, hf)
 }
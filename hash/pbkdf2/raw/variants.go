@@ -0,0 +1,209 @@
+package raw
+
+import (
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The number of rounds used by Atlassian {PKCS5S2} hashes.
+const AtlassianRounds = 10000
+
+// The rounds value which is omitted from dlitz $p5k2$ hashes.
+const DlitzDefaultRounds = 400
+
+func checkRounds(n uint64) (int, error) {
+	if n < MinRounds || n > MaxRounds {
+		return 0, ErrInvalidRounds
+	}
+
+	return int(n), nil
+}
+
+// Calculates an Atlassian {PKCS5S2} hash, which is the base64 encoding of
+// a 16-byte salt followed by a 32-byte PBKDF2-HMAC-SHA1 key derived with
+// AtlassianRounds rounds.
+func Atlassian(password string, salt []byte) (string, error) {
+	if len(salt) != 16 {
+		return "", ErrInvalidStub
+	}
+
+	key := Key([]byte(password), salt, AtlassianRounds, 32, sha1.New)
+	return "{PKCS5S2}" + base64.StdEncoding.EncodeToString(append(append([]byte(nil), salt...), key...)), nil
+}
+
+// Parses an Atlassian {PKCS5S2} hash.
+func ParseAtlassian(hash string) (salt, key []byte, err error) {
+	if !strings.HasPrefix(hash, "{PKCS5S2}") {
+		return nil, nil, ErrInvalidStub
+	}
+
+	b, err := base64.StdEncoding.DecodeString(hash[9:])
+	if err != nil || len(b) != 48 {
+		return nil, nil, ErrInvalidStub
+	}
+
+	return b[0:16], b[16:], nil
+}
+
+// Calculates a GRUB 2 PBKDF2 password hash, as produced by
+// grub-mkpasswd-pbkdf2.
+//
+// The format is as follows:
+//
+//	grub.pbkdf2.sha512.rounds.salt.hash
+//
+// where salt and hash are in uppercase hex. The hash is a 64-byte
+// PBKDF2-HMAC-SHA512 key.
+func GRUB(password string, salt []byte, rounds int) string {
+	key := Key([]byte(password), salt, rounds, 64, sha512.New)
+	return fmt.Sprintf("grub.pbkdf2.sha512.%d.%s.%s", rounds,
+		strings.ToUpper(hex.EncodeToString(salt)), strings.ToUpper(hex.EncodeToString(key)))
+}
+
+// Parses a GRUB 2 PBKDF2 password hash.
+func ParseGRUB(hash string) (rounds int, salt, key []byte, err error) {
+	if !strings.HasPrefix(hash, "grub.pbkdf2.sha512.") {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(hash[19:], ".")
+	if len(parts) != 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(parts[0], 10, 31)
+	if err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	if rounds, err = checkRounds(n); err != nil {
+		return
+	}
+
+	salt, err = hex.DecodeString(parts[1])
+	if err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	key, err = hex.DecodeString(parts[2])
+	if err != nil || len(key) != 64 {
+		err = ErrInvalidStub
+		return
+	}
+
+	return
+}
+
+var ctaEncoding = base64.URLEncoding
+
+// Calculates a $p5k2$ hash in the format used by Python passlib's
+// cta_pbkdf2_sha1, which is PBKDF2-HMAC-SHA1.
+//
+// The format is as follows:
+//
+//	$p5k2$rounds$salt$hash
+//
+// where rounds is in lowercase hex and salt and hash are in padded base64
+// using the URL-safe alphabet.
+func CTA(password string, salt []byte, rounds int) string {
+	key := Key([]byte(password), salt, rounds, sha1.Size, sha1.New)
+	return fmt.Sprintf("$p5k2$%x$%s$%s", rounds, ctaEncoding.EncodeToString(salt), ctaEncoding.EncodeToString(key))
+}
+
+// Parses a cta $p5k2$ hash.
+func ParseCTA(hash string) (rounds int, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "p5k2" || len(parts[4]) != 28 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if rounds, err = parseHexRounds(parts[2]); err != nil {
+		return
+	}
+
+	if salt, err = ctaEncoding.DecodeString(parts[3]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	if key, err = ctaEncoding.DecodeString(parts[4]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	return
+}
+
+func parseHexRounds(s string) (int, error) {
+	// Lowercase hex without leading zeroes.
+	n, err := strconv.ParseUint(s, 16, 31)
+	if err != nil || strconv.FormatUint(n, 16) != s {
+		return 0, ErrInvalidStub
+	}
+
+	return checkRounds(n)
+}
+
+// Calculates a $p5k2$ hash in the format produced by Dwayne Litzenberger's
+// PBKDF2 crypt, which is also supported by Python passlib as
+// dlitz_pbkdf2_sha1.
+//
+// The format is as follows:
+//
+//	$p5k2$rounds$salt$hash
+//
+// where rounds is in lowercase hex, or empty if it is DlitzDefaultRounds, and
+// hash is a 24-byte PBKDF2-HMAC-SHA1 key encoded in base64 with '.' in place
+// of '+'. The salt is used as a string, and the whole setting string
+// "$p5k2$rounds$salt" is used as the PBKDF2 salt.
+func Dlitz(password, salt string, rounds int) string {
+	r := ""
+	if rounds != DlitzDefaultRounds {
+		r = strconv.FormatUint(uint64(rounds), 16)
+	}
+
+	setting := "$p5k2$" + r + "$" + salt
+	key := Key([]byte(password), []byte(setting), rounds, 24, sha1.New)
+	return setting + "$" + Base64Encode(key)
+}
+
+// Parses a dlitz $p5k2$ hash.
+func ParseDlitz(hash string) (rounds int, salt string, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "p5k2" || len(parts[4]) != 32 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if parts[2] == "" {
+		rounds = DlitzDefaultRounds
+	} else if rounds, err = parseHexRounds(parts[2]); err != nil {
+		return
+	}
+
+	salt = parts[3]
+	for i := 0; i < len(salt); i++ {
+		c := salt[i]
+		if !(c == '.' || c == '/' || (c >= '0' && c <= '9') || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')) {
+			err = ErrInvalidStub
+			return
+		}
+	}
+
+	if key, err = Base64Decode(parts[4]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	return
+}
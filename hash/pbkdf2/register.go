@@ -0,0 +1,68 @@
+package pbkdf2
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func roundsParam(def int) []registry.Param {
+	return []registry.Param{
+		{Name: "rounds", Type: registry.Int, Default: strconv.Itoa(def), Min: raw.MinRounds, Max: raw.MaxRounds, Doc: "number of PBKDF2 iterations"},
+	}
+}
+
+func registerPasslib(name, ident string, hf func() hash.Hash, def int) {
+	registry.Register(&registry.Factory{
+		Name:   name,
+		Doc:    "PBKDF2 in Python passlib's " + ident + " format",
+		Params: roundsParam(def),
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return New(ident, hf, args.Int("rounds")), nil
+		},
+	})
+}
+
+func init() {
+	registerPasslib("pbkdf2-sha1", "$pbkdf2$", sha1.New, RecommendedRoundsSHA1)
+	registerPasslib("pbkdf2-sha256", "$pbkdf2-sha256$", sha256.New, RecommendedRoundsSHA256)
+	registerPasslib("pbkdf2-sha512", "$pbkdf2-sha512$", sha512.New, RecommendedRoundsSHA512)
+
+	registry.Register(&registry.Factory{
+		Name: "atlassian-pbkdf2-sha1",
+		Doc:  "Atlassian {PKCS5S2}",
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewAtlassian(), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "grub-pbkdf2-sha512",
+		Doc:    "GRUB 2 grub.pbkdf2.sha512",
+		Params: roundsParam(RecommendedRoundsGRUB),
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewGRUB(args.Int("rounds")), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "cta-pbkdf2-sha1",
+		Doc:    "Python passlib's cta $p5k2$",
+		Params: roundsParam(RecommendedRoundsCTA),
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewCTA(args.Int("rounds")), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "dlitz-pbkdf2-sha1",
+		Doc:    "dlitz $p5k2$",
+		Params: roundsParam(RecommendedRoundsDlitz),
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewDlitz(args.Int("rounds")), nil
+		},
+	})
+}
@@ -0,0 +1,261 @@
+package pbkdf2
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// An implementation of Scheme for Atlassian {PKCS5S2} hashes, as used by
+// Confluence, Jira and Crowd. The rounds count is fixed by the format, so
+// these hashes never need updating on their own account; placing a stronger
+// scheme first in the context upgrades them.
+//
+// WARNING: This format uses PBKDF2-SHA1 with a low, fixed rounds count. It
+// should be used for legacy compatibility only.
+var AtlassianCrypter abstract.Scheme
+
+// An implementation of Scheme for GRUB 2 grub.pbkdf2.sha512 bootloader
+// password hashes. Uses RecommendedRoundsGRUB.
+var GRUBCrypter abstract.Scheme
+
+// An implementation of Scheme for Python passlib's cta_pbkdf2_sha1 $p5k2$
+// format. Uses RecommendedRoundsCTA.
+//
+// WARNING: This format uses PBKDF2-SHA1. It should be used for legacy
+// compatibility only.
+var CTACrypter abstract.Scheme
+
+// An implementation of Scheme for Dwayne Litzenberger's $p5k2$ format
+// (dlitz_pbkdf2_sha1 in Python passlib). Uses RecommendedRoundsDlitz.
+//
+// WARNING: This format uses PBKDF2-SHA1. It should be used for legacy
+// compatibility only.
+var DlitzCrypter abstract.Scheme
+
+const (
+	RecommendedRoundsGRUB  = 19000
+	RecommendedRoundsCTA   = 60000
+	RecommendedRoundsDlitz = 60000
+)
+
+// The salt length used by GRUBCrypter. grub-mkpasswd-pbkdf2 uses 64 bytes.
+const SaltLengthGRUB = 64
+
+func init() {
+	AtlassianCrypter = NewAtlassian()
+	GRUBCrypter = NewGRUB(RecommendedRoundsGRUB)
+	CTACrypter = NewCTA(RecommendedRoundsCTA)
+	DlitzCrypter = NewDlitz(RecommendedRoundsDlitz)
+}
+
+// Atlassian
+
+type atlassianScheme struct{}
+
+// Returns an implementation of Scheme for Atlassian {PKCS5S2} hashes.
+func NewAtlassian() abstract.Scheme {
+	return atlassianScheme{}
+}
+
+func (atlassianScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "{PKCS5S2}")
+}
+
+func (atlassianScheme) Hash(password string) (string, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Atlassian(password, salt)
+}
+
+func (atlassianScheme) Verify(password, hash string) error {
+	salt, _, err := raw.ParseAtlassian(hash)
+	if err != nil {
+		return err
+	}
+
+	newHash, err := raw.Atlassian(password, salt)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, newHash) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (atlassianScheme) NeedsUpdate(stub string) bool {
+	return false
+}
+
+func (atlassianScheme) String() string {
+	return "pbkdf2-atlassian"
+}
+
+// GRUB
+
+type grubScheme struct {
+	rounds int
+}
+
+// Returns an implementation of Scheme for GRUB 2 grub.pbkdf2.sha512 hashes
+// using the given number of rounds.
+func NewGRUB(rounds int) abstract.Scheme {
+	return &grubScheme{rounds: rounds}
+}
+
+func (s *grubScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "grub.pbkdf2.sha512.")
+}
+
+func (s *grubScheme) Hash(password string) (string, error) {
+	salt := make([]byte, SaltLengthGRUB)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.GRUB(password, salt, s.rounds), nil
+}
+
+func (s *grubScheme) Verify(password, hash string) error {
+	rounds, salt, key, err := raw.ParseGRUB(hash)
+	if err != nil {
+		return err
+	}
+
+	newKey := raw.Key([]byte(password), salt, rounds, len(key), sha512.New)
+	if !abstract.SecureCompare(string(key), string(newKey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *grubScheme) NeedsUpdate(stub string) bool {
+	rounds, salt, _, err := raw.ParseGRUB(stub)
+	return err == raw.ErrInvalidRounds || (err == nil && (rounds < s.rounds || len(salt) < SaltLengthGRUB))
+}
+
+func (s *grubScheme) String() string {
+	return fmt.Sprintf("pbkdf2-grub(%d)", s.rounds)
+}
+
+// cta
+
+type ctaScheme struct {
+	rounds int
+}
+
+// Returns an implementation of Scheme for Python passlib's cta_pbkdf2_sha1
+// $p5k2$ format using the given number of rounds.
+func NewCTA(rounds int) abstract.Scheme {
+	return &ctaScheme{rounds: rounds}
+}
+
+// cta and dlitz hashes share the $p5k2$ prefix and are distinguished by the
+// length of the checksum.
+func (s *ctaScheme) SupportsStub(stub string) bool {
+	if !strings.HasPrefix(stub, "$p5k2$") {
+		return false
+	}
+
+	i := strings.LastIndexByte(stub, '$')
+	return len(stub)-i-1 == 28
+}
+
+func (s *ctaScheme) Hash(password string) (string, error) {
+	salt := make([]byte, SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.CTA(password, salt, s.rounds), nil
+}
+
+func (s *ctaScheme) Verify(password, hash string) error {
+	rounds, salt, _, err := raw.ParseCTA(hash)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, raw.CTA(password, salt, rounds)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *ctaScheme) NeedsUpdate(stub string) bool {
+	rounds, salt, _, err := raw.ParseCTA(stub)
+	return err == raw.ErrInvalidRounds || (err == nil && (rounds < s.rounds || len(salt) < SaltLength))
+}
+
+func (s *ctaScheme) String() string {
+	return fmt.Sprintf("pbkdf2-cta(%d)", s.rounds)
+}
+
+// dlitz
+
+type dlitzScheme struct {
+	rounds int
+}
+
+// Returns an implementation of Scheme for the dlitz $p5k2$ format using the
+// given number of rounds.
+func NewDlitz(rounds int) abstract.Scheme {
+	return &dlitzScheme{rounds: rounds}
+}
+
+func (s *dlitzScheme) SupportsStub(stub string) bool {
+	if !strings.HasPrefix(stub, "$p5k2$") {
+		return false
+	}
+
+	i := strings.LastIndexByte(stub, '$')
+	return len(stub)-i-1 == 32
+}
+
+func (s *dlitzScheme) Hash(password string) (string, error) {
+	// 12 bytes encode to 16 characters of the dlitz salt alphabet.
+	salt := make([]byte, 12)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Dlitz(password, raw.Base64Encode(salt), s.rounds), nil
+}
+
+func (s *dlitzScheme) Verify(password, hash string) error {
+	rounds, salt, _, err := raw.ParseDlitz(hash)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(hash, raw.Dlitz(password, salt, rounds)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (s *dlitzScheme) NeedsUpdate(stub string) bool {
+	rounds, salt, _, err := raw.ParseDlitz(stub)
+	return err == raw.ErrInvalidRounds || (err == nil && (rounds < s.rounds || len(salt) < 16))
+}
+
+func (s *dlitzScheme) String() string {
+	return fmt.Sprintf("pbkdf2-dlitz(%d)", s.rounds)
+}
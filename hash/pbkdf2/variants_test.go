@@ -0,0 +1,103 @@
+package pbkdf2
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+var test_dlitz = []test{
+	// From passlib 1.7
+	{"dcl", "$p5k2$d$tUsch7fU$nqDkaxMDOFBeJsTSfABsyn.PYUXilHwL"},
+	{"spam", "$p5k2$3e8$H0NX9mT/$wk/sE8vv6OMKuMaqazCJYDSUhWY9YB2J"},
+	{"cloadm", "$p5k2$$exec$r1EWMCMk7Rlv3L/RNcFXviDefYa0hlql"},
+}
+
+var test_cta = []test{
+	// From passlib 1.7
+	{"password", "$p5k2$1$$h1TDLGSw9ST8UMAPeIE13i0t12c="},
+	{"hashy the ☃", "$p5k2$1000$ZxK4ZBJCfQg=$jJZVscWtO--p1-xIZl6jhO2LKR0="},
+}
+
+var test_atlassian = []test{
+	{"password", "{PKCS5S2}AAECAwQFBgcICQoLDA0OD44+L3PD62OQqBq7yBAcA0OwF6ev//tatl4TTwkJ3Mos"},
+}
+
+func testVariant(t *testing.T, crypter abstract.Scheme, hashes []test) {
+	for _, h := range hashes {
+		if !crypter.SupportsStub(h.hash) {
+			t.Errorf("%v: crypter reports not support valid stub: %s", crypter, h.hash)
+		}
+
+		if err := crypter.Verify(h.password, h.hash); err != nil {
+			t.Errorf("%v: unable to verify password %s: %v", crypter, h.password, err)
+		}
+
+		if err := crypter.Verify(h.password+"x", h.hash); err == nil {
+			t.Errorf("%v: invalid password accepted", crypter)
+		}
+	}
+
+	hash, err := crypter.Hash("helloworld")
+	if err != nil {
+		t.Fatalf("%v: recieved error whilst hashing password: %v", crypter, err)
+	}
+
+	if !crypter.SupportsStub(hash) {
+		t.Errorf("%v: crypter does not support own hash: %s", crypter, hash)
+	}
+
+	if err := crypter.Verify("helloworld", hash); err != nil {
+		t.Errorf("%v: valid password not accepted: %v", crypter, err)
+	}
+
+	if err := crypter.Verify("goodbyeuniverse", hash); err == nil {
+		t.Errorf("%v: invalid password accepted", crypter)
+	}
+
+	if crypter.NeedsUpdate(hash) {
+		t.Errorf("%v: new hash reported as needing update", crypter)
+	}
+}
+
+func TestAtlassian(t *testing.T) {
+	testVariant(t, AtlassianCrypter, test_atlassian)
+}
+
+func TestGRUB(t *testing.T) {
+	testVariant(t, NewGRUB(1000), nil)
+
+	if !GRUBCrypter.NeedsUpdate("grub.pbkdf2.sha512.1000.00.00000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000") {
+		t.Errorf("weak GRUB hash not reported as needing update")
+	}
+}
+
+func TestCTA(t *testing.T) {
+	testVariant(t, NewCTA(1000), test_cta)
+
+	if !CTACrypter.NeedsUpdate(test_cta[1].hash) {
+		t.Errorf("weak cta hash not reported as needing update")
+	}
+}
+
+func TestDlitz(t *testing.T) {
+	testVariant(t, NewDlitz(1000), test_dlitz)
+
+	if !DlitzCrypter.NeedsUpdate(test_dlitz[0].hash) {
+		t.Errorf("weak dlitz hash not reported as needing update")
+	}
+}
+
+func TestP5K2Discrimination(t *testing.T) {
+	for _, h := range test_dlitz {
+		if CTACrypter.SupportsStub(h.hash) {
+			t.Errorf("cta claims dlitz hash: %s", h.hash)
+		}
+	}
+
+	for _, h := range test_cta {
+		if DlitzCrypter.SupportsStub(h.hash) {
+			t.Errorf("dlitz claims cta hash: %s", h.hash)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package postgres implements the SCRAM-SHA-256 and legacy md5 password
+// verifiers stored by PostgreSQL for roles.
+//
+// The md5 verifier incorporates the role name, so a scheme for it must be
+// constructed for each role using NewMD5. A Context whose preferred scheme is
+// SCRAMSHA256Crypter and which also contains such a scheme can be used to
+// upgrade md5 roles to SCRAM.
+package postgres
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/postgres/raw"
+	scram "gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// An implementation of Scheme producing PostgreSQL SCRAM-SHA-256 verifiers.
+//
+// Uses RecommendedIterations.
+var SCRAMSHA256Crypter abstract.Scheme
+
+func init() {
+	SCRAMSHA256Crypter = NewSCRAMSHA256(raw.RecommendedIterations)
+}
+
+// Returns an implementation of Scheme producing PostgreSQL SCRAM-SHA-256
+// verifiers with the given iteration count.
+func NewSCRAMSHA256(iterations int) abstract.Scheme {
+	return &scramScheme{
+		iterations: iterations,
+	}
+}
+
+type scramScheme struct {
+	iterations int
+}
+
+func (c *scramScheme) SetParams(iterations int) error {
+	c.iterations = iterations
+	return nil
+}
+
+func (c *scramScheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "SCRAM-SHA-256$")
+}
+
+func (c *scramScheme) Hash(password string) (string, error) {
+	salt := make([]byte, raw.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.SCRAMSHA256(password, salt, c.iterations), nil
+}
+
+func (c *scramScheme) Verify(password, hash string) error {
+	iterations, salt, storedKey, serverKey, err := raw.ParseSCRAMSHA256(hash)
+	if err != nil {
+		return err
+	}
+
+	salted := scram.SaltedPassword(sha256.New, []byte(raw.NormalizePassword(password)), salt, iterations)
+	newStoredKey := scram.StoredKey(sha256.New, scram.ClientKey(sha256.New, salted))
+	newServerKey := scram.ServerKey(sha256.New, salted)
+
+	if !abstract.SecureCompare(string(storedKey)+string(serverKey), string(newStoredKey)+string(newServerKey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scramScheme) NeedsUpdate(stub string) bool {
+	iterations, salt, _, _, err := raw.ParseSCRAMSHA256(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return iterations < c.iterations || len(salt) < raw.SaltLength
+}
+
+func (c *scramScheme) String() string {
+	return fmt.Sprintf("postgres-scram-sha-256(%d)", c.iterations)
+}
+
+// Returns an implementation of Scheme producing legacy PostgreSQL md5
+// verifiers for the role with the given name.
+//
+// md5 verifiers are obsolete, so NeedsUpdate always returns true.
+func NewMD5(username string) abstract.Scheme {
+	return &md5Scheme{
+		username: username,
+	}
+}
+
+type md5Scheme struct {
+	username string
+}
+
+func (c *md5Scheme) SupportsStub(stub string) bool {
+	return raw.IsMD5(stub)
+}
+
+func (c *md5Scheme) Hash(password string) (string, error) {
+	return raw.MD5(password, c.username), nil
+}
+
+func (c *md5Scheme) Verify(password, hash string) error {
+	if !raw.IsMD5(hash) {
+		return raw.ErrInvalidStub
+	}
+
+	if !abstract.SecureCompare(hash, raw.MD5(password, c.username)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *md5Scheme) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (c *md5Scheme) String() string {
+	return fmt.Sprintf("postgres-md5(%q)", c.username)
+}
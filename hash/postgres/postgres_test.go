@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// Generated using Python's hashlib and hmac modules.
+var scramTests = []struct {
+	password string
+	hash     string
+}{
+	{"password", "SCRAM-SHA-256$4096:AAECAwQFBgcICQoLDA0ODw==$4PSH04DiBM59z6mw0gs6x1r6+duXYQ+R0KwGZr+W5/o=:IgPInY95tTazYxnARISZb/eTxuX/JRwWgrM9ByaOUIk="},
+	{"", "SCRAM-SHA-256$4096:c2FsdHNhbHRzYWx0c2FsdA==$DFbydvTWZW1PeppXcjOeOqEk21QqumsyojJxcvbsCI0=:OV9/8tiPK01emdHD+656o85d5V5lm0va9sck8KJFi7s="},
+	// SASLprep is applied to non-ASCII passwords.
+	{"Ⅸ", "SCRAM-SHA-256$4096:c2FsdHNhbHRzYWx0c2FsdA==$reE3rYE9SNaeceisedNRugrCpKnRQC5P2JIOWa16Plc=:NhrtD0Cz0Q1t8UjIC4+JWouKyg1hgiaGWJeXUwxGkh8="},
+	{"I­X", "SCRAM-SHA-256$4096:c2FsdHNhbHRzYWx0c2FsdA==$reE3rYE9SNaeceisedNRugrCpKnRQC5P2JIOWa16Plc=:NhrtD0Cz0Q1t8UjIC4+JWouKyg1hgiaGWJeXUwxGkh8="},
+	{"pass word", "SCRAM-SHA-256$4096:c2FsdHNhbHRzYWx0c2FsdA==$vi0PsosEh4GFOidg72go0yCb3v07psjmsXykTbu3rh0=:PRn3XDk34nxLy4V4tzdf6xGoZ+mHt19o7PV16l/mnCA="},
+}
+
+func TestSCRAMSHA256(t *testing.T) {
+	for _, tst := range scramTests {
+		if !SCRAMSHA256Crypter.SupportsStub(tst.hash) {
+			t.Errorf("stub not supported: %#v", tst.hash)
+		}
+
+		if err := SCRAMSHA256Crypter.Verify(tst.password, tst.hash); err != nil {
+			t.Errorf("err verifying known good hash: %v (%#v)", err, tst.hash)
+		}
+
+		if err := SCRAMSHA256Crypter.Verify(tst.password+"x", tst.hash); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v", err)
+		}
+	}
+
+	h, err := SCRAMSHA256Crypter.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := SCRAMSHA256Crypter.Verify("password", h); err != nil || SCRAMSHA256Crypter.NeedsUpdate(h) {
+		t.Errorf("cannot verify new hash: %v (%#v)", err, h)
+	}
+
+	if !NewSCRAMSHA256(8192).NeedsUpdate(h) {
+		t.Errorf("expected update for higher iteration count")
+	}
+}
+
+func TestMD5(t *testing.T) {
+	c := NewMD5("postgres")
+	h := "md532e12f215ba27cb750c9e093ce4b5127"
+
+	if !c.SupportsStub(h) || !c.NeedsUpdate(h) {
+		t.Errorf("unexpected stub handling")
+	}
+
+	if err := c.Verify("password", h); err != nil {
+		t.Errorf("err verifying known good hash: %v", err)
+	}
+
+	if err := NewMD5("other").Verify("password", h); err != abstract.ErrInvalidPassword {
+		t.Errorf("unexpected result verifying with wrong role: %v", err)
+	}
+
+	if h2, _ := c.Hash("password"); h2 != h {
+		t.Errorf("unexpected hash: %#v", h2)
+	}
+
+	for _, s := range []string{"md5", "md532E12F215BA27CB750C9E093CE4B5127", "SCRAM-SHA-256$4096:c2FsdA==$"} {
+		if c.SupportsStub(s) {
+			t.Errorf("should not support stub: %#v", s)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package raw provides a raw implementation of the password verifier formats
+// stored by PostgreSQL in pg_authid.rolpassword.
+package raw
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	scram "gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// The default SCRAM iteration count used by PostgreSQL.
+const RecommendedIterations = 4096
+
+// The salt length used by PostgreSQL.
+const SaltLength = 16
+
+// Indicates that a password verifier is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid PostgreSQL password verifier")
+
+// Prepares a password in the same way as PostgreSQL. SASLprep is applied, but
+// the password is used as-is if it is ASCII or cannot be prepared.
+func NormalizePassword(password string) string {
+	for i := 0; i < len(password); i++ {
+		if password[i] >= 0x80 {
+			if p, err := scram.SASLprep(password); err == nil && p != "" {
+				return p
+			}
+
+			break
+		}
+	}
+
+	return password
+}
+
+// Calculates a PostgreSQL SCRAM-SHA-256 verifier.
+//
+// password should be a UTF-8 plaintext password.
+// salt should be a random salt value in binary form.
+//
+// Returns a verifier of the form
+//
+//	SCRAM-SHA-256$iterations:salt$StoredKey:ServerKey
+func SCRAMSHA256(password string, salt []byte, iterations int) string {
+	salted := scram.SaltedPassword(sha256.New, []byte(NormalizePassword(password)), salt, iterations)
+	storedKey := scram.StoredKey(sha256.New, scram.ClientKey(sha256.New, salted))
+	serverKey := scram.ServerKey(sha256.New, salted)
+
+	return fmt.Sprintf("SCRAM-SHA-256$%d:%s$%s:%s", iterations,
+		base64.StdEncoding.EncodeToString(salt),
+		base64.StdEncoding.EncodeToString(storedKey),
+		base64.StdEncoding.EncodeToString(serverKey))
+}
+
+// Parses a PostgreSQL SCRAM-SHA-256 verifier.
+//
+// The format is as follows:
+//
+//	SCRAM-SHA-256$iterations:salt$StoredKey:ServerKey
+//
+// The salt and keys are in standard base64.
+func ParseSCRAMSHA256(stub string) (iterations int, salt, storedKey, serverKey []byte, err error) {
+	if !strings.HasPrefix(stub, "SCRAM-SHA-256$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(stub[14:], "$")
+	if len(parts) != 2 {
+		err = ErrInvalidStub
+		return
+	}
+
+	params := strings.Split(parts[0], ":")
+	keys := strings.Split(parts[1], ":")
+	if len(params) != 2 || len(keys) != 2 {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(params[0], 10, 31)
+	if err != nil || n == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	iterations = int(n)
+
+	if salt, err = base64.StdEncoding.DecodeString(params[1]); err != nil {
+		return
+	}
+
+	if storedKey, err = base64.StdEncoding.DecodeString(keys[0]); err != nil {
+		return
+	}
+
+	if serverKey, err = base64.StdEncoding.DecodeString(keys[1]); err != nil {
+		return
+	}
+
+	if len(storedKey) != sha256.Size || len(serverKey) != sha256.Size {
+		err = ErrInvalidStub
+		return
+	}
+
+	return
+}
+
+// Calculates a legacy PostgreSQL md5 verifier, "md5" followed by the hex
+// encoding of MD5(password || username).
+func MD5(password, username string) string {
+	h := md5.Sum([]byte(password + username))
+	return "md5" + hex.EncodeToString(h[:])
+}
+
+// Returns true if the string has the form of an md5 verifier.
+func IsMD5(stub string) bool {
+	if len(stub) != 35 || !strings.HasPrefix(stub, "md5") {
+		return false
+	}
+
+	for _, c := range stub[3:] {
+		if !(c >= '0' && c <= '9') && !(c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+
+	return true
+}
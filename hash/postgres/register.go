@@ -0,0 +1,23 @@
+package postgres
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/postgres/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+// The md5 scheme is per-role, so only SCRAM-SHA-256 is registered.
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "postgres-scram-sha256",
+		Doc:  "PostgreSQL SCRAM-SHA-256 verifiers",
+		Params: []registry.Param{
+			{Name: "iterations", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedIterations), Min: 1, Max: 1<<31 - 1, Doc: "number of PBKDF2 iterations"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewSCRAMSHA256(args.Int("iterations")), nil
+		},
+	})
+}
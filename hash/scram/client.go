@@ -0,0 +1,198 @@
+package scram
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// Indicates that the server's signature did not verify, i.e. the server does
+// not know the user's credentials.
+var ErrInvalidServerSignature = fmt.Errorf("invalid SCRAM server signature")
+
+// The client side of a SCRAM authentication exchange. It is provided mainly
+// for testing servers.
+//
+// Step is first called with a nil message to obtain the client-first-message,
+// and subsequently with each server message.
+type Client struct {
+	mech mechanism
+
+	username, password, authzID string
+
+	cbType string
+	cbData []byte
+
+	state int
+
+	gs2Header       string
+	clientFirstBare string
+	nonce           string
+	serverSignature []byte
+}
+
+const (
+	clientStateInitial = iota
+	clientStateServerFirst
+	clientStateServerFinal
+	clientStateDone
+	clientStateFailed
+)
+
+// Creates a client for the given mechanism, e.g. "SCRAM-SHA-256".
+func NewClient(mechanismName, username, password string) (*Client, error) {
+	m, err := parseMechanism(mechanismName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		mech:     m,
+		username: username,
+		password: password,
+	}, nil
+}
+
+// Sets the authorization identity to send.
+func (c *Client) SetAuthzID(authzID string) {
+	c.authzID = authzID
+}
+
+// Indicates that the client supports channel binding of the given type, and
+// provides the channel binding data. Channel binding is used if the
+// mechanism is a "-PLUS" mechanism; otherwise, the client indicates to the
+// server that it supports channel binding but believes the server does not.
+func (c *Client) SetChannelBinding(cbType string, data []byte) {
+	c.cbType = cbType
+	c.cbData = data
+}
+
+// Returns true if the server has been successfully authenticated.
+func (c *Client) Done() bool {
+	return c.state == clientStateDone
+}
+
+// Processes a server message and returns the response.
+func (c *Client) Step(in []byte) (out []byte, err error) {
+	switch c.state {
+	case clientStateInitial:
+		out, err = c.clientFirst()
+	case clientStateServerFirst:
+		out, err = c.clientFinal(string(in))
+	case clientStateServerFinal:
+		err = c.serverFinal(string(in))
+	default:
+		return nil, ErrDone
+	}
+
+	if err != nil {
+		c.state = clientStateFailed
+	} else {
+		c.state++
+	}
+
+	return
+}
+
+func (c *Client) clientFirst() ([]byte, error) {
+	switch {
+	case c.mech.plus:
+		if c.cbType == "" {
+			return nil, ErrChannelBindingNotSupported
+		}
+
+		c.gs2Header = "p=" + c.cbType
+	case c.cbType != "":
+		c.gs2Header = "y"
+	default:
+		c.gs2Header = "n"
+	}
+
+	c.gs2Header += ","
+	if c.authzID != "" {
+		c.gs2Header += "a=" + encodeName(c.authzID)
+	}
+
+	c.gs2Header += ","
+
+	if c.nonce == "" {
+		nonce, err := makeNonce()
+		if err != nil {
+			return nil, err
+		}
+
+		c.nonce = nonce
+	}
+
+	c.clientFirstBare = "n=" + encodeName(c.username) + ",r=" + c.nonce
+	return []byte(c.gs2Header + c.clientFirstBare), nil
+}
+
+func (c *Client) clientFinal(serverFirst string) ([]byte, error) {
+	attrs, err := parseAttributes(serverFirst, 'r', 's', 'i')
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(attrs[0], c.nonce) || len(attrs[0]) == len(c.nonce) {
+		return nil, ErrInvalidMessage
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(attrs[1])
+	if err != nil {
+		return nil, ErrInvalidEncoding
+	}
+
+	iterations, err := strconv.ParseUint(attrs[2], 10, 31)
+	if err != nil || iterations == 0 {
+		return nil, ErrInvalidMessage
+	}
+
+	password, err := raw.SASLprep(c.password)
+	if err != nil {
+		return nil, err
+	}
+
+	cb := c.gs2Header
+	if c.mech.plus {
+		cb += string(c.cbData)
+	}
+
+	withoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(cb)) + ",r=" + attrs[0]
+	authMessage := []byte(c.clientFirstBare + "," + serverFirst + "," + withoutProof)
+
+	hf := c.mech.hf
+	salted := raw.SaltedPassword(hf, []byte(password), salt, int(iterations))
+	clientKey := raw.ClientKey(hf, salted)
+	clientSignature := raw.HMAC(hf, raw.StoredKey(hf, clientKey), authMessage)
+	c.serverSignature = raw.HMAC(hf, raw.ServerKey(hf, salted), authMessage)
+
+	proof := xorBytes(clientKey, clientSignature)
+	return []byte(withoutProof + ",p=" + base64.StdEncoding.EncodeToString(proof)), nil
+}
+
+func (c *Client) serverFinal(msg string) error {
+	if strings.HasPrefix(msg, "e=") {
+		return ServerError(msg[2:])
+	}
+
+	attrs, err := parseAttributes(msg, 'v')
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(attrs[0])
+	if err != nil {
+		return ErrInvalidEncoding
+	}
+
+	if !abstract.SecureCompare(string(sig), string(c.serverSignature)) {
+		return ErrInvalidServerSignature
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+package scram
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	postgres "gopkg.in/hlandau/passlib.v1/hash/postgres/raw"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// SCRAM mechanism names. Each may be suffixed with "-PLUS" to indicate the
+// channel binding variant.
+const (
+	SHA1   = "SCRAM-SHA-1"
+	SHA256 = "SCRAM-SHA-256"
+	SHA512 = "SCRAM-SHA-512"
+)
+
+// Indicates that a mechanism name is not supported.
+var ErrUnsupportedMechanism = fmt.Errorf("unsupported SCRAM mechanism")
+
+// Indicates that a SCRAM message is malformed or unexpected.
+var ErrInvalidMessage = fmt.Errorf("invalid SCRAM message")
+
+// Indicates that Step was called after the exchange has finished.
+var ErrDone = fmt.Errorf("SCRAM exchange already finished")
+
+// An error sent by the server in a server-final-message, as defined in RFC
+// 5802 section 7.
+type ServerError string
+
+// Server errors.
+const (
+	ErrInvalidEncoding                 ServerError = "invalid-encoding"
+	ErrExtensionsNotSupported          ServerError = "extensions-not-supported"
+	ErrInvalidProof                    ServerError = "invalid-proof"
+	ErrChannelBindingsDontMatch        ServerError = "channel-bindings-dont-match"
+	ErrServerDoesSupportChannelBinding ServerError = "server-does-support-channel-binding"
+	ErrChannelBindingNotSupported      ServerError = "channel-binding-not-supported"
+	ErrUnsupportedChannelBindingType   ServerError = "unsupported-channel-binding-type"
+	ErrUnknownUser                     ServerError = "unknown-user"
+	ErrOtherError                      ServerError = "other-error"
+)
+
+func (e ServerError) Error() string {
+	return "SCRAM server error: " + string(e)
+}
+
+type mechanism struct {
+	alg  string
+	hf   func() hash.Hash
+	plus bool
+}
+
+func parseMechanism(name string) (m mechanism, err error) {
+	if strings.HasSuffix(name, "-PLUS") {
+		m.plus = true
+		name = name[0 : len(name)-5]
+	}
+
+	if !strings.HasPrefix(name, "SCRAM-") {
+		err = ErrUnsupportedMechanism
+		return
+	}
+
+	m.alg = strings.ToLower(name[6:])
+
+	var ok bool
+	if m.hf, ok = raw.HashFunc(m.alg); !ok {
+		err = ErrUnsupportedMechanism
+	}
+
+	return
+}
+
+// The credentials the server needs to authenticate a user.
+type Credentials struct {
+	Salt       []byte
+	Iterations int
+	StoredKey  []byte
+	ServerKey  []byte
+}
+
+// Derives the credentials for the given mechanism from a stored hash. $scram$
+// hashes with a digest for the mechanism's hash function and PostgreSQL
+// SCRAM-SHA-256 verifiers are supported.
+func CredentialsFromHash(mechanismName, hash string) (*Credentials, error) {
+	m, err := parseMechanism(mechanismName)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(hash, "SCRAM-SHA-256$") && m.alg == "sha-256" {
+		iterations, salt, storedKey, serverKey, err := postgres.ParseSCRAMSHA256(hash)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Credentials{salt, iterations, storedKey, serverKey}, nil
+	}
+
+	rounds, salt, digests, err := raw.Parse(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sp := raw.FindDigest(digests, m.alg)
+	if sp == nil {
+		return nil, ErrUnsupportedMechanism
+	}
+
+	return &Credentials{
+		Salt:       salt,
+		Iterations: rounds,
+		StoredKey:  raw.StoredKey(m.hf, raw.ClientKey(m.hf, sp)),
+		ServerKey:  raw.ServerKey(m.hf, sp),
+	}, nil
+}
+
+func makeNonce() (string, error) {
+	buf := make([]byte, 18)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// Escapes a username or authorization identity as a saslname.
+func encodeName(s string) string {
+	return strings.NewReplacer("=", "=3D", ",", "=2C").Replace(s)
+}
+
+func decodeName(s string) (string, error) {
+	out := ""
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '=')
+		if i < 0 {
+			return out + s, nil
+		}
+
+		out += s[0:i]
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "=2C"):
+			out += ","
+		case strings.HasPrefix(s, "=3D"):
+			out += "="
+		default:
+			return "", ErrInvalidMessage
+		}
+
+		s = s[3:]
+	}
+
+	return out, nil
+}
+
+// Splits a message into attributes, checking that the first attributes are
+// those named.
+func parseAttributes(msg string, names ...byte) ([]string, error) {
+	attrs := strings.Split(msg, ",")
+	if len(attrs) < len(names) {
+		return nil, ErrInvalidMessage
+	}
+
+	for i, attr := range attrs {
+		if len(attr) < 2 || attr[1] != '=' {
+			return nil, ErrInvalidMessage
+		}
+
+		if i < len(names) && attr[0] != names[i] {
+			return nil, ErrInvalidMessage
+		}
+
+		attrs[i] = attr[2:]
+	}
+
+	return attrs, nil
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+
+	return out
+}
@@ -0,0 +1,153 @@
+package raw
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	pbkdf2 "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// The recommended number of rounds. This is Python passlib's default.
+const RecommendedRounds = 100000
+
+// The salt length used by Python passlib.
+const SaltLength = 12
+
+// Indicates that a password hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid scram password stub")
+
+var hashFuncs = map[string]func() hash.Hash{
+	"sha-1":   sha1.New,
+	"sha-224": sha256.New224,
+	"sha-256": sha256.New,
+	"sha-384": sha512.New384,
+	"sha-512": sha512.New,
+}
+
+// Returns the hash function for an algorithm name as used in $scram$ hashes
+// and SCRAM mechanism names, e.g. "sha-256".
+func HashFunc(alg string) (func() hash.Hash, bool) {
+	hf, ok := hashFuncs[alg]
+	return hf, ok
+}
+
+// A SaltedPassword digest for a single hash algorithm.
+type Digest struct {
+	Alg   string
+	Value []byte
+}
+
+// Calculates a hash in the $scram$ format used by Python passlib, which stores
+// the SCRAM SaltedPassword for each of the given algorithms.
+//
+// password should be a UTF-8 plaintext password. SASLprep is applied to it.
+// salt should be a random salt value in binary form.
+//
+// Returns a modular crypt hash.
+func Crypt(password string, salt []byte, rounds int, algs []string) (string, error) {
+	if rounds < 1 || len(algs) == 0 {
+		return "", ErrInvalidStub
+	}
+
+	p, err := SASLprep(password)
+	if err != nil {
+		return "", err
+	}
+
+	s := "$scram$" + strconv.Itoa(rounds) + "$" + pbkdf2.Base64Encode(salt) + "$"
+	for i, alg := range algs {
+		hf, ok := HashFunc(alg)
+		if !ok {
+			return "", ErrInvalidStub
+		}
+
+		if i > 0 {
+			s += ","
+		}
+
+		s += alg + "=" + pbkdf2.Base64Encode(SaltedPassword(hf, []byte(p), salt, rounds))
+	}
+
+	return s, nil
+}
+
+// Parses a $scram$ modular hash or stub string.
+//
+// The format is as follows:
+//
+//	$scram$rounds$salt$alg=digest,...   // hash
+//	$scram$rounds$salt                  // stub
+//
+// The salt and digests use the ab64 encoding.
+func Parse(stub string) (rounds int, salt []byte, digests []Digest, err error) {
+	if !strings.HasPrefix(stub, "$scram$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(stub[7:], "$")
+	if len(parts) < 2 || len(parts) > 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(parts[0], 10, 31)
+	if err != nil || n == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	rounds = int(n)
+
+	if salt, err = pbkdf2.Base64Decode(parts[1]); err != nil {
+		return
+	}
+
+	if len(parts) < 3 {
+		return
+	}
+
+	for _, d := range strings.Split(parts[2], ",") {
+		kv := strings.SplitN(d, "=", 2)
+		if len(kv) != 2 {
+			err = ErrInvalidStub
+			return
+		}
+
+		hf, ok := HashFunc(kv[0])
+		if !ok || FindDigest(digests, kv[0]) != nil {
+			err = ErrInvalidStub
+			return
+		}
+
+		var v []byte
+		if v, err = pbkdf2.Base64Decode(kv[1]); err != nil {
+			return
+		}
+
+		if len(v) != hf().Size() {
+			err = ErrInvalidStub
+			return
+		}
+
+		digests = append(digests, Digest{Alg: kv[0], Value: v})
+	}
+
+	return
+}
+
+// Returns the digest for the given algorithm, or nil.
+func FindDigest(digests []Digest, alg string) []byte {
+	for _, d := range digests {
+		if d.Alg == alg {
+			return d.Value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,129 @@
+package raw
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/bidi"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Indicates that a string cannot be prepared with SASLprep because it is not
+// valid UTF-8 or contains prohibited characters.
+var ErrProhibited = fmt.Errorf("string is prohibited by SASLprep")
+
+type runeRange struct{ lo, hi rune }
+
+func inRanges(r rune, ranges []runeRange) bool {
+	for _, rr := range ranges {
+		if r >= rr.lo && r <= rr.hi {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RFC 3454 table C.1.2, non-ASCII space characters. These are mapped to
+// U+0020.
+var nonASCIISpace = []runeRange{
+	{0x00A0, 0x00A0}, {0x1680, 0x1680}, {0x2000, 0x200B}, {0x202F, 0x202F},
+	{0x205F, 0x205F}, {0x3000, 0x3000},
+}
+
+// RFC 3454 table B.1, characters commonly mapped to nothing.
+var mappedToNothing = []runeRange{
+	{0x00AD, 0x00AD}, {0x034F, 0x034F}, {0x1806, 0x1806}, {0x180B, 0x180D},
+	{0x200B, 0x200D}, {0x2060, 0x2060}, {0xFE00, 0xFE0F}, {0xFEFF, 0xFEFF},
+}
+
+// RFC 3454 tables C.2.1 to C.9, as required by RFC 4013 section 2.3. Table
+// C.1.2 is handled by mapping.
+var prohibited = []runeRange{
+	// C.2.1, C.2.2: control characters.
+	{0x0000, 0x001F}, {0x007F, 0x009F}, {0x06DD, 0x06DD}, {0x070F, 0x070F},
+	{0x180E, 0x180E}, {0x200C, 0x200D}, {0x2028, 0x2029}, {0x2060, 0x2063},
+	{0x206A, 0x206F}, {0xFEFF, 0xFEFF}, {0xFFF9, 0xFFFC}, {0x1D173, 0x1D17A},
+	// C.3: private use.
+	{0xE000, 0xF8FF}, {0xF0000, 0xFFFFD}, {0x100000, 0x10FFFD},
+	// C.4: non-character code points.
+	{0xFDD0, 0xFDEF}, {0xFFFE, 0xFFFF}, {0x1FFFE, 0x1FFFF}, {0x2FFFE, 0x2FFFF},
+	{0x3FFFE, 0x3FFFF}, {0x4FFFE, 0x4FFFF}, {0x5FFFE, 0x5FFFF}, {0x6FFFE, 0x6FFFF},
+	{0x7FFFE, 0x7FFFF}, {0x8FFFE, 0x8FFFF}, {0x9FFFE, 0x9FFFF}, {0xAFFFE, 0xAFFFF},
+	{0xBFFFE, 0xBFFFF}, {0xCFFFE, 0xCFFFF}, {0xDFFFE, 0xDFFFF}, {0xEFFFE, 0xEFFFF},
+	{0xFFFFE, 0xFFFFF}, {0x10FFFE, 0x10FFFF},
+	// C.5: surrogate codes.
+	{0xD800, 0xDFFF},
+	// C.6: inappropriate for plain text.
+	{0xFFF9, 0xFFFD},
+	// C.7: inappropriate for canonical representation.
+	{0x2FF0, 0x2FFB},
+	// C.8: change display properties or are deprecated.
+	{0x0340, 0x0341}, {0x200E, 0x200F}, {0x202A, 0x202E}, {0x206A, 0x206F},
+	// C.9: tagging characters.
+	{0xE0001, 0xE0001}, {0xE0020, 0xE007F},
+}
+
+// Prepares a string using the SASLprep profile of stringprep (RFC 4013), as
+// used for passwords by SCRAM.
+//
+// Unassigned code points are prohibited, as for stored strings. Whether a code
+// point is assigned, and its bidirectional category, are determined using the
+// Unicode tables available to Go rather than the Unicode 3.2 tables referenced
+// by RFC 3454.
+func SASLprep(s string) (string, error) {
+	if !utf8.ValidString(s) {
+		return "", ErrProhibited
+	}
+
+	// Map.
+	mapped := make([]rune, 0, len(s))
+	for _, r := range s {
+		switch {
+		case inRanges(r, nonASCIISpace):
+			mapped = append(mapped, ' ')
+		case inRanges(r, mappedToNothing):
+		default:
+			mapped = append(mapped, r)
+		}
+	}
+
+	// Normalize.
+	out := norm.NFKC.String(string(mapped))
+
+	// Prohibit, and check bidi.
+	hasRandAL, hasL := false, false
+	for _, r := range out {
+		if inRanges(r, nonASCIISpace) || inRanges(r, prohibited) || !isAssigned(r) {
+			return "", ErrProhibited
+		}
+
+		p, _ := bidi.LookupRune(r)
+		switch p.Class() {
+		case bidi.R, bidi.AL:
+			hasRandAL = true
+		case bidi.L:
+			hasL = true
+		}
+	}
+
+	if hasRandAL {
+		first, _ := utf8.DecodeRuneInString(out)
+		last, _ := utf8.DecodeLastRuneInString(out)
+		if hasL || !isRandAL(first) || !isRandAL(last) {
+			return "", ErrProhibited
+		}
+	}
+
+	return out, nil
+}
+
+func isRandAL(r rune) bool {
+	p, _ := bidi.LookupRune(r)
+	return p.Class() == bidi.R || p.Class() == bidi.AL
+}
+
+func isAssigned(r rune) bool {
+	return unicode.In(r, unicode.L, unicode.M, unicode.N, unicode.P, unicode.S, unicode.Z, unicode.C)
+}
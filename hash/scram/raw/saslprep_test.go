@@ -0,0 +1,27 @@
+package raw
+
+import "testing"
+
+// From RFC 4013 section 3.
+func TestSASLprep(t *testing.T) {
+	for _, tst := range []struct{ in, out string }{
+		{"I­X", "IX"},
+		{"user", "user"},
+		{"USER", "USER"},
+		{"ª", "a"},
+		{"Ⅸ", "IX"},
+		{"a b", "a b"},
+		{"ا1ب", "ا1ب"},
+	} {
+		out, err := SASLprep(tst.in)
+		if err != nil || out != tst.out {
+			t.Errorf("SASLprep(%+q) = %+q, %v; expected %+q", tst.in, out, err, tst.out)
+		}
+	}
+
+	for _, in := range []string{"\u0007", "ا1", "aا", "\xff", "", "\U000E0001"} {
+		if out, err := SASLprep(in); err == nil {
+			t.Errorf("SASLprep(%+q) = %+q; expected error", in, out)
+		}
+	}
+}
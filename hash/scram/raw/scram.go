@@ -0,0 +1,41 @@
+// Package raw provides the key derivation primitives of the SCRAM
+// authentication mechanism (RFC 5802), along with SASLprep (RFC 4013).
+package raw
+
+import (
+	"crypto/hmac"
+	"hash"
+
+	"gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// Calculates SaltedPassword := Hi(Normalize(password), salt, i).
+//
+// password should already have been normalized, if required; see SASLprep.
+func SaltedPassword(hf func() hash.Hash, password, salt []byte, iterations int) []byte {
+	return raw.Key(password, salt, iterations, hf().Size(), hf)
+}
+
+// Calculates ClientKey := HMAC(SaltedPassword, "Client Key").
+func ClientKey(hf func() hash.Hash, saltedPassword []byte) []byte {
+	return HMAC(hf, saltedPassword, []byte("Client Key"))
+}
+
+// Calculates StoredKey := H(ClientKey).
+func StoredKey(hf func() hash.Hash, clientKey []byte) []byte {
+	h := hf()
+	h.Write(clientKey)
+	return h.Sum(nil)
+}
+
+// Calculates ServerKey := HMAC(SaltedPassword, "Server Key").
+func ServerKey(hf func() hash.Hash, saltedPassword []byte) []byte {
+	return HMAC(hf, saltedPassword, []byte("Server Key"))
+}
+
+// Calculates HMAC(key, msg) using the given hash function.
+func HMAC(hf func() hash.Hash, key, msg []byte) []byte {
+	h := hmac.New(hf, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
@@ -0,0 +1,31 @@
+package scram
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "scram",
+		Doc:  "Python passlib's $scram$",
+		Params: []registry.Param{
+			{Name: "rounds", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedRounds), Min: 1, Max: 1<<31 - 1, Doc: "number of PBKDF2 iterations"},
+			{Name: "algs", Type: registry.String, Default: strings.Join(DefaultAlgs, ","), Doc: "comma-separated digest algorithms"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			algs := strings.Split(args.String("algs"), ",")
+			for _, alg := range algs {
+				if _, ok := raw.HashFunc(alg); !ok {
+					return nil, registry.ErrInvalidParam
+				}
+			}
+
+			return New(args.Int("rounds"), algs...), nil
+		},
+	})
+}
@@ -0,0 +1,113 @@
+// Package scram implements Python passlib's $scram$ password hashing scheme,
+// which stores SCRAM SaltedPassword values for one or more hash functions,
+// together with a SCRAM (RFC 5802, RFC 7677) server which authenticates
+// clients against such stored hashes without receiving the plaintext
+// password.
+package scram
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// An implementation of Scheme producing $scram$ hashes with digests for
+// SHA-1, SHA-256 and SHA-512, which supports the SCRAM-SHA-1, SCRAM-SHA-256
+// and SCRAM-SHA-512 mechanisms.
+//
+// Uses RecommendedRounds.
+var Crypter abstract.Scheme
+
+// The algorithms for which digests are stored by Crypter.
+var DefaultAlgs = []string{"sha-1", "sha-256", "sha-512"}
+
+func init() {
+	Crypter = New(raw.RecommendedRounds, DefaultAlgs...)
+}
+
+// Returns an implementation of Scheme producing $scram$ hashes with the given
+// number of rounds and a digest for each of the given algorithms, e.g.
+// "sha-256".
+func New(rounds int, algs ...string) abstract.Scheme {
+	return &scheme{
+		rounds: rounds,
+		algs:   algs,
+	}
+}
+
+type scheme struct {
+	rounds int
+	algs   []string
+}
+
+func (c *scheme) SetParams(rounds int, algs ...string) error {
+	c.rounds = rounds
+	c.algs = algs
+	return nil
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$scram$")
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	salt := make([]byte, raw.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Crypt(password, salt, c.rounds, c.algs)
+}
+
+// All digests in the hash are checked.
+func (c *scheme) Verify(password, hash string) error {
+	rounds, salt, digests, err := raw.Parse(hash)
+	if err != nil {
+		return err
+	}
+
+	if len(digests) == 0 {
+		return raw.ErrInvalidStub
+	}
+
+	p, err := raw.SASLprep(password)
+	if err != nil {
+		return abstract.ErrInvalidPassword
+	}
+
+	ok := true
+	for _, d := range digests {
+		hf, _ := raw.HashFunc(d.Alg)
+		sp := raw.SaltedPassword(hf, []byte(p), salt, rounds)
+		ok = abstract.SecureCompare(string(sp), string(d.Value)) && ok
+	}
+
+	if !ok {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	rounds, salt, digests, err := raw.Parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	for _, alg := range c.algs {
+		if raw.FindDigest(digests, alg) == nil {
+			return true
+		}
+	}
+
+	return rounds < c.rounds || len(salt) < raw.SaltLength
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("scram(%d,%s)", c.rounds, strings.Join(c.algs, ","))
+}
@@ -0,0 +1,290 @@
+package scram
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// From the passlib documentation; the SHA-256 and SHA-512 digests were
+// generated using Python's hashlib.
+const passlibHash = "$scram$6400$.Z/znnNOKWUsBaCU$sha-1=cRseQyJpnuPGn3e6d6u6JdJWk.0,sha-256=5GcjEbRaUIIci1r6NAMdI9OPZbxl9S5CFR6la9CHXYc,sha-512=.DHbIm82ajXbFR196Y.9TtbsgzvGjbMeuWCtKve8TPjRMNoZK9EGyHQ6y0lW9OtWdHZrDZbBUhB9ou./VI2mlw"
+
+func TestScheme(t *testing.T) {
+	if err := Crypter.Verify("password", passlibHash); err != nil {
+		t.Errorf("err verifying known good hash: %v", err)
+	}
+
+	if err := Crypter.Verify("passwore", passlibHash); err != abstract.ErrInvalidPassword {
+		t.Errorf("unexpected result verifying wrong password: %v", err)
+	}
+
+	if !Crypter.NeedsUpdate(passlibHash) {
+		t.Errorf("expected update for low rounds")
+	}
+
+	c := New(10, "sha-1", "sha-256")
+	h, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Verify("password", h); err != nil || c.NeedsUpdate(h) {
+		t.Errorf("cannot verify new hash: %v (%#v)", err, h)
+	}
+
+	if !New(10, DefaultAlgs...).NeedsUpdate(h) {
+		t.Errorf("expected update for missing algorithm")
+	}
+}
+
+type exchange struct {
+	mechanism, salt, clientNonce, serverNonce string
+	messages                                  []string
+}
+
+// From RFC 5802 section 5 and RFC 7677 section 3.
+var exchanges = []exchange{
+	{SHA1, "QSXCR+Q6sek8bf92", "fyko+d2lbbFgONRv9qkxdawL", "3rfcNHYJY1ZVvWVs7j", []string{
+		"n,,n=user,r=fyko+d2lbbFgONRv9qkxdawL",
+		"r=fyko+d2lbbFgONRv9qkxdawL3rfcNHYJY1ZVvWVs7j,s=QSXCR+Q6sek8bf92,i=4096",
+		"c=biws,r=fyko+d2lbbFgONRv9qkxdawL3rfcNHYJY1ZVvWVs7j,p=v0X8v3Bz2T0CJGbJQyF0X+HI4Ts=",
+		"v=rmF9pqV8S7suAoZWja4dJRkFsKQ=",
+	}},
+	{SHA256, "W22ZaJ0SNY7soEsUEjb6gQ==", "rOprNGfwEbeRWgbNEkqO", "%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0", []string{
+		"n,,n=user,r=rOprNGfwEbeRWgbNEkqO",
+		"r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096",
+		"c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ=",
+		"v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4=",
+	}},
+}
+
+func lookupFor(mechanism, hash string) LookupFunc {
+	return func(username string) (*Credentials, error) {
+		switch username {
+		case "user":
+		case "error":
+			return nil, fmt.Errorf("lookup failed")
+		default:
+			return nil, nil
+		}
+
+		return CredentialsFromHash(mechanism, hash)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	for _, ex := range exchanges {
+		salt, _ := base64.StdEncoding.DecodeString(ex.salt)
+		h, err := raw.Crypt("pencil", salt, 4096, DefaultAlgs)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		server, err := NewServer(ex.mechanism, lookupFor(ex.mechanism, h))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		server.nonce = ex.clientNonce + ex.serverNonce
+
+		client, err := NewClient(ex.mechanism, "user", "pencil")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		client.nonce = ex.clientNonce
+
+		var msg []byte
+		for i, expected := range ex.messages {
+			if i%2 == 0 {
+				msg, err = client.Step(msg)
+			} else {
+				msg, err = server.Step(msg)
+			}
+
+			if err != nil || string(msg) != expected {
+				t.Fatalf("%s: message %d: got %q (%v), expected %q", ex.mechanism, i, msg, err, expected)
+			}
+		}
+
+		if _, err := client.Step(msg); err != nil || !client.Done() || !server.Done() {
+			t.Fatalf("%s: exchange did not complete: %v", ex.mechanism, err)
+		}
+
+		if server.Username() != "user" {
+			t.Errorf("unexpected username: %q", server.Username())
+		}
+	}
+}
+
+// Runs an exchange between a client and server, returning the first error.
+func run(client *Client, server *Server) error {
+	var msg []byte
+	var err error
+	for !client.Done() {
+		if msg, err = client.Step(msg); err != nil || client.Done() {
+			return err
+		}
+
+		if msg, err = server.Step(msg); err != nil {
+			// Let the client see the server-final error message.
+			if msg != nil {
+				client.Step(msg)
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func TestExchangeFailures(t *testing.T) {
+	h, err := New(16, DefaultAlgs...).Hash("pencil")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	newPair := func(serverMech, clientMech, password string) (*Client, *Server) {
+		server, err := NewServer(serverMech, lookupFor(serverMech, h))
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		client, err := NewClient(clientMech, "user", password)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		return client, server
+	}
+
+	for _, mech := range []string{SHA1, SHA256, SHA512} {
+		client, server := newPair(mech, mech, "pencil")
+		client.SetAuthzID("admin,=x")
+		if err := run(client, server); err != nil || server.AuthzID() != "admin,=x" {
+			t.Errorf("%s: exchange failed: %v", mech, err)
+		}
+
+		client, server = newPair(mech, mech, "pencils")
+		if err := run(client, server); err != ErrInvalidProof || server.Done() {
+			t.Errorf("%s: expected invalid proof: %v", mech, err)
+		}
+	}
+
+	// Channel binding.
+	client, server := newPair(SHA256+"-PLUS", SHA256+"-PLUS", "pencil")
+	client.SetChannelBinding("tls-unique", []byte("abc"))
+	server.SetChannelBinding("tls-unique", []byte("abc"))
+	if err := run(client, server); err != nil {
+		t.Errorf("exchange with channel binding failed: %v", err)
+	}
+
+	client, server = newPair(SHA256+"-PLUS", SHA256+"-PLUS", "pencil")
+	client.SetChannelBinding("tls-unique", []byte("abc"))
+	server.SetChannelBinding("tls-unique", []byte("abd"))
+	if err := run(client, server); err != ErrChannelBindingsDontMatch {
+		t.Errorf("expected channel binding mismatch: %v", err)
+	}
+
+	client, server = newPair(SHA256+"-PLUS", SHA256+"-PLUS", "pencil")
+	client.SetChannelBinding("tls-exporter", nil)
+	server.SetChannelBinding("tls-unique", nil)
+	if err := run(client, server); err != ErrUnsupportedChannelBindingType {
+		t.Errorf("expected unsupported channel binding type: %v", err)
+	}
+
+	// A client which supports channel binding but does not use it, talking to
+	// a server which offers it, indicates a downgrade attack.
+	client, server = newPair(SHA256, SHA256, "pencil")
+	client.SetChannelBinding("tls-unique", nil)
+	server.SetChannelBinding("tls-unique", nil)
+	if err := run(client, server); err != ErrServerDoesSupportChannelBinding {
+		t.Errorf("expected downgrade to be detected: %v", err)
+	}
+
+	// The server is not permitted to skip channel binding for -PLUS.
+	client, server = newPair(SHA256+"-PLUS", SHA256, "pencil")
+	server.SetChannelBinding("tls-unique", nil)
+	if err := run(client, server); err != ErrChannelBindingNotSupported {
+		t.Errorf("expected channel binding to be required: %v", err)
+	}
+
+	if _, err := server.Step(nil); err != ErrDone {
+		t.Errorf("expected ErrDone: %v", err)
+	}
+
+	// Unknown users get consistent fake credentials, and fail only at
+	// client-final, as for a wrong password.
+	var serverFirst []byte
+	for i := 0; i < 2; i++ {
+		server, _ = NewServer(SHA256, lookupFor(SHA256, h))
+		server.SetUnknownUserCredentials([]byte("secret"), 4096)
+		msg, err := server.Step([]byte("n,,n=other,r=abc"))
+		if err != nil {
+			t.Fatalf("unknown user aborted exchange: %v", err)
+		}
+
+		if i > 0 && string(msg[strings.Index(string(msg), ",s="):]) != string(serverFirst[strings.Index(string(serverFirst), ",s="):]) {
+			t.Errorf("inconsistent fake credentials: %q, %q", serverFirst, msg)
+		}
+
+		serverFirst = msg
+	}
+
+	if !strings.HasSuffix(string(serverFirst), ",i=4096") {
+		t.Errorf("unexpected server-first-message: %q", serverFirst)
+	}
+
+	for _, username := range []string{"other", "other2"} {
+		for _, setSecret := range []bool{false, true} {
+			server, _ = NewServer(SHA256, lookupFor(SHA256, h))
+			if setSecret {
+				server.SetUnknownUserCredentials([]byte("secret"), 4096)
+			}
+
+			client, _ = NewClient(SHA256, username, "pencil")
+			if err := run(client, server); err != ErrInvalidProof || server.Done() || client.Done() {
+				t.Errorf("%s: expected invalid proof at client-final: %v", username, err)
+			}
+		}
+	}
+
+	server, _ = NewServer(SHA256, lookupFor(SHA256, h))
+	if _, err := server.Step([]byte("n,,n=error,r=abc")); err == nil {
+		t.Errorf("expected lookup failure")
+	}
+
+	// Malformed messages.
+	for _, msg := range []string{"", "n,,", "x,,n=user,r=abc", "n,,m=ext,n=user,r=abc", "n,,n=us=er,r=abc", "n,,n=user"} {
+		server, _ = NewServer(SHA256, lookupFor(SHA256, h))
+		if _, err := server.Step([]byte(msg)); err == nil {
+			t.Errorf("expected error for %q", msg)
+		}
+	}
+
+	// A server which does not know the ServerKey.
+	server, _ = NewServer(SHA256, func(username string) (*Credentials, error) {
+		creds, err := CredentialsFromHash(SHA256, h)
+		creds.ServerKey = make([]byte, len(creds.ServerKey))
+		return creds, err
+	})
+	client, _ = NewClient(SHA256, "user", "pencil")
+	if err := run(client, server); err != ErrInvalidServerSignature || !server.Done() {
+		t.Errorf("expected invalid server signature: %v", err)
+	}
+
+	// PostgreSQL verifiers.
+	if _, err := CredentialsFromHash(SHA256, "SCRAM-SHA-256$4096:c2FsdHNhbHRzYWx0c2FsdA==$DFbydvTWZW1PeppXcjOeOqEk21QqumsyojJxcvbsCI0=:OV9/8tiPK01emdHD+656o85d5V5lm0va9sck8KJFi7s="); err != nil {
+		t.Errorf("cannot use PostgreSQL verifier: %v", err)
+	}
+
+	if _, err := CredentialsFromHash("SCRAM-MD4", h); err != ErrUnsupportedMechanism {
+		t.Errorf("expected unsupported mechanism: %v", err)
+	}
+}
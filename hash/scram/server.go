@@ -0,0 +1,315 @@
+package scram
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scram/raw"
+)
+
+// Looks up the credentials for a user.
+//
+// If the user does not exist, it should return nil credentials and either a
+// nil error or ErrUnknownUser. As recommended by RFC 5802, the exchange then
+// continues with fake credentials and fails in the final step with
+// ErrInvalidProof, exactly as for a wrong password, so that clients cannot
+// determine whether a user exists. Any other error aborts the exchange.
+type LookupFunc func(username string) (*Credentials, error)
+
+// The server side of a SCRAM authentication exchange.
+//
+// Each incoming client message is passed to Step, which returns the message
+// to send to the client. The exchange has succeeded once Step has returned a
+// nil error and Done returns true.
+type Server struct {
+	mech   mechanism
+	lookup LookupFunc
+
+	cbType string
+	cbData []byte
+
+	unknownSecret     []byte
+	unknownIterations int
+
+	state int
+
+	username, authzID string
+	gs2Header         string
+	clientFirstBare   string
+	serverFirst       string
+	nonce             string
+	creds             *Credentials
+	unknown           bool
+}
+
+const (
+	serverStateClientFirst = iota
+	serverStateClientFinal
+	serverStateDone
+	serverStateFailed
+)
+
+// Creates a server for the given mechanism, e.g. "SCRAM-SHA-256". For a
+// "-PLUS" mechanism, SetChannelBinding must also be called.
+func NewServer(mechanismName string, lookup LookupFunc) (*Server, error) {
+	m, err := parseMechanism(mechanismName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		mech:   m,
+		lookup: lookup,
+	}, nil
+}
+
+// Indicates that the server supports channel binding of the given type, e.g.
+// "tls-server-end-point", and provides the channel binding data for the
+// underlying connection. This should be called if and only if the server
+// advertises the "-PLUS" variant of the mechanism.
+func (s *Server) SetChannelBinding(cbType string, data []byte) {
+	s.cbType = cbType
+	s.cbData = data
+}
+
+// Configures the fake credentials presented to clients for unknown users. The
+// salt is derived from secret and the username, so that it is the same in
+// every exchange, and iterations should be that of most real credentials.
+//
+// If this is not called, the secret is generated randomly when first needed
+// and shared by all servers in the process, and the iteration count is
+// raw.RecommendedRounds. A fixed secret should be configured if clients may
+// connect to several processes.
+func (s *Server) SetUnknownUserCredentials(secret []byte, iterations int) {
+	s.unknownSecret = secret
+	s.unknownIterations = iterations
+}
+
+var defaultUnknownSecret struct {
+	once   sync.Once
+	secret []byte
+	err    error
+}
+
+// Returns credentials for an unknown user, which are derived from the secret
+// and the username and which no password can match.
+func (s *Server) fakeCredentials(username string) (*Credentials, error) {
+	secret := s.unknownSecret
+	if secret == nil {
+		d := &defaultUnknownSecret
+		d.once.Do(func() {
+			d.secret = make([]byte, 32)
+			_, d.err = rand.Read(d.secret)
+		})
+		if d.err != nil {
+			return nil, d.err
+		}
+
+		secret = d.secret
+	}
+
+	iterations := s.unknownIterations
+	if iterations == 0 {
+		iterations = raw.RecommendedRounds
+	}
+
+	hf := s.mech.hf
+	return &Credentials{
+		Salt:       raw.HMAC(hf, secret, []byte("salt\x00"+username))[0:raw.SaltLength],
+		Iterations: iterations,
+		StoredKey:  raw.HMAC(hf, secret, []byte("stored-key\x00"+username)),
+		ServerKey:  raw.HMAC(hf, secret, []byte("server-key\x00"+username)),
+	}, nil
+}
+
+// Returns the username sent by the client.
+func (s *Server) Username() string {
+	return s.username
+}
+
+// Returns the authorization identity sent by the client, if any.
+func (s *Server) AuthzID() string {
+	return s.authzID
+}
+
+// Returns true if the client has been successfully authenticated.
+func (s *Server) Done() bool {
+	return s.state == serverStateDone
+}
+
+// Processes a client message and returns the response.
+//
+// If authentication fails in the final step, the returned message contains
+// the error to be sent to the client and err is a ServerError.
+func (s *Server) Step(in []byte) (out []byte, err error) {
+	switch s.state {
+	case serverStateClientFirst:
+		out, err = s.clientFirst(string(in))
+		if err == nil {
+			s.state = serverStateClientFinal
+		}
+	case serverStateClientFinal:
+		out, err = s.clientFinal(string(in))
+		if err == nil {
+			s.state = serverStateDone
+		} else if se, ok := err.(ServerError); ok {
+			out = []byte("e=" + string(se))
+		}
+	default:
+		return nil, ErrDone
+	}
+
+	if err != nil {
+		s.state = serverStateFailed
+	}
+
+	return
+}
+
+func (s *Server) clientFirst(msg string) ([]byte, error) {
+	// gs2-header: cbind-flag "," [authzid] ","
+	parts := strings.SplitN(msg, ",", 3)
+	if len(parts) != 3 {
+		return nil, ErrInvalidMessage
+	}
+
+	switch flag := parts[0]; {
+	case flag == "n":
+		if s.mech.plus {
+			return nil, ErrChannelBindingNotSupported
+		}
+	case flag == "y":
+		if s.mech.plus {
+			return nil, ErrInvalidMessage
+		}
+
+		if s.cbType != "" {
+			return nil, ErrServerDoesSupportChannelBinding
+		}
+	case strings.HasPrefix(flag, "p="):
+		if !s.mech.plus || s.cbType == "" {
+			return nil, ErrChannelBindingNotSupported
+		}
+
+		if flag[2:] != s.cbType {
+			return nil, ErrUnsupportedChannelBindingType
+		}
+	default:
+		return nil, ErrInvalidMessage
+	}
+
+	if parts[1] != "" {
+		if !strings.HasPrefix(parts[1], "a=") {
+			return nil, ErrInvalidMessage
+		}
+
+		authzID, err := decodeName(parts[1][2:])
+		if err != nil {
+			return nil, err
+		}
+
+		s.authzID = authzID
+	}
+
+	s.gs2Header = parts[0] + "," + parts[1] + ","
+	s.clientFirstBare = parts[2]
+
+	if strings.HasPrefix(s.clientFirstBare, "m=") {
+		return nil, ErrExtensionsNotSupported
+	}
+
+	attrs, err := parseAttributes(s.clientFirstBare, 'n', 'r')
+	if err != nil {
+		return nil, err
+	}
+
+	if s.username, err = decodeName(attrs[0]); err != nil {
+		return nil, err
+	}
+
+	if attrs[1] == "" {
+		return nil, ErrInvalidMessage
+	}
+
+	s.creds, err = s.lookup(s.username)
+	if err == ErrUnknownUser || (err == nil && s.creds == nil) {
+		s.unknown = true
+		if s.creds, err = s.fakeCredentials(s.username); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	if s.nonce == "" {
+		serverNonce, err := makeNonce()
+		if err != nil {
+			return nil, err
+		}
+
+		s.nonce = attrs[1] + serverNonce
+	}
+
+	s.serverFirst = "r=" + s.nonce +
+		",s=" + base64.StdEncoding.EncodeToString(s.creds.Salt) +
+		",i=" + strconv.Itoa(s.creds.Iterations)
+
+	return []byte(s.serverFirst), nil
+}
+
+func (s *Server) clientFinal(msg string) ([]byte, error) {
+	i := strings.LastIndex(msg, ",p=")
+	if i < 0 {
+		return nil, ErrInvalidMessage
+	}
+
+	withoutProof := msg[0:i]
+
+	proof, err := base64.StdEncoding.DecodeString(msg[i+3:])
+	if err != nil {
+		return nil, ErrInvalidEncoding
+	}
+
+	attrs, err := parseAttributes(withoutProof, 'c', 'r')
+	if err != nil {
+		return nil, err
+	}
+
+	cb, err := base64.StdEncoding.DecodeString(attrs[0])
+	if err != nil {
+		return nil, ErrInvalidEncoding
+	}
+
+	expectedCB := s.gs2Header
+	if strings.HasPrefix(s.gs2Header, "p=") {
+		expectedCB += string(s.cbData)
+	}
+
+	if !abstract.SecureCompare(string(cb), expectedCB) {
+		return nil, ErrChannelBindingsDontMatch
+	}
+
+	if attrs[1] != s.nonce {
+		return nil, ErrInvalidMessage
+	}
+
+	authMessage := []byte(s.clientFirstBare + "," + s.serverFirst + "," + withoutProof)
+
+	hf := s.mech.hf
+	clientSignature := raw.HMAC(hf, s.creds.StoredKey, authMessage)
+	if len(proof) != len(clientSignature) {
+		return nil, ErrInvalidProof
+	}
+
+	clientKey := xorBytes(proof, clientSignature)
+	if !abstract.SecureCompare(string(raw.StoredKey(hf, clientKey)), string(s.creds.StoredKey)) || s.unknown {
+		return nil, ErrInvalidProof
+	}
+
+	serverSignature := raw.HMAC(hf, s.creds.ServerKey, authMessage)
+	return []byte("v=" + base64.StdEncoding.EncodeToString(serverSignature)), nil
+}
@@ -0,0 +1,91 @@
+package scrypt
+
+import "fmt"
+import "expvar"
+import "strings"
+import "crypto/rand"
+import "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+import yraw "gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+
+var cScrypt7HashCalls = expvar.NewInt("passlib.scrypt7.hashCalls")
+var cScrypt7VerifyCalls = expvar.NewInt("passlib.scrypt7.verifyCalls")
+
+// An implementation of Scheme performing scrypt in the $7$ format used by
+// libxcrypt and libsodium.
+//
+// Uses the recommended values for N,r,p defined in raw.
+var Crypter7 abstract.Scheme
+
+func init() {
+	Crypter7 = New7(
+		raw.RecommendedN,
+		raw.Recommendedr,
+		raw.Recommendedp,
+	)
+}
+
+// Returns an implementation of Scheme implementing scrypt in the $7$ format
+// with the specified parameters. N must be a power of two.
+func New7(N, r, p int) abstract.Scheme {
+	return &scrypt7Crypter{
+		nN: N,
+		r:  r,
+		p:  p,
+	}
+}
+
+type scrypt7Crypter struct {
+	nN, r, p int
+}
+
+// Number of random bytes used to generate a salt. The salt is stored as
+// crypt base64, so this yields a 22 character salt string.
+const scrypt7SaltBytes = 16
+
+func (c *scrypt7Crypter) SetParams(N, r, p int) error {
+	c.nN = N
+	c.r = r
+	c.p = p
+	return nil
+}
+
+func (c *scrypt7Crypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$7$")
+}
+
+func (c *scrypt7Crypter) Hash(password string) (string, error) {
+	cScrypt7HashCalls.Add(1)
+
+	buf := make([]byte, scrypt7SaltBytes)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Crypt7(password, yraw.EncodeBase64(buf), c.nN, c.r, c.p)
+}
+
+func (c *scrypt7Crypter) Verify(password, hash string) (err error) {
+	cScrypt7VerifyCalls.Add(1)
+
+	newHash, err := raw.Crypt7Setting(password, hash)
+	if err == nil && !abstract.SecureCompare(hash, newHash) {
+		err = abstract.ErrInvalidPassword
+	}
+
+	return
+}
+
+func (c *scrypt7Crypter) NeedsUpdate(stub string) bool {
+	salt, _, N, r, p, err := raw.Parse7(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return len(salt)*6 < scrypt7SaltBytes*8 || N < c.nN || r < c.r || p < c.p
+}
+
+func (c *scrypt7Crypter) String() string {
+	return fmt.Sprintf("scrypt7(%d,%d,%d)", c.nN, c.r, c.p)
+}
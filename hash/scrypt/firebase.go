@@ -0,0 +1,140 @@
+package scrypt
+
+import "fmt"
+import "expvar"
+import "strings"
+import "encoding/base64"
+import "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+
+var cScryptFirebaseVerifyCalls = expvar.NewInt("passlib.scryptfirebase.verifyCalls")
+
+// The hash parameters of a Firebase Authentication project, as shown in the
+// Firebase console and used by the auth:import command of the Firebase CLI.
+type FirebaseConfig struct {
+	// The base64_signer_key and base64_salt_separator values, in standard
+	// base64.
+	SignerKey     string
+	SaltSeparator string
+
+	// The rounds and mem_cost values.
+	Rounds  int
+	MemCost int
+}
+
+func (cfg *FirebaseConfig) checkCost() error {
+	if cfg.Rounds < 1 || cfg.MemCost < 1 || cfg.MemCost > 30 {
+		return fmt.Errorf("invalid Firebase rounds or memory cost")
+	}
+
+	return raw.CheckParams(1<<uint(cfg.MemCost), cfg.Rounds, 1)
+}
+
+func (cfg *FirebaseConfig) params() (*raw.FirebaseParams, error) {
+	if err := cfg.checkCost(); err != nil {
+		return nil, err
+	}
+
+	signerKey, err := base64.StdEncoding.DecodeString(cfg.SignerKey)
+	if err != nil || len(signerKey) == 0 {
+		return nil, fmt.Errorf("invalid Firebase signer key")
+	}
+
+	saltSeparator, err := base64.StdEncoding.DecodeString(cfg.SaltSeparator)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Firebase salt separator")
+	}
+
+	return &raw.FirebaseParams{
+		SignerKey:     signerKey,
+		SaltSeparator: saltSeparator,
+		Rounds:        cfg.Rounds,
+		MemCost:       cfg.MemCost,
+	}, nil
+}
+
+// Encodes the passwordHash and salt fields of a user exported from the
+// project, which are in standard base64, as a $firebase-scrypt$ string which
+// can be stored and later verified by a scheme returned by NewFirebase.
+func (cfg *FirebaseConfig) Encode(passwordHash, salt string) (string, error) {
+	if err := cfg.checkCost(); err != nil {
+		return "", err
+	}
+
+	h, err := base64.StdEncoding.DecodeString(passwordHash)
+	if err != nil || len(h) == 0 {
+		return "", raw.ErrInvalidStub
+	}
+
+	s, err := base64.StdEncoding.DecodeString(salt)
+	if err != nil {
+		return "", raw.ErrInvalidStub
+	}
+
+	return raw.FormatFirebase(s, h, cfg.Rounds, cfg.MemCost), nil
+}
+
+// Returns an implementation of Scheme verifying $firebase-scrypt$ hashes
+// exported from the Firebase project with the given configuration.
+//
+// New hashes cannot be created, so this is a verify-only scheme (see
+// abstract.VerifyOnlyScheme). It is intended for use in a Context with a
+// preferred scheme to which users are upgraded when they next log in.
+func NewFirebase(cfg FirebaseConfig) (abstract.Scheme, error) {
+	p, err := cfg.params()
+	if err != nil {
+		return nil, err
+	}
+
+	return &scryptFirebaseCrypter{params: *p}, nil
+}
+
+type scryptFirebaseCrypter struct {
+	params raw.FirebaseParams
+}
+
+func (c *scryptFirebaseCrypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$firebase-scrypt$")
+}
+
+func (c *scryptFirebaseCrypter) Hash(password string) (string, error) {
+	return "", abstract.ErrVerifyOnly
+}
+
+// The rounds and memory cost stored in the hash are used, so that hashes
+// exported before the project parameters were changed can be verified.
+func (c *scryptFirebaseCrypter) Verify(password, hash string) error {
+	cScryptFirebaseVerifyCalls.Add(1)
+
+	salt, oldHashRaw, rounds, memCost, err := raw.ParseFirebase(hash)
+	if err != nil {
+		return err
+	}
+
+	p := c.params
+	p.Rounds = rounds
+	p.MemCost = memCost
+
+	newHashRaw, err := raw.KeyFirebase(password, salt, &p)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(oldHashRaw), string(newHashRaw)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scryptFirebaseCrypter) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (c *scryptFirebaseCrypter) VerifyOnly() bool {
+	return true
+}
+
+func (c *scryptFirebaseCrypter) String() string {
+	return fmt.Sprintf("scrypt-firebase(%d,%d)", c.params.Rounds, c.params.MemCost)
+}
@@ -0,0 +1,72 @@
+package scrypt
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+)
+
+var firebaseConfig = FirebaseConfig{
+	SignerKey:     "jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==",
+	SaltSeparator: "Bw==",
+	Rounds:        8,
+	MemCost:       14,
+}
+
+func TestFirebase(t *testing.T) {
+	c, err := NewFirebase(firebaseConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := firebaseConfig.Encode("lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==", "42xEC+ixf3L2lw==")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.SupportsStub(h) {
+		t.Errorf("encoded hash not supported: %s", h)
+	}
+
+	if err := c.Verify("user1password", h); err != nil {
+		t.Errorf("valid password not accepted: %v", err)
+	}
+
+	if err := c.Verify("user2password", h); err != abstract.ErrInvalidPassword {
+		t.Errorf("invalid password accepted: %v", err)
+	}
+
+	if !abstract.IsVerifyOnly(c) || !c.NeedsUpdate(h) {
+		t.Errorf("scheme not verify-only")
+	}
+
+	if _, err := c.Hash("user1password"); err != abstract.ErrVerifyOnly {
+		t.Errorf("unexpected result from Hash: %v", err)
+	}
+
+	if _, err := NewFirebase(FirebaseConfig{SignerKey: "!", Rounds: 8, MemCost: 14}); err == nil {
+		t.Errorf("invalid signer key accepted")
+	}
+
+	for _, cfg := range []FirebaseConfig{
+		{SignerKey: firebaseConfig.SignerKey, Rounds: 0, MemCost: 14},
+		{SignerKey: firebaseConfig.SignerKey, Rounds: -8, MemCost: 14},
+		{SignerKey: firebaseConfig.SignerKey, Rounds: 8, MemCost: 0},
+		{SignerKey: firebaseConfig.SignerKey, Rounds: 8, MemCost: -14},
+		{SignerKey: firebaseConfig.SignerKey, Rounds: 1024, MemCost: 14},
+	} {
+		if _, err := NewFirebase(cfg); err == nil {
+			t.Errorf("invalid cost accepted: %d, %d", cfg.Rounds, cfg.MemCost)
+		}
+
+		if _, err := cfg.Encode("aGFzaA==", "c2FsdA=="); err == nil {
+			t.Errorf("invalid cost encoded: %d, %d", cfg.Rounds, cfg.MemCost)
+		}
+	}
+
+	// A stored hash cannot override the project parameters with excessive ones.
+	if err := c.Verify("user1password", "$firebase-scrypt$r=4096,m=24$c2FsdA$aGFzaA"); err != raw.ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package scrypt
+
+import "fmt"
+import "expvar"
+import "strings"
+import "crypto/rand"
+import "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+
+var cScryptPasslibHashCalls = expvar.NewInt("passlib.scryptpasslib.hashCalls")
+var cScryptPasslibVerifyCalls = expvar.NewInt("passlib.scryptpasslib.verifyCalls")
+
+// An implementation of Scheme performing scrypt in the $scrypt$ format used
+// by Python passlib.
+//
+// Uses the recommended values for log2(N), r and p defined in raw.
+var PasslibCrypter abstract.Scheme
+
+func init() {
+	PasslibCrypter = NewPasslib(
+		raw.RecommendedLogN,
+		raw.Recommendedr,
+		raw.Recommendedp,
+	)
+}
+
+// Returns an implementation of Scheme implementing scrypt in the $scrypt$
+// format with the specified parameters. logN is log2 of the scrypt N
+// parameter, as stored in the "ln" field of the hash.
+func NewPasslib(logN, r, p int) abstract.Scheme {
+	return &scryptPasslibCrypter{
+		logN: logN,
+		r:    r,
+		p:    p,
+	}
+}
+
+type scryptPasslibCrypter struct {
+	logN, r, p int
+}
+
+// Python passlib's default salt size.
+const scryptPasslibSaltLength = 16
+
+func (c *scryptPasslibCrypter) SetParams(logN, r, p int) error {
+	c.logN = logN
+	c.r = r
+	c.p = p
+	return nil
+}
+
+func (c *scryptPasslibCrypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$scrypt$")
+}
+
+func (c *scryptPasslibCrypter) Hash(password string) (string, error) {
+	cScryptPasslibHashCalls.Add(1)
+
+	salt := make([]byte, scryptPasslibSaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.ScryptPasslib(password, salt, c.logN, c.r, c.p)
+}
+
+func (c *scryptPasslibCrypter) Verify(password, hash string) error {
+	cScryptPasslibVerifyCalls.Add(1)
+
+	salt, oldHashRaw, logN, r, p, err := raw.ParsePasslib(hash)
+	if err != nil {
+		return err
+	}
+
+	if oldHashRaw == nil {
+		return raw.ErrInvalidStub
+	}
+
+	// Compare the raw keys, since either base64 alphabet may have been used.
+	newHashRaw, err := raw.KeyPasslib(password, salt, logN, r, p)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(oldHashRaw), string(newHashRaw)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scryptPasslibCrypter) NeedsUpdate(stub string) bool {
+	salt, _, logN, r, p, err := raw.ParsePasslib(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return len(salt) < scryptPasslibSaltLength || logN < c.logN || r < c.r || p < c.p
+}
+
+func (c *scryptPasslibCrypter) String() string {
+	return fmt.Sprintf("scrypt-passlib(%d,%d,%d)", c.logN, c.r, c.p)
+}
@@ -0,0 +1,126 @@
+package raw
+
+import (
+	"strings"
+
+	yraw "gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+)
+
+// Length of the derived key embedded in a $7$ hash.
+const Crypt7HashLength = 32
+
+// Calculates an scrypt hash in the $7$ format used by libxcrypt and libsodium.
+//
+// password should be a UTF-8 plaintext password.
+// salt is used verbatim, and should be a random string of characters from the
+// crypt base64 alphabet.
+//
+// N, r and p are parameters to scrypt. N must be a power of two, and the
+// parameters must not require more than MaxMemory.
+//
+// Returns a modular crypt hash.
+func Crypt7(password, salt string, N, r, p int) (string, error) {
+	if N < 2 || N&(N-1) != 0 || r < 1 || r >= 1<<30 || p < 1 || p >= 1<<30 ||
+		strings.IndexByte(salt, '$') >= 0 {
+		return "", ErrInvalidStub
+	}
+
+	nLog2 := 0
+	for n := N; n > 1; n >>= 1 {
+		nLog2++
+	}
+
+	setting := "$7$" + yraw.EncodeUint32Fixed(uint32(nLog2), 6) +
+		yraw.EncodeUint32Fixed(uint32(r), 30) +
+		yraw.EncodeUint32Fixed(uint32(p), 30) + salt
+
+	return Crypt7Setting(password, setting)
+}
+
+// Calculates an scrypt hash using a $7$ setting string (or full hash).
+//
+// As with libxcrypt, the salt extends to the last "$", and anything after it
+// (normally the hash) is ignored.
+func Crypt7Setting(password, setting string) (string, error) {
+	N, r, p, salt, err := parse7Params(setting)
+	if err != nil {
+		return "", err
+	}
+
+	if i := strings.LastIndexByte(salt, '$'); i >= 0 {
+		salt = salt[0:i]
+	}
+
+	hash, err := Key([]byte(password), []byte(salt), N, r, p, Crypt7HashLength)
+	if err != nil {
+		return "", err
+	}
+
+	return setting[0:14+len(salt)] + "$" + yraw.EncodeBase64(hash), nil
+}
+
+// Parses a $7$ modular hash or stub string.
+//
+// The format is as follows:
+//
+//	$7$Nrrrrrppppsalt$hash    // hash
+//	$7$Nrrrrrppppsalt         // stub
+//
+// N is a single character encoding log2(N), and r and p are encoded in five
+// characters each, all using the crypt base64 alphabet. The salt is used
+// as-is; the hash is crypt base64 encoded. ErrInvalidParams is returned if
+// the parameters require more than MaxMemory.
+func Parse7(stub string) (salt string, hash []byte, N, r, p int, err error) {
+	N, r, p, salt, err = parse7Params(stub)
+	if err != nil {
+		return
+	}
+
+	rest := salt
+	if i := strings.IndexByte(rest, '$'); i >= 0 {
+		salt = rest[0:i]
+		if hash, err = yraw.DecodeBase64(rest[i+1:]); err != nil {
+			return
+		}
+
+		if len(hash) != Crypt7HashLength {
+			err = ErrInvalidStub
+			return
+		}
+	}
+
+	return
+}
+
+// Parses the parameters of a $7$ string, returning the remainder following
+// them.
+func parse7Params(stub string) (N, r, p int, rest string, err error) {
+	if len(stub) < 14 || !strings.HasPrefix(stub, "$7$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	nLog2, rest, err := yraw.DecodeUint32Fixed(stub[3:], 6)
+	if err != nil {
+		return
+	}
+
+	ri, rest, err := yraw.DecodeUint32Fixed(rest, 30)
+	if err != nil {
+		return
+	}
+
+	pi, rest, err := yraw.DecodeUint32Fixed(rest, 30)
+	if err != nil {
+		return
+	}
+
+	if nLog2 < 1 || nLog2 > 30 || ri == 0 || pi == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	N, r, p = 1<<nLog2, int(ri), int(pi)
+	err = CheckParams(N, r, p)
+	return
+}
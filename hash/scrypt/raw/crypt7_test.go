@@ -0,0 +1,76 @@
+package raw
+
+import (
+	"testing"
+
+	yraw "gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+)
+
+// Generated using libxcrypt's crypt(3).
+var crypt7Tests = []struct {
+	password string
+	hash     string
+}{
+	{"", "$7$A/....0....SodiumChloride$h0vbPj9o1zvOls0gS/jQvIcBhin8KchL/qzS7UgiD8/"},
+	{"password", "$7$A/....0....SodiumChloride$AIIQ9n7HMCyJm9MVM98LUcmrZS2yUGAExH.J1iwbBI8"},
+	{"U*U*U*U*", "$7$A/....0....SodiumChloride$WqxR4hzoxjWwtPyxrsHQGc8nGoyHtFY/xQizgSGxKO1"},
+	{"password", "$7$CU..../....abcdefgh$sWsarqbldvBJgryJJYHjYzc1J1T48nJOIdZfeQFpq2A"},
+	{"password", "$7$9/..../....$omc3.CFNxj3RSy97mitXoPSGAzSMIFaCmr7l9YrkMS6"},
+}
+
+func TestCrypt7(t *testing.T) {
+	for _, tst := range crypt7Tests {
+		out, err := Crypt7Setting(tst.password, tst.hash)
+		if err != nil {
+			t.Errorf("error: %v (%#v)", err, tst.hash)
+		} else if out != tst.hash {
+			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n",
+				out, tst.hash, tst.password)
+		}
+	}
+
+	out, err := Crypt7("password", "abcdefgh", 16384, 32, 1)
+	if err != nil || out != crypt7Tests[3].hash {
+		t.Errorf("unexpected result: %#v (%v)", out, err)
+	}
+
+	for _, s := range []string{
+		"$7$CU..../.",                            // truncated
+		"$7$9/........",                          // p = 0
+		"$7$CU..../....abcdefgh$sWsarqbldvBJgry", // short hash
+		"$y$j9T$F5Jx5fExrKuPp53xLKQ..1",          // wrong scheme
+	} {
+		if _, _, _, _, _, err := Parse7(s); err == nil {
+			t.Errorf("expected parse error: %q", s)
+		}
+	}
+}
+
+func TestCrypt7MaxMemory(t *testing.T) {
+	setting := func(nLog2, r, p uint32) string {
+		return "$7$" + yraw.EncodeUint32Fixed(nLog2, 6) + yraw.EncodeUint32Fixed(r, 30) +
+			yraw.EncodeUint32Fixed(p, 30) + "abcdefgh"
+	}
+
+	for _, s := range []string{
+		setting(24, 4096, 1),  // 8 TiB
+		setting(14, 1024, 1),  // 2 GiB
+		setting(14, 8, 1<<21), // 2 GiB
+	} {
+		if _, _, _, _, _, err := Parse7(s); err != ErrInvalidParams {
+			t.Errorf("excessive memory accepted: %q: %v", s, err)
+		}
+
+		if _, err := Crypt7Setting("password", s); err != ErrInvalidParams {
+			t.Errorf("excessive memory accepted: %q: %v", s, err)
+		}
+	}
+
+	if _, err := Crypt7("password", "abc", 16384, 1024, 1); err != ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+
+	if _, _, _, _, _, err := Parse7(setting(14, 1023, 1)); err != nil {
+		t.Errorf("parameters within limit rejected: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package raw
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// Project-level parameters of Firebase Authentication's modified scrypt, as
+// shown in the password hash parameters of the Firebase console.
+type FirebaseParams struct {
+	// The signer key and salt separator in binary form.
+	SignerKey     []byte
+	SaltSeparator []byte
+
+	// The scrypt r parameter.
+	Rounds int
+
+	// log2 of the scrypt N parameter.
+	MemCost int
+}
+
+// Derives a Firebase password hash.
+//
+// The password and the salt followed by the salt separator are passed to
+// scrypt with N = 2**MemCost, r = Rounds and p = 1. The resulting 32-byte key
+// is used to encrypt the signer key with AES-256 in CTR mode with a zero IV,
+// and the ciphertext is the password hash. ErrInvalidParams is returned if the
+// parameters require more than MaxMemory.
+func KeyFirebase(password string, salt []byte, p *FirebaseParams) ([]byte, error) {
+	if p.MemCost < 1 || p.MemCost > 30 || len(p.SignerKey) == 0 {
+		return nil, ErrInvalidStub
+	}
+
+	s := make([]byte, 0, len(salt)+len(p.SaltSeparator))
+	s = append(s, salt...)
+	s = append(s, p.SaltSeparator...)
+
+	key, err := Key([]byte(password), s, 1<<uint(p.MemCost), p.Rounds, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(p.SignerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(out, p.SignerKey)
+	return out, nil
+}
+
+// Encodes a Firebase password hash and salt, as found in an export, together
+// with the rounds and memory cost of the project which produced them.
+//
+// The format is as follows:
+//
+//	$firebase-scrypt$r=rounds,m=memCost$salt$hash
+//
+// This is a PHC string; see package phc. The signer key and salt separator
+// are not included and must be supplied when verifying.
+func FormatFirebase(salt, hash []byte, rounds, memCost int) string {
+	h := phc.Hash{ID: "firebase-scrypt", Salt: salt, Hash: hash}
+	h.AddParamUint("r", uint64(rounds))
+	h.AddParamUint("m", uint64(memCost))
+	return h.String()
+}
+
+// Parses a $firebase-scrypt$ hash. ErrInvalidParams is returned if the rounds
+// and memory cost require more than MaxMemory.
+func ParseFirebase(stub string) (salt, hash []byte, rounds, memCost int, err error) {
+	if !strings.HasPrefix(stub, "$firebase-scrypt$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	h, err := phc.Parse(stub)
+	if err != nil {
+		return
+	}
+
+	if h.HasVersion || h.Salt == nil || len(h.Hash) == 0 || !h.HasOnlyParams("r", "m") {
+		err = ErrInvalidStub
+		return
+	}
+
+	r, err := h.ParamUint("r", 31)
+	if err != nil {
+		return
+	}
+
+	m, err := h.ParamUint("m", 31)
+	if err != nil {
+		return
+	}
+
+	if m < 1 || m > 30 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if err = CheckParams(1<<m, int(r), 1); err != nil {
+		return
+	}
+
+	return h.Salt, h.Hash, int(r), int(m), nil
+}
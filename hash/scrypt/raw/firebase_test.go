@@ -0,0 +1,54 @@
+package raw
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestFirebase(t *testing.T) {
+	// From the firebase/scrypt reference implementation.
+	signerKey, _ := base64.StdEncoding.DecodeString("jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==")
+	saltSeparator, _ := base64.StdEncoding.DecodeString("Bw==")
+	salt, _ := base64.StdEncoding.DecodeString("42xEC+ixf3L2lw==")
+
+	p := &FirebaseParams{
+		SignerKey:     signerKey,
+		SaltSeparator: saltSeparator,
+		Rounds:        8,
+		MemCost:       14,
+	}
+
+	h, err := KeyFirebase("user1password", salt, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s := base64.StdEncoding.EncodeToString(h); s != "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==" {
+		t.Errorf("mismatch: %s", s)
+	}
+
+	stub := FormatFirebase(salt, h, 8, 14)
+	salt2, h2, r, m, err := ParseFirebase(stub)
+	if err != nil || string(salt2) != string(salt) || string(h2) != string(h) || r != 8 || m != 14 {
+		t.Errorf("round trip failed: %s %v", stub, err)
+	}
+}
+
+func TestFirebaseMaxMemory(t *testing.T) {
+	for _, s := range []string{
+		"$firebase-scrypt$r=1048576,m=20$c2FsdA$aGFzaA", // 128 TiB
+		"$firebase-scrypt$r=1024,m=14$c2FsdA$aGFzaA",    // 2 GiB
+		"$firebase-scrypt$r=0,m=14$c2FsdA$aGFzaA",
+		"$firebase-scrypt$r=8,m=0$c2FsdA$aGFzaA",
+		"$firebase-scrypt$r=8,m=31$c2FsdA$aGFzaA",
+	} {
+		if _, _, _, _, err := ParseFirebase(s); err == nil {
+			t.Errorf("invalid parameters accepted: %q", s)
+		}
+	}
+
+	p := &FirebaseParams{SignerKey: []byte("key"), Rounds: 1024, MemCost: 14}
+	if _, err := KeyFirebase("password", nil, p); err != ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package raw
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// The maximum amount of memory, in bytes, that verifying a $7$, $scrypt$ or
+// $firebase-scrypt$ hash may require. Hashes exceeding it are rejected, so
+// that a malicious stored hash cannot exhaust memory.
+const MaxMemory = 1 << 31
+
+// Indicates that scrypt parameters are out of range or require more than
+// MaxMemory.
+var ErrInvalidParams = fmt.Errorf("invalid scrypt parameters")
+
+// Checks that N, r and p are positive and do not require more than MaxMemory.
+func CheckParams(N, r, p int) error {
+	// V takes 128·r·N bytes and B 128·r·p.
+	if N < 2 || r < 1 || p < 1 ||
+		uint64(r) > MaxMemory/(128*(uint64(N)+uint64(p))) {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+// Derives a key using scrypt, returning ErrInvalidParams if the parameters
+// would require more than MaxMemory.
+func Key(password, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	if err := CheckParams(N, r, p); err != nil {
+		return nil, err
+	}
+
+	return scrypt.Key(password, salt, N, r, p, keyLen)
+}
@@ -0,0 +1,92 @@
+package raw
+
+import (
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// The current recommended log2(N) value for Python passlib's $scrypt$
+// format. This matches passlib's default.
+const RecommendedLogN = 16
+
+// Length of the derived key embedded in a $scrypt$ hash.
+const PasslibHashLength = 32
+
+// Calculates an scrypt hash in the $scrypt$ format used by Python passlib.
+//
+// password should be a UTF-8 plaintext password.
+// salt should be a random salt value in binary form.
+//
+// logN is log2 of the scrypt N parameter; r and p are parameters to scrypt.
+// The parameters must not require more than MaxMemory.
+//
+// Returns a modular crypt hash.
+func ScryptPasslib(password string, salt []byte, logN, r, p int) (string, error) {
+	hash, err := KeyPasslib(password, salt, logN, r, p)
+	if err != nil {
+		return "", err
+	}
+
+	h := phc.Hash{ID: "scrypt", Salt: salt, Hash: hash}
+	h.AddParamUint("ln", uint64(logN))
+	h.AddParamUint("r", uint64(r))
+	h.AddParamUint("p", uint64(p))
+
+	return h.String(), nil
+}
+
+// Derives the raw key embedded in a $scrypt$ hash.
+func KeyPasslib(password string, salt []byte, logN, r, p int) ([]byte, error) {
+	if logN < 1 || logN > 30 {
+		return nil, ErrInvalidStub
+	}
+
+	return Key([]byte(password), salt, 1<<uint(logN), r, p, PasslibHashLength)
+}
+
+// Parses a $scrypt$ modular hash or stub string.
+//
+// The format is as follows:
+//
+//	$scrypt$ln=logN,r=r,p=p$salt$hash    // hash
+//	$scrypt$ln=logN,r=r,p=p$salt         // stub
+//
+// This is a PHC string; see package phc. Python passlib writes the salt and
+// hash using standard base64 without padding, but the ab64 variant, which uses
+// '.' instead of '+', is also accepted. ErrInvalidParams is returned if the
+// parameters require more than MaxMemory.
+func ParsePasslib(stub string) (salt, hash []byte, logN, r, p int, err error) {
+	h, err := phc.Parse(strings.Replace(stub, ".", "+", -1))
+	if err != nil {
+		return
+	}
+
+	if h.ID != "scrypt" || h.HasVersion || h.Salt == nil || !h.HasOnlyParams("ln", "r", "p") {
+		err = ErrInvalidStub
+		return
+	}
+
+	var v [3]uint64
+	for i, name := range []string{"ln", "r", "p"} {
+		if v[i], err = h.ParamUint(name, 31); err != nil {
+			return
+		}
+	}
+
+	if h.Hash != nil && len(h.Hash) != PasslibHashLength {
+		err = ErrInvalidStub
+		return
+	}
+
+	if v[0] < 1 || v[0] > 30 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if err = CheckParams(1<<v[0], int(v[1]), int(v[2])); err != nil {
+		return
+	}
+
+	return h.Salt, h.Hash, int(v[0]), int(v[1]), int(v[2]), nil
+}
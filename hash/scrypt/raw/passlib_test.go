@@ -0,0 +1,72 @@
+package raw
+
+import "testing"
+
+// Generated using Python's hashlib.scrypt; the first is from the passlib
+// documentation.
+var passlibTests = []struct {
+	password string
+	hash     string
+}{
+	{"password", "$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E"},
+	{"", "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$KjMXA+ztMvG1DNU/OJLnH+cwgCb03WVM93yCoDb2OPE"},
+	{"password", "$scrypt$ln=10,r=8,p=1$AAECAwQFBgcICQoLDA0ODw$OnwHgqTb31Q6zXxSL+hT2bNKu4ryelxll0iM3yKBQLU"},
+	{"U*U*U*U*", "$scrypt$ln=6,r=4,p=3$+//++//++//++//++//++//+$K2TZqrjkR/kRlI+tgCcYtgEnUhARgkpudEE9jEfTLqY"},
+	{"táБℓə", "$scrypt$ln=8,r=8,p=1$MDEyMzQ1Njc4OWFiY2RlZg$HAVbsF9/u2zKUQ3vfSD5w6pvsqrM7Jq6jXZH5emazUc"},
+}
+
+func TestPasslib(t *testing.T) {
+	for _, tst := range passlibTests {
+		salt, _, logN, r, p, err := ParsePasslib(tst.hash)
+		if err != nil {
+			t.Errorf("error: %v (%#v)", err, tst.hash)
+			continue
+		}
+
+		out, err := ScryptPasslib(tst.password, salt, logN, r, p)
+		if err != nil {
+			t.Errorf("error: %v (%#v)", err, tst.hash)
+		} else if out != tst.hash {
+			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n",
+				out, tst.hash, tst.password)
+		}
+	}
+
+	// ab64 encoding.
+	_, hash, _, _, _, err := ParsePasslib("$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$KjMXA.ztMvG1DNU/OJLnH.cwgCb03WVM93yCoDb2OPE")
+	if err != nil || len(hash) != PasslibHashLength {
+		t.Errorf("cannot parse ab64 hash: %v", err)
+	}
+
+	for _, s := range []string{
+		"$scrypt$ln=4,r=8$c2FsdHNhbHRzYWx0c2FsdA",          // missing p
+		"$scrypt$ln=4,r=8,p=1,p=1$c2FsdHNhbHRzYWx0c2FsdA",  // duplicate p
+		"$scrypt$ln=4,r=8,p=1,x=1$c2FsdHNhbHRzYWx0c2FsdA",  // unknown parameter
+		"$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$KjMX", // short hash
+		"$s2$16384$8$1$c2FsdHNhbHRzYWx0c2FsdA",             // wrong scheme
+	} {
+		if _, _, _, _, _, err := ParsePasslib(s); err == nil {
+			t.Errorf("expected parse error: %q", s)
+		}
+	}
+}
+
+func TestPasslibMaxMemory(t *testing.T) {
+	for _, s := range []string{
+		"$scrypt$ln=20,r=4096,p=1$c2FsdHNhbHRzYWx0c2FsdA",   // 512 GiB
+		"$scrypt$ln=14,r=1024,p=1$c2FsdHNhbHRzYWx0c2FsdA",   // 2 GiB
+		"$scrypt$ln=4,r=8,p=2097152$c2FsdHNhbHRzYWx0c2FsdA", // 2 GiB
+	} {
+		if _, _, _, _, _, err := ParsePasslib(s); err != ErrInvalidParams {
+			t.Errorf("excessive memory accepted: %q: %v", s, err)
+		}
+	}
+
+	if _, err := KeyPasslib("password", nil, 20, 4096, 1); err != ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+
+	if _, _, _, _, _, err := ParsePasslib("$scrypt$ln=14,r=1023,p=1$c2FsdHNhbHRzYWx0c2FsdA"); err != nil {
+		t.Errorf("parameters within limit rejected: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package scrypt
+
+import "strconv"
+import "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+import "gopkg.in/hlandau/passlib.v1/registry"
+
+var registryParams = []registry.Param{
+	{Name: "n", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedN), Min: 2, Max: 1 << 30, Doc: "CPU/memory cost (a power of two)"},
+	{Name: "r", Type: registry.Int, Default: strconv.Itoa(raw.Recommendedr), Min: 1, Max: 1<<31 - 1, Doc: "block size"},
+	{Name: "p", Type: registry.Int, Default: strconv.Itoa(raw.Recommendedp), Min: 1, Max: 1<<31 - 1, Doc: "parallelism"},
+}
+
+func nrp(args registry.Args) (N, r, p int, err error) {
+	N, r, p = args.Int("n"), args.Int("r"), args.Int("p")
+	if N&(N-1) != 0 {
+		err = registry.ErrInvalidParam
+	}
+
+	return
+}
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name:   "scrypt-sha256",
+		Doc:    "scrypt in the $s2$ format",
+		Params: registryParams,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			N, r, p, err := nrp(args)
+			if err != nil {
+				return nil, err
+			}
+
+			return NewSHA256(N, r, p), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "scrypt-7",
+		Doc:    "scrypt in the libxcrypt/libsodium $7$ format",
+		Params: registryParams,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			N, r, p, err := nrp(args)
+			if err != nil {
+				return nil, err
+			}
+
+			return New7(N, r, p), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "scrypt",
+		Doc:  "scrypt in Python passlib's $scrypt$ format",
+		Params: []registry.Param{
+			{Name: "ln", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedLogN), Min: 1, Max: 30, Doc: "log2 of the CPU/memory cost"},
+			registryParams[1],
+			registryParams[2],
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewPasslib(args.Int("ln"), args.Int("r"), args.Int("p")), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name: "firebase-scrypt",
+		Doc:  "Firebase Authentication modified scrypt (verify only)",
+		Params: []registry.Param{
+			{Name: "signer_key", Type: registry.String, Required: true, Doc: "base64 signer key of the project"},
+			{Name: "salt_separator", Type: registry.String, Required: true, Doc: "base64 salt separator of the project"},
+			{Name: "rounds", Type: registry.Int, Required: true, Min: 1, Max: 1<<31 - 1, Doc: "rounds of the project"},
+			{Name: "mem_cost", Type: registry.Int, Required: true, Min: 1, Max: 30, Doc: "memory cost of the project"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewFirebase(FirebaseConfig{
+				SignerKey:     args.String("signer_key"),
+				SaltSeparator: args.String("salt_separator"),
+				Rounds:        args.Int("rounds"),
+				MemCost:       args.Int("mem_cost"),
+			})
+		},
+	})
+}
@@ -57,17 +57,32 @@ func Crypt512(password, salt string, rounds int) string {
 	return "$6" + shaCrypt(password, salt, rounds, sha512.New, transpose512)
 }
 
+// Calculates the encoded digest part of a sha256-crypt hash, without the
+// restrictions on salt length and rounds imposed by Crypt256. This is used by
+// derived formats such as MySQL's caching_sha2_password.
+func CryptHash256(password, salt []byte, rounds int) string {
+	return shaCryptHash(password, salt, rounds, sha256.New, transpose256)
+}
+
 func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, transpose func(b []byte)) string {
 	if rounds < MinimumRounds || rounds > MaximumRounds {
 		panic("sha256-crypt rounds must be in 1000 <= rounds <= 999999999")
 	}
 
-	passwordb := []byte(password)
-	saltb := []byte(salt)
-	if len(saltb) > 16 {
+	if len(salt) > 16 {
 		panic("salt must not exceed 16 bytes")
 	}
 
+	hstr := shaCryptHash([]byte(password), []byte(salt), rounds, newHash, transpose)
+
+	if rounds == DefaultRounds {
+		return fmt.Sprintf("$%s$%s", salt, hstr)
+	}
+
+	return fmt.Sprintf("$rounds=%d$%s$%s", rounds, salt, hstr)
+}
+
+func shaCryptHash(passwordb, saltb []byte, rounds int, newHash func() hash.Hash, transpose func(b []byte)) string {
 	// B
 	b := newHash()
 	b.Write(passwordb)
@@ -144,13 +159,7 @@ func shaCrypt(password, salt string, rounds int, newHash func() hash.Hash, trans
 	transpose(cur)
 
 	// Hash
-	hstr := EncodeBase64(cur)
-
-	if rounds == DefaultRounds {
-		return fmt.Sprintf("$%s$%s", salt, hstr)
-	}
-
-	return fmt.Sprintf("$rounds=%d$%s$%s", rounds, salt, hstr)
+	return EncodeBase64(cur)
 }
 
 func repeat(w io.Writer, b []byte, sz int) {
@@ -0,0 +1,29 @@
+package sha2crypt
+
+import "strconv"
+import "gopkg.in/hlandau/passlib.v1/hash/sha2crypt/raw"
+import "gopkg.in/hlandau/passlib.v1/abstract"
+import "gopkg.in/hlandau/passlib.v1/registry"
+
+func init() {
+	params := []registry.Param{
+		{Name: "rounds", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedRounds), Min: raw.MinimumRounds, Max: raw.MaximumRounds, Doc: "number of rounds"},
+	}
+
+	registry.Register(&registry.Factory{
+		Name:   "sha256-crypt",
+		Doc:    "sha256-crypt ($5$)",
+		Params: params,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewCrypter256(args.Int("rounds")), nil
+		},
+	})
+	registry.Register(&registry.Factory{
+		Name:   "sha512-crypt",
+		Doc:    "sha512-crypt ($6$)",
+		Params: params,
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			return NewCrypter512(args.Int("rounds")), nil
+		},
+	})
+}
@@ -0,0 +1,185 @@
+// Package raw provides raw implementations of the encodings used by the
+// Spring Security Pbkdf2PasswordEncoder and SCryptPasswordEncoder, and of
+// the "{id}" prefixes added by DelegatingPasswordEncoder.
+package raw
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	pbkdf2 "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+	scrypt "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+)
+
+// Indicates that a password hash is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid Spring Security password hash")
+
+// Splits a DelegatingPasswordEncoder hash of the form "{id}encoded" into its
+// parts.
+func SplitID(hash string) (id, encoded string, ok bool) {
+	if !strings.HasPrefix(hash, "{") {
+		return "", "", false
+	}
+
+	i := strings.IndexByte(hash, '}')
+	if i < 0 {
+		return "", "", false
+	}
+
+	return hash[1:i], hash[i+1:], true
+}
+
+// The configuration of a Pbkdf2PasswordEncoder. Pbkdf2PasswordEncoder does not
+// record its parameters in the hashes it produces, so the same configuration
+// must be used to verify them.
+type PBKDF2Config struct {
+	// The secret (pepper) appended to the salt. Usually empty.
+	Secret string
+
+	// Salt length in bytes.
+	SaltLength int
+
+	// PBKDF2 iteration count.
+	Iterations int
+
+	// Derived key length in bytes (the hash width in bits divided by 8).
+	KeyLength int
+
+	// The HMAC hash function: "sha1", "sha256" or "sha512".
+	Hash string
+}
+
+// The configuration used by Pbkdf2PasswordEncoder.defaultsForSpringSecurity_v5_8.
+var PBKDF2DefaultsV5_8 = PBKDF2Config{SaltLength: 16, Iterations: 310000, KeyLength: 32, Hash: "sha256"}
+
+// The configuration used by Pbkdf2PasswordEncoder.defaultsForSpringSecurity_v5_5,
+// which is also that of the deprecated no-argument constructor.
+var PBKDF2DefaultsV5_5 = PBKDF2Config{SaltLength: 8, Iterations: 185000, KeyLength: 32, Hash: "sha1"}
+
+func (cfg *PBKDF2Config) hashFunc() (func() hash.Hash, error) {
+	switch cfg.Hash {
+	case "sha1":
+		return sha1.New, nil
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	default:
+		return nil, fmt.Errorf("unsupported Spring PBKDF2 hash function: %q", cfg.Hash)
+	}
+}
+
+// Calculates a Pbkdf2PasswordEncoder hash, which is the hex encoding of the
+// salt followed by PBKDF2(password, salt || secret).
+//
+// salt should be cfg.SaltLength random bytes.
+func PBKDF2(password string, salt []byte, cfg PBKDF2Config) (string, error) {
+	key, err := KeyPBKDF2(password, salt, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(append(append([]byte(nil), salt...), key...)), nil
+}
+
+// Derives the key embedded in a Pbkdf2PasswordEncoder hash for the given
+// salt and configuration.
+func KeyPBKDF2(password string, salt []byte, cfg PBKDF2Config) ([]byte, error) {
+	hf, err := cfg.hashFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	s := append(append([]byte(nil), salt...), cfg.Secret...)
+	return pbkdf2.Key([]byte(password), s, cfg.Iterations, cfg.KeyLength, hf), nil
+}
+
+// Parses a Pbkdf2PasswordEncoder hash produced with the given configuration,
+// returning the salt and derived key.
+func ParsePBKDF2(encoded string, cfg PBKDF2Config) (salt, key []byte, err error) {
+	b, err := hex.DecodeString(encoded)
+	if err != nil || len(b) != cfg.SaltLength+cfg.KeyLength {
+		return nil, nil, ErrInvalidStub
+	}
+
+	return b[0:cfg.SaltLength], b[cfg.SaltLength:], nil
+}
+
+// Calculates an SCryptPasswordEncoder hash.
+//
+// The format is as follows:
+//
+//	$params$salt$key
+//
+// where params is log2(N)<<16 | r<<8 | p in lowercase hex, and salt and key
+// are in padded standard base64.
+func SCrypt(password string, salt []byte, N, r, p, keyLen int) (string, error) {
+	logN := 0
+	for n := N; n > 1; n >>= 1 {
+		logN++
+	}
+
+	if N < 2 || 1<<uint(logN) != N || logN > 0xFFFF || r < 1 || r > 0xFF || p < 1 || p > 0xFF {
+		return "", ErrInvalidStub
+	}
+
+	key, err := KeySCrypt(password, salt, N, r, p, keyLen)
+	if err != nil {
+		return "", err
+	}
+
+	params := uint64(logN)<<16 | uint64(r)<<8 | uint64(p)
+	return "$" + strconv.FormatUint(params, 16) + "$" + base64.StdEncoding.EncodeToString(salt) + "$" + base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Derives the key embedded in an SCryptPasswordEncoder hash. As for other
+// scrypt formats, the parameters must not require more than scrypt.MaxMemory.
+func KeySCrypt(password string, salt []byte, N, r, p, keyLen int) ([]byte, error) {
+	return scrypt.Key([]byte(password), salt, N, r, p, keyLen)
+}
+
+// Parses an SCryptPasswordEncoder hash. scrypt.ErrInvalidParams is returned if
+// the parameters require more than scrypt.MaxMemory.
+func ParseSCrypt(encoded string) (salt, key []byte, N, r, p int, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "" {
+		err = ErrInvalidStub
+		return
+	}
+
+	params, err := strconv.ParseUint(parts[1], 16, 32)
+	if err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	logN := int(params >> 16 & 0xFFFF)
+	r, p = int(params>>8&0xFF), int(params&0xFF)
+	if logN < 1 || logN > 30 || r < 1 || p < 1 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if err = scrypt.CheckParams(1<<uint(logN), r, p); err != nil {
+		return
+	}
+
+	if salt, err = base64.StdEncoding.DecodeString(parts[2]); err != nil {
+		err = ErrInvalidStub
+		return
+	}
+
+	if key, err = base64.StdEncoding.DecodeString(parts[3]); err != nil || len(key) == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	return salt, key, 1 << uint(logN), r, p, nil
+}
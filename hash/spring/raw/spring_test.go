@@ -0,0 +1,74 @@
+package raw
+
+import (
+	"testing"
+
+	scrypt "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+)
+
+var salt = []byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Generated using Python's hashlib module.
+func TestPBKDF2(t *testing.T) {
+	for _, tst := range []struct {
+		cfg  PBKDF2Config
+		hash string
+	}{
+		{PBKDF2DefaultsV5_8, "000102030405060708090a0b0c0d0e0fe0f65a4bf6716253d2d10a7a4b18f35cd4baf31ff031a187cd0091674905482d"},
+		{PBKDF2DefaultsV5_5, "0001020304050607c18c42a363543f68645d57859f952d9e5b74a5302ef4c2199584b3b948016b76"},
+		{PBKDF2Config{Secret: "secret", SaltLength: 16, Iterations: 310000, KeyLength: 32, Hash: "sha256"}, "000102030405060708090a0b0c0d0e0f46becad51b80f8da45b017d01805c813d42a4f3ece990bbfa45ee0fce32440a5"},
+	} {
+		h, err := PBKDF2("password", salt[0:tst.cfg.SaltLength], tst.cfg)
+		if err != nil || h != tst.hash {
+			t.Errorf("mismatch: got %q (%v), expected %q", h, err, tst.hash)
+		}
+
+		s, key, err := ParsePBKDF2(h, tst.cfg)
+		if err != nil || len(s) != tst.cfg.SaltLength || len(key) != tst.cfg.KeyLength {
+			t.Errorf("cannot parse: %v", err)
+		}
+	}
+
+	if _, _, err := ParsePBKDF2("0001020304050607c18c42a363543f68645d57859f952d9e5b74a5302ef4c2199584b3b948016b76", PBKDF2DefaultsV5_8); err == nil {
+		t.Errorf("hash with wrong length accepted")
+	}
+}
+
+func TestSCrypt(t *testing.T) {
+	const expected = "$e0801$AAECAwQFBgcICQoLDA0ODw==$6iMJXpgeItuXSS3ial5ceU6o+LQA0aKIA8ORmTlhNMU="
+	h, err := SCrypt("password", salt, 16384, 8, 1, 32)
+	if err != nil || h != expected {
+		t.Errorf("mismatch: got %q (%v), expected %q", h, err, expected)
+	}
+
+	s, key, N, r, p, err := ParseSCrypt(h)
+	if err != nil || string(s) != string(salt) || len(key) != 32 || N != 16384 || r != 8 || p != 1 {
+		t.Errorf("cannot parse: %v", err)
+	}
+
+	for _, bad := range []string{"e0801$AAEC$AAEC", "$e0801$AAEC", "$zz$AAEC$AAEC", "$801$AAEC$AAEC", "$e0801$AAEC$"} {
+		if _, _, _, _, _, err := ParseSCrypt(bad); err == nil {
+			t.Errorf("invalid hash accepted: %q", bad)
+		}
+	}
+
+	// N = 2^20, r = 255: 32 GiB.
+	if _, _, _, _, _, err := ParseSCrypt("$14ff01$AAEC$AAEC"); err != scrypt.ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+
+	if _, err := KeySCrypt("password", salt, 1<<20, 255, 1, 32); err != scrypt.ErrInvalidParams {
+		t.Errorf("excessive memory accepted: %v", err)
+	}
+}
+
+func TestSplitID(t *testing.T) {
+	id, encoded, ok := SplitID("{bcrypt}$2a$10$x")
+	if !ok || id != "bcrypt" || encoded != "$2a$10$x" {
+		t.Errorf("unexpected result: %q %q %v", id, encoded, ok)
+	}
+
+	if _, _, ok := SplitID("$2a$10$x"); ok {
+		t.Errorf("unexpected result for unprefixed hash")
+	}
+}
@@ -0,0 +1,26 @@
+package spring
+
+import (
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/spring/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name: "spring",
+		Doc:  "Spring Security DelegatingPasswordEncoder",
+		Params: []registry.Param{
+			{Name: "id", Type: registry.String, Default: "bcrypt", Choices: []string{"bcrypt", "pbkdf2", "scrypt", "argon2"}, Doc: "encoder used for new hashes"},
+			{Name: "pbkdf2", Type: registry.String, Default: "v5.8", Choices: []string{"v5.8", "v5.5"}, Doc: "Pbkdf2PasswordEncoder defaults"},
+		},
+		New: func(args registry.Args) (abstract.Scheme, error) {
+			cfg := raw.PBKDF2DefaultsV5_8
+			if args.String("pbkdf2") == "v5.5" {
+				cfg = raw.PBKDF2DefaultsV5_5
+			}
+
+			return New(args.String("id"), cfg)
+		},
+	})
+}
@@ -0,0 +1,196 @@
+// Package spring implements the password hash formats produced by the Spring
+// Security DelegatingPasswordEncoder, which prefixes each hash with the ID of
+// the encoder which produced it:
+//
+//	{bcrypt}$2a$10$...
+//	{pbkdf2}hexsaltandkey
+//	{scrypt}$params$salt$key
+//	{argon2}$argon2id$v=19$m=...,t=...,p=...$salt$key
+//	{noop}plaintext
+//
+// Hashes with the {noop} prefix are verified, but always need an update.
+package spring
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	argon2 "gopkg.in/hlandau/passlib.v1/hash/argon2/raw"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	scrypt "gopkg.in/hlandau/passlib.v1/hash/scrypt/raw"
+	"gopkg.in/hlandau/passlib.v1/hash/spring/raw"
+)
+
+// An implementation of Scheme producing {bcrypt} hashes, which is the
+// DelegatingPasswordEncoder default. {pbkdf2} hashes are verified using
+// raw.PBKDF2DefaultsV5_8.
+var Crypter abstract.Scheme
+
+const saltLength = 16
+
+func init() {
+	Crypter = &scheme{id: "bcrypt", pbkdf2: raw.PBKDF2DefaultsV5_8}
+}
+
+// Returns an implementation of Scheme producing hashes using the encoder with
+// the given ID, which must be "bcrypt", "pbkdf2", "scrypt" or "argon2". Hashes
+// produced by the other encoders are verified, but need an update.
+//
+// Pbkdf2PasswordEncoder does not record its parameters in its hashes, so the
+// configuration used to produce {pbkdf2} hashes must be specified. Its salt
+// length, iteration count and key length must be positive.
+func New(id string, pbkdf2 raw.PBKDF2Config) (abstract.Scheme, error) {
+	switch id {
+	case "bcrypt", "pbkdf2", "scrypt", "argon2":
+	default:
+		return nil, fmt.Errorf("unsupported Spring Security encoder: %q", id)
+	}
+
+	if pbkdf2.SaltLength <= 0 || pbkdf2.Iterations <= 0 || pbkdf2.KeyLength <= 0 {
+		return nil, fmt.Errorf("invalid Spring Security PBKDF2 configuration: %+v", pbkdf2)
+	}
+
+	return &scheme{
+		id:     id,
+		pbkdf2: pbkdf2,
+	}, nil
+}
+
+type scheme struct {
+	id     string
+	pbkdf2 raw.PBKDF2Config
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	id, _, ok := raw.SplitID(stub)
+	switch id {
+	case "bcrypt", "pbkdf2", "scrypt", "argon2", "noop":
+		return ok
+	default:
+		return false
+	}
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	n := saltLength
+	if c.id == "pbkdf2" {
+		n = c.pbkdf2.SaltLength
+	}
+
+	salt := make([]byte, n)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	var h string
+	switch c.id {
+	case "bcrypt":
+		h, err = bcrypt.Crypter.Hash(password)
+	case "pbkdf2":
+		h, err = raw.PBKDF2(password, salt, c.pbkdf2)
+	case "scrypt":
+		h, err = raw.SCrypt(password, salt, scrypt.RecommendedN, scrypt.Recommendedr, scrypt.Recommendedp, 32)
+	case "argon2":
+		h = argon2.Argon2ID(password, salt, argon2.RecommendedTime, argon2.RecommendedMemory, argon2.RecommendedThreads)
+	default:
+		err = fmt.Errorf("unsupported Spring Security encoder: %q", c.id)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return "{" + c.id + "}" + h, nil
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	id, encoded, ok := raw.SplitID(hash)
+	if !ok {
+		return raw.ErrInvalidStub
+	}
+
+	var key, newKey []byte
+	switch id {
+	case "bcrypt":
+		if !bcrypt.Crypter.SupportsStub(encoded) {
+			return raw.ErrInvalidStub
+		}
+
+		return bcrypt.Crypter.Verify(password, encoded)
+
+	case "pbkdf2":
+		salt, k, err := raw.ParsePBKDF2(encoded, c.pbkdf2)
+		if err != nil {
+			return err
+		}
+
+		key = k
+		if newKey, err = raw.KeyPBKDF2(password, salt, c.pbkdf2); err != nil {
+			return err
+		}
+
+	case "scrypt":
+		salt, k, N, r, p, err := raw.ParseSCrypt(encoded)
+		if err != nil {
+			return err
+		}
+
+		key = k
+		if newKey, err = raw.KeySCrypt(password, salt, N, r, p, len(k)); err != nil {
+			return err
+		}
+
+	case "argon2":
+		variant, salt, k, _, time, memory, threads, err := argon2.ParseVariant(encoded)
+		if err != nil {
+			return err
+		}
+
+		key = k
+		if newKey, err = argon2.Key(variant, []byte(password), salt, time, memory, threads, uint32(len(k))); err != nil {
+			return err
+		}
+
+	case "noop":
+		key, newKey = []byte(encoded), []byte(password)
+
+	default:
+		return abstract.ErrUnsupportedScheme
+	}
+
+	if len(key) == 0 || !abstract.SecureCompare(string(key), string(newKey)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	id, encoded, ok := raw.SplitID(stub)
+	if !ok {
+		return false // ...
+	}
+
+	if id != c.id {
+		return true
+	}
+
+	switch id {
+	case "bcrypt":
+		return bcrypt.Crypter.NeedsUpdate(encoded)
+	case "scrypt":
+		_, _, N, r, p, err := raw.ParseSCrypt(encoded)
+		return err == nil && (N < scrypt.RecommendedN || r < scrypt.Recommendedr || p < scrypt.Recommendedp)
+	case "argon2":
+		variant, _, _, _, time, memory, threads, err := argon2.ParseVariant(encoded)
+		return err == nil && (variant != "argon2id" || time < argon2.RecommendedTime ||
+			memory < argon2.RecommendedMemory || threads < argon2.RecommendedThreads)
+	default:
+		return false
+	}
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("spring(%s)", c.id)
+}
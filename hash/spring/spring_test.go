@@ -0,0 +1,105 @@
+package spring
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/spring/raw"
+)
+
+// Examples for "password" from the Spring Security documentation.
+var docHashes = []string{
+	"{bcrypt}$2a$10$dXJ3SW6G7P50lGmMkkmwe.20cQQubK3.HZWzG3YB1tlRy.fqvM/BG",
+	"{noop}password",
+	"{scrypt}$e0801$8bWJaSu2IKSn9Z9kM+TPXfOc/9bdYSrN1oD9qfVThWEwdRTnO7re7Ei+fUZRJ68k9lTyuTeUp4of4g24hHnazw==$OAOec05+bXxvuu/1qZ6NUR+xQYvYv7BeL1QxwRpY5Pc=",
+}
+
+func TestVerify(t *testing.T) {
+	c, err := New("bcrypt", raw.PBKDF2DefaultsV5_5)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	hashes := append(docHashes, "{pbkdf2}5d923b44a6d129f3ddf3e3c8d29412723dcbde72445e8ef6bf3b508fbf17fa4ed4d6b99ca763d8dc")
+
+	for _, h := range hashes {
+		if !c.SupportsStub(h) {
+			t.Errorf("stub not supported: %q", h)
+		}
+
+		if err := c.Verify("password", h); err != nil {
+			t.Errorf("err verifying known good hash: %v (%q)", err, h)
+		}
+
+		if err := c.Verify("passwore", h); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v (%q)", err, h)
+		}
+	}
+
+	if !c.NeedsUpdate("{noop}password") || !c.NeedsUpdate(docHashes[2]) {
+		t.Errorf("expected update for non-bcrypt hash")
+	}
+
+	if err := c.Verify("password", "{sha256}97cde38028ad898ebc02e690819fa220e88c62e0699403e94fff291cfffaf8410849f27605abcbc0"); err != abstract.ErrUnsupportedScheme {
+		t.Errorf("unexpected result for unsupported encoder: %v", err)
+	}
+
+	if err := c.Verify("", "{noop}"); err != abstract.ErrInvalidPassword {
+		t.Errorf("empty noop password accepted: %v", err)
+	}
+}
+
+func TestHash(t *testing.T) {
+	for _, id := range []string{"bcrypt", "pbkdf2", "scrypt", "argon2"} {
+		c, err := New(id, raw.PBKDF2Config{SaltLength: 16, Iterations: 1000, KeyLength: 32, Hash: "sha256"})
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		h, err := c.Hash("password")
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if !c.SupportsStub(h) {
+			t.Errorf("stub not supported: %q", h)
+		}
+
+		if err := c.Verify("password", h); err != nil || c.NeedsUpdate(h) {
+			t.Errorf("cannot verify new hash: %v (%q)", err, h)
+		}
+	}
+
+	for _, id := range []string{"", "noop", "md5", "BCRYPT"} {
+		if _, err := New(id, raw.PBKDF2DefaultsV5_8); err == nil {
+			t.Errorf("unsupported encoder accepted: %q", id)
+		}
+	}
+}
+
+func TestPBKDF2SaltLength(t *testing.T) {
+	c, err := New("pbkdf2", raw.PBKDF2Config{SaltLength: 32, Iterations: 1000, KeyLength: 32, Hash: "sha256"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	h, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// {pbkdf2} followed by the hex salt and key.
+	if len(h) != 8+2*(32+32) {
+		t.Errorf("unexpected hash length: %q", h)
+	}
+
+	if err := c.Verify("password", h); err != nil {
+		t.Errorf("cannot verify new hash: %v (%q)", err, h)
+	}
+
+	for _, n := range []int{0, -1} {
+		if _, err := New("pbkdf2", raw.PBKDF2Config{SaltLength: n, Iterations: 1000, KeyLength: 32, Hash: "sha256"}); err == nil {
+			t.Errorf("salt length %d accepted", n)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package srp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"gopkg.in/hlandau/passlib.v1/hash/srp/raw"
+)
+
+// Indicates that the server proof M2 did not match, meaning that the server
+// does not possess the verifier.
+var ErrInvalidServerProof = fmt.Errorf("invalid SRP server proof")
+
+// The client side of an SRP-6a handshake. This is primarily useful for
+// testing and for tools which need to authenticate against a Server.
+type Client struct {
+	identity, password string
+	g                  *raw.Group
+	hf                 func() hash.Hash
+
+	a, A    *big.Int
+	m1, key []byte
+	state   int
+}
+
+const (
+	clientStateInitial = iota
+	clientStateStarted
+	clientStateProved
+	clientStateDone
+	clientStateFailed
+)
+
+// Creates a client which authenticates as the given identity using the given
+// plaintext password. The group size and hash function must match those used
+// by the server.
+func NewClient(identity, password string, groupBits int, hashName string) (*Client, error) {
+	g, ok := raw.GroupByBits(groupBits)
+	if !ok {
+		return nil, raw.ErrInvalidParams
+	}
+
+	hf, ok := raw.HashFunc(hashName)
+	if !ok {
+		return nil, raw.ErrInvalidParams
+	}
+
+	return &Client{
+		identity: identity,
+		password: password,
+		g:        g,
+		hf:       hf,
+	}, nil
+}
+
+// Generates the client's ephemeral values and returns the client public
+// value A to send to the server.
+func (c *Client) Public() (A []byte, err error) {
+	if c.state != clientStateInitial {
+		return nil, ErrOutOfOrder
+	}
+
+	c.a, err = raw.GeneratePrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	c.A = raw.ClientPublic(c.g, c.a)
+	c.state = clientStateStarted
+	return c.A.Bytes(), nil
+}
+
+// Processes the salt and server public value B received from the server and
+// returns the client proof M1 to send to the server.
+func (c *Client) Proof(salt, B []byte) (M1 []byte, err error) {
+	if c.state != clientStateStarted {
+		return nil, ErrOutOfOrder
+	}
+
+	c.state = clientStateFailed
+
+	b := new(big.Int).SetBytes(B)
+	if err := raw.CheckPublic(c.g, b); err != nil {
+		return nil, err
+	}
+
+	u := raw.U(c.g, c.hf, c.A, b)
+	if u.Sign() == 0 {
+		return nil, raw.ErrInvalidPublicValue
+	}
+
+	x := raw.X(c.hf, salt, c.identity, c.password)
+	c.key = raw.SessionKey(c.hf, raw.ClientPremaster(c.g, c.hf, b, x, c.a, u))
+	c.m1 = raw.ClientProof(c.g, c.hf, c.identity, salt, c.A, b, c.key)
+	c.state = clientStateProved
+	return c.m1, nil
+}
+
+// Checks the server proof M2 received from the server.
+func (c *Client) VerifyServer(M2 []byte) error {
+	if c.state != clientStateProved {
+		return ErrOutOfOrder
+	}
+
+	if subtle.ConstantTimeCompare(M2, raw.ServerProof(c.hf, c.A, c.m1, c.key)) != 1 {
+		c.state = clientStateFailed
+		return ErrInvalidServerProof
+	}
+
+	c.state = clientStateDone
+	return nil
+}
+
+// Returns true if the server has been successfully authenticated.
+func (c *Client) Done() bool {
+	return c.state == clientStateDone
+}
+
+// Returns the shared session key K. Returns nil until Proof has succeeded.
+func (c *Client) Key() []byte {
+	return c.key
+}
@@ -0,0 +1,100 @@
+package raw
+
+import (
+	"fmt"
+	"math/big"
+
+	"gopkg.in/hlandau/passlib.v1/phc"
+)
+
+// The recommended group size in bits.
+const RecommendedGroupBits = 3072
+
+// The recommended hash function.
+const RecommendedHash = "sha256"
+
+// The length of the salt generated for new verifiers, in bytes.
+const SaltLength = 16
+
+// Indicates that a stored SRP verifier is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid SRP verifier string")
+
+// Indicates that an unknown group size or hash function was specified.
+var ErrInvalidParams = fmt.Errorf("invalid SRP parameters")
+
+// Calculates an SRP-6a verifier and encodes it as a $srp6a$ string.
+//
+// identity is the SRP username I and password should be a UTF-8 plaintext
+// password. salt should be a random salt value in binary form. groupBits
+// selects one of the RFC 5054 groups and hashName is one of "sha1", "sha256"
+// or "sha512".
+func Crypt(identity, password string, salt []byte, groupBits int, hashName string) (string, error) {
+	g, ok := GroupByBits(groupBits)
+	if !ok {
+		return "", ErrInvalidParams
+	}
+
+	hf, ok := HashFunc(hashName)
+	if !ok {
+		return "", ErrInvalidParams
+	}
+
+	v := Verifier(g, hf, salt, identity, password)
+
+	h := phc.Hash{ID: "srp6a", Salt: salt, Hash: g.pad(v)}
+	h.AddParamUint("g", uint64(groupBits))
+	h.Params = append(h.Params, phc.Param{Name: "h", Value: hashName})
+
+	return h.String(), nil
+}
+
+// Parses a $srp6a$ verifier string.
+//
+// The format is as follows:
+//
+//	$srp6a$g=groupBits,h=hashName$salt$verifier
+//
+// This is a PHC string; see package phc. The verifier is stored as a
+// big-endian integer padded to the length of the group modulus.
+func Parse(stub string) (g *Group, hashName string, salt []byte, v *big.Int, err error) {
+	h, err := phc.Parse(stub)
+	if err != nil {
+		return
+	}
+
+	if h.ID != "srp6a" || h.HasVersion || h.Salt == nil || h.Hash == nil || !h.HasOnlyParams("g", "h") {
+		err = ErrInvalidStub
+		return
+	}
+
+	bits, err := h.ParamUint("g", 16)
+	if err != nil {
+		return
+	}
+
+	hashName, _ = h.Param("h")
+
+	var ok bool
+	if g, ok = GroupByBits(int(bits)); !ok {
+		err = ErrInvalidParams
+		return
+	}
+
+	if _, ok = HashFunc(hashName); !ok {
+		err = ErrInvalidParams
+		return
+	}
+
+	if len(h.Hash) != (g.N.BitLen()+7)/8 {
+		err = ErrInvalidStub
+		return
+	}
+
+	v = new(big.Int).SetBytes(h.Hash)
+	if v.Sign() == 0 || v.Cmp(g.N) >= 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	return g, hashName, h.Salt, v, nil
+}
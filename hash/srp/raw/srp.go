@@ -0,0 +1,176 @@
+// Package raw provides a raw implementation of the SRP-6a protocol (RFC 2945,
+// RFC 5054).
+//
+// Values are computed as follows, where H is the chosen hash function, | is
+// concatenation and PAD pads a value with leading zeroes to the length of N:
+//
+//	x  = H(s | H(I | ":" | P))
+//	v  = g^x % N
+//	k  = H(N | PAD(g))
+//	A  = g^a % N
+//	B  = (k*v + g^b) % N
+//	u  = H(PAD(A) | PAD(B))
+//	S  = (B - k*g^x)^(a + u*x) % N     // client
+//	S  = (A * v^u)^b % N               // server
+//	K  = H(S)
+//	M1 = H(H(N) XOR H(g) | H(I) | s | A | B | K)
+//	M2 = H(A | M1 | K)
+//
+// Other than where padding is noted, integers are encoded as big-endian byte
+// strings without leading zeroes.
+package raw
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"math/big"
+)
+
+// An SRP group.
+type Group struct {
+	Bits int
+	N    *big.Int
+	G    *big.Int
+}
+
+// Indicates that a public value received from the peer is invalid.
+var ErrInvalidPublicValue = fmt.Errorf("invalid SRP public value")
+
+var hashFuncs = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// Returns the hash function with the given name, which is one of "sha1",
+// "sha256" or "sha512".
+func HashFunc(name string) (func() hash.Hash, bool) {
+	hf, ok := hashFuncs[name]
+	return hf, ok
+}
+
+func hashBytes(hf func() hash.Hash, parts ...[]byte) []byte {
+	h := hf()
+	for _, p := range parts {
+		h.Write(p)
+	}
+
+	return h.Sum(nil)
+}
+
+func hashInt(hf func() hash.Hash, parts ...[]byte) *big.Int {
+	return new(big.Int).SetBytes(hashBytes(hf, parts...))
+}
+
+// Pads a value to the length of N.
+func (g *Group) pad(x *big.Int) []byte {
+	b := x.Bytes()
+	n := (g.N.BitLen() + 7) / 8
+	if len(b) >= n {
+		return b
+	}
+
+	out := make([]byte, n)
+	copy(out[n-len(b):], b)
+	return out
+}
+
+// Calculates the private key x.
+func X(hf func() hash.Hash, salt []byte, identity, password string) *big.Int {
+	return hashInt(hf, salt, hashBytes(hf, []byte(identity+":"+password)))
+}
+
+// Calculates the verifier v.
+func Verifier(g *Group, hf func() hash.Hash, salt []byte, identity, password string) *big.Int {
+	return new(big.Int).Exp(g.G, X(hf, salt, identity, password), g.N)
+}
+
+// Calculates the multiplier parameter k.
+func K(g *Group, hf func() hash.Hash) *big.Int {
+	return hashInt(hf, g.N.Bytes(), g.pad(g.G))
+}
+
+// Calculates the scrambling parameter u.
+func U(g *Group, hf func() hash.Hash, A, B *big.Int) *big.Int {
+	return hashInt(hf, g.pad(A), g.pad(B))
+}
+
+// Generates a random private value of 256 bits.
+func GeneratePrivate() (*big.Int, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(buf), nil
+}
+
+// Calculates the client public value A from the private value a.
+func ClientPublic(g *Group, a *big.Int) *big.Int {
+	return new(big.Int).Exp(g.G, a, g.N)
+}
+
+// Calculates the server public value B from the verifier and the private
+// value b.
+func ServerPublic(g *Group, hf func() hash.Hash, v, b *big.Int) *big.Int {
+	B := new(big.Int).Mul(K(g, hf), v)
+	B.Add(B, new(big.Int).Exp(g.G, b, g.N))
+	return B.Mod(B, g.N)
+}
+
+// Checks that a public value received from the peer is valid, i.e. that it is
+// not zero modulo N.
+func CheckPublic(g *Group, X *big.Int) error {
+	if X.Sign() <= 0 || new(big.Int).Mod(X, g.N).Sign() == 0 {
+		return ErrInvalidPublicValue
+	}
+
+	return nil
+}
+
+// Calculates the premaster secret S on the client.
+func ClientPremaster(g *Group, hf func() hash.Hash, B, x, a, u *big.Int) *big.Int {
+	t := new(big.Int).Exp(g.G, x, g.N)
+	t.Mul(t, K(g, hf))
+	t.Sub(B, t)
+	t.Mod(t, g.N)
+
+	e := new(big.Int).Mul(u, x)
+	e.Add(e, a)
+
+	return t.Exp(t, e, g.N)
+}
+
+// Calculates the premaster secret S on the server.
+func ServerPremaster(g *Group, A, v, u, b *big.Int) *big.Int {
+	t := new(big.Int).Exp(v, u, g.N)
+	t.Mul(t, A)
+	t.Mod(t, g.N)
+	return t.Exp(t, b, g.N)
+}
+
+// Calculates the session key K = H(S).
+func SessionKey(hf func() hash.Hash, S *big.Int) []byte {
+	return hashBytes(hf, S.Bytes())
+}
+
+// Calculates the client evidence message M1.
+func ClientProof(g *Group, hf func() hash.Hash, identity string, salt []byte, A, B *big.Int, key []byte) []byte {
+	hN := hashBytes(hf, g.N.Bytes())
+	hg := hashBytes(hf, g.G.Bytes())
+	for i := range hN {
+		hN[i] ^= hg[i]
+	}
+
+	return hashBytes(hf, hN, hashBytes(hf, []byte(identity)), salt, A.Bytes(), B.Bytes(), key)
+}
+
+// Calculates the server evidence message M2.
+func ServerProof(hf func() hash.Hash, A *big.Int, m1, key []byte) []byte {
+	return hashBytes(hf, A.Bytes(), m1, key)
+}
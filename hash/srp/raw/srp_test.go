@@ -0,0 +1,102 @@
+package raw
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"math/big"
+	"testing"
+)
+
+func hexInt(s string) *big.Int {
+	x, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("bad hex")
+	}
+	return x
+}
+
+// Test vectors from RFC 5054 appendix B.
+func TestRFC5054(t *testing.T) {
+	g := Group1024
+	hf := sha1.New
+	identity, password := "alice", "password123"
+	salt := hexInt("BEB25379D1A8581EB5A727673A2441EE").Bytes()
+
+	k := hexInt("7556AA045AEF2CDD07ABAF0F665C3E818913186F")
+	x := hexInt("94B7555AABE9127CC58CCF4993DB6CF84D16C124")
+	v := hexInt("7E273DE8696FFC4F4E337D05B4B375BEB0DDE1569E8FA00A9886D8129BADA1F1822223CA1A605B530E379BA4729FDC59F105B4787E5186F5C671085A1447B52A48CF1970B4FB6F8400BBF4CEBFBB168152E08AB5EA53D15C1AFF87B2B9DA6E04E058AD51CC72BFC9033B564E26480D78E955A5E29E7AB245DB2BE315E2099AFB")
+	a := hexInt("60975527035CF2AD1989806F0407210BC81EDC04E2762A56AFD529DDDA2D4393")
+	b := hexInt("E487CB59D31AC550471E81F00F6928E01DDA08E974A004F49E61F5D105284D20")
+	A := hexInt("61D5E490F6F1B79547B0704C436F523DD0E560F0C64115BB72557EC44352E8903211C04692272D8B2D1A5358A2CF1B6E0BFCF99F921530EC8E39356179EAE45E42BA92AEACED825171E1E8B9AF6D9C03E1327F44BE087EF06530E69F66615261EEF54073CA11CF5858F0EDFDFE15EFEAB349EF5D76988A3672FAC47B0769447B")
+	B := hexInt("BD0C61512C692C0CB6D041FA01BB152D4916A1E77AF46AE105393011BAF38964DC46A0670DD125B95A981652236F99D9B681CBF87837EC996C6DA04453728610D0C6DDB58B318885D7D82C7F8DEB75CE7BD4FBAA37089E6F9C6059F388838E7A00030B331EB76840910440B1B27AAEAEEB4012B7D7665238A8E3FB004B117B58")
+	u := hexInt("CE38B9593487DA98554ED47D70A7AE5F462EF019")
+	S := hexInt("B0DC82BABCF30674AE450C0287745E7990A3381F63B387AAF271A10D233861E359B48220F7C4693C9AE12B0A6F67809F0876E2D013800D6C41BB59B6D5979B5C00A172B4A2A5903A0BDCAF8A709585EB2AFAFA8F3499B200210DCC1F10EB33943CD67FC88A2F39A4BE5BEC4EC0A3212DC346D7E474B29EDE8A469FFECA686E5A")
+
+	check := func(name string, got, expected *big.Int) {
+		if got.Cmp(expected) != 0 {
+			t.Errorf("%s mismatch: got %X, expected %X", name, got, expected)
+		}
+	}
+
+	check("k", K(g, hf), k)
+	check("x", X(hf, salt, identity, password), x)
+	check("v", Verifier(g, hf, salt, identity, password), v)
+	check("A", ClientPublic(g, a), A)
+	check("B", ServerPublic(g, hf, v, b), B)
+	check("u", U(g, hf, A, B), u)
+	check("S (client)", ClientPremaster(g, hf, B, x, a, u), S)
+	check("S (server)", ServerPremaster(g, A, v, u, b), S)
+}
+
+func TestGroups(t *testing.T) {
+	for _, bits := range []int{1024, 1536, 2048, 3072, 4096, 6144, 8192} {
+		g, ok := GroupByBits(bits)
+		if !ok {
+			t.Fatalf("missing group %d", bits)
+		}
+
+		if g.N.BitLen() != bits {
+			t.Errorf("group %d has modulus of %d bits", bits, g.N.BitLen())
+		}
+
+		if !g.N.ProbablyPrime(0) {
+			t.Errorf("group %d modulus is not prime", bits)
+		}
+	}
+
+	if _, ok := GroupByBits(1000); ok {
+		t.Errorf("unexpected group")
+	}
+}
+
+func TestProofs(t *testing.T) {
+	g := Group2048
+	hf := sha1.New
+	salt := []byte("salt")
+	v := Verifier(g, hf, salt, "bob", "hunter2")
+
+	a, _ := GeneratePrivate()
+	b, _ := GeneratePrivate()
+	A := ClientPublic(g, a)
+	B := ServerPublic(g, hf, v, b)
+	u := U(g, hf, A, B)
+
+	ck := SessionKey(hf, ClientPremaster(g, hf, B, X(hf, salt, "bob", "hunter2"), a, u))
+	sk := SessionKey(hf, ServerPremaster(g, A, v, u, b))
+	if !bytes.Equal(ck, sk) {
+		t.Fatalf("session key mismatch")
+	}
+
+	m1 := ClientProof(g, hf, "bob", salt, A, B, ck)
+	if !bytes.Equal(m1, ClientProof(g, hf, "bob", salt, A, B, sk)) {
+		t.Fatalf("client proof mismatch")
+	}
+
+	if len(ServerProof(hf, A, m1, sk)) != sha1.Size {
+		t.Fatalf("bad server proof length")
+	}
+
+	if CheckPublic(g, new(big.Int)) == nil || CheckPublic(g, g.N) == nil {
+		t.Fatalf("zero public value accepted")
+	}
+}
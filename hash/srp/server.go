@@ -0,0 +1,129 @@
+package srp
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/srp/raw"
+)
+
+// Indicates that the steps of a handshake were performed out of order, or
+// that a handshake object was reused.
+var ErrOutOfOrder = fmt.Errorf("SRP handshake step performed out of order")
+
+// The server side of an SRP-6a handshake.
+//
+// The server sends the salt and its public value B, as returned by Challenge,
+// to the client. The client replies with its public value A and its proof M1,
+// which are passed to Verify. If Verify succeeds, the client has proven
+// knowledge of the password and the returned proof M2 should be sent to the
+// client so that it can authenticate the server in turn.
+//
+// A Server must not be reused for more than one handshake.
+type Server struct {
+	identity string
+	g        *raw.Group
+	hashName string
+	hf       func() hash.Hash
+	salt     []byte
+	v        *big.Int
+
+	b, B  *big.Int
+	key   []byte
+	state int
+}
+
+const (
+	serverStateInitial = iota
+	serverStateChallenged
+	serverStateDone
+	serverStateFailed
+)
+
+// Creates a server which authenticates the user with the given SRP identity
+// against a stored $srp6a$ verifier.
+func NewServer(identity, verifier string) (*Server, error) {
+	g, hashName, salt, v, err := raw.Parse(verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	hf, _ := raw.HashFunc(hashName)
+	return &Server{
+		identity: identity,
+		g:        g,
+		hashName: hashName,
+		hf:       hf,
+		salt:     salt,
+		v:        v,
+	}, nil
+}
+
+// Returns the group size and hash function used by the stored verifier. The
+// client must use the same parameters.
+func (s *Server) Params() (groupBits int, hashName string) {
+	return s.g.Bits, s.hashName
+}
+
+// Generates the server's ephemeral values and returns the salt and the
+// server public value B to send to the client.
+func (s *Server) Challenge() (salt, B []byte, err error) {
+	if s.state != serverStateInitial {
+		return nil, nil, ErrOutOfOrder
+	}
+
+	s.b, err = raw.GeneratePrivate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.B = raw.ServerPublic(s.g, s.hf, s.v, s.b)
+	s.state = serverStateChallenged
+	return s.salt, s.B.Bytes(), nil
+}
+
+// Checks the client public value A and the client proof M1. If the client
+// has proven knowledge of the password, returns the server proof M2 to send
+// to the client. Otherwise, returns abstract.ErrInvalidPassword or
+// raw.ErrInvalidPublicValue.
+func (s *Server) Verify(A, M1 []byte) (M2 []byte, err error) {
+	if s.state != serverStateChallenged {
+		return nil, ErrOutOfOrder
+	}
+
+	s.state = serverStateFailed
+
+	a := new(big.Int).SetBytes(A)
+	if err := raw.CheckPublic(s.g, a); err != nil {
+		return nil, err
+	}
+
+	u := raw.U(s.g, s.hf, a, s.B)
+	if u.Sign() == 0 {
+		return nil, raw.ErrInvalidPublicValue
+	}
+
+	key := raw.SessionKey(s.hf, raw.ServerPremaster(s.g, a, s.v, u, s.b))
+	expected := raw.ClientProof(s.g, s.hf, s.identity, s.salt, a, s.B, key)
+	if subtle.ConstantTimeCompare(M1, expected) != 1 {
+		return nil, abstract.ErrInvalidPassword
+	}
+
+	s.key = key
+	s.state = serverStateDone
+	return raw.ServerProof(s.hf, a, M1, key), nil
+}
+
+// Returns true if the client has been successfully authenticated.
+func (s *Server) Done() bool {
+	return s.state == serverStateDone
+}
+
+// Returns the shared session key K. Returns nil unless the handshake has
+// succeeded.
+func (s *Server) Key() []byte {
+	return s.key
+}
@@ -0,0 +1,86 @@
+// Package srp implements SRP-6a (RFC 2945, RFC 5054) password verifiers,
+// together with the server side of the SRP handshake, which authenticates
+// clients against such verifiers without receiving the plaintext password.
+//
+// Because an SRP verifier incorporates the username, a scheme must be
+// constructed for each user using New. Plaintext verification is supported
+// so that verifiers can be checked and upgraded using the ordinary Context
+// machinery while migrating.
+package srp
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/srp/raw"
+)
+
+// Returns an implementation of Scheme producing $srp6a$ verifiers for the
+// user with the given SRP identity, using the RFC 5054 group with the given
+// size in bits and the named hash function ("sha1", "sha256" or "sha512").
+//
+// raw.RecommendedGroupBits and raw.RecommendedHash are recommended.
+func New(identity string, groupBits int, hashName string) abstract.Scheme {
+	return &scheme{
+		identity:  identity,
+		groupBits: groupBits,
+		hashName:  hashName,
+	}
+}
+
+type scheme struct {
+	identity  string
+	groupBits int
+	hashName  string
+}
+
+func (c *scheme) SetParams(groupBits int, hashName string) error {
+	c.groupBits = groupBits
+	c.hashName = hashName
+	return nil
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$srp6a$")
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	salt := make([]byte, raw.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	return raw.Crypt(c.identity, password, salt, c.groupBits, c.hashName)
+}
+
+func (c *scheme) Verify(password, hash string) error {
+	g, hashName, salt, v, err := raw.Parse(hash)
+	if err != nil {
+		return err
+	}
+
+	hf, _ := raw.HashFunc(hashName)
+	newV := raw.Verifier(g, hf, salt, c.identity, password)
+
+	if !abstract.SecureCompare(string(v.Bytes()), string(newV.Bytes())) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	g, hashName, salt, _, err := raw.Parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return g.Bits < c.groupBits || hashName != c.hashName || len(salt) < raw.SaltLength
+}
+
+func (c *scheme) String() string {
+	return fmt.Sprintf("srp6a(%q,%d,%s)", c.identity, c.groupBits, c.hashName)
+}
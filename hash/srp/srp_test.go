@@ -0,0 +1,147 @@
+package srp
+
+import (
+	"bytes"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/srp/raw"
+)
+
+// The verifier from RFC 5054 appendix B.
+const rfc5054Verifier = "$srp6a$g=1024,h=sha1$vrJTedGoWB61pydnOiRB7g$fic96Glv/E9OM30FtLN1vrDd4Vaej6AKmIbYEputofGCIiPKGmBbUw43m6Ryn9xZ8QW0eH5RhvXGcQhaFEe1KkjPGXC0+2+EALv0zr+7FoFS4Iq16lPRXBr/h7K52m4E4FitUcxyv8kDO1ZOJkgNeOlVpeKeerJF2yvjFeIJmvs"
+
+func TestScheme(t *testing.T) {
+	c := New("alice", raw.RecommendedGroupBits, raw.RecommendedHash)
+
+	if !c.SupportsStub(rfc5054Verifier) {
+		t.Errorf("stub not supported")
+	}
+
+	if err := c.Verify("password123", rfc5054Verifier); err != nil {
+		t.Errorf("err verifying known good verifier: %v", err)
+	}
+
+	if err := c.Verify("password124", rfc5054Verifier); err != abstract.ErrInvalidPassword {
+		t.Errorf("unexpected result verifying wrong password: %v", err)
+	}
+
+	if err := New("bob", 1024, "sha1").Verify("password123", rfc5054Verifier); err != abstract.ErrInvalidPassword {
+		t.Errorf("unexpected result verifying wrong identity: %v", err)
+	}
+
+	if !c.NeedsUpdate(rfc5054Verifier) {
+		t.Errorf("expected update for smaller group")
+	}
+
+	h, err := c.Hash("password123")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if err := c.Verify("password123", h); err != nil || c.NeedsUpdate(h) {
+		t.Errorf("cannot verify new verifier: %v (%#v)", err, h)
+	}
+
+	if _, err := New("alice", 1000, "sha256").Hash("password"); err != raw.ErrInvalidParams {
+		t.Errorf("unexpected result for bad group: %v", err)
+	}
+
+	for _, bad := range []string{
+		"$srp6a$g=1024,h=md5$vrJTedGoWB61pydnOiRB7g$AQ",
+		"$srp6a$g=1024,h=sha1$vrJTedGoWB61pydnOiRB7g$AQ",
+		"$srp6a$g=1024,h=sha1$vrJTedGoWB61pydnOiRB7g",
+		"$srp6a$g=1024$vrJTedGoWB61pydnOiRB7g$AQ",
+	} {
+		if _, err := NewServer("alice", bad); err == nil {
+			t.Errorf("invalid verifier accepted: %#v", bad)
+		}
+	}
+}
+
+func handshake(t *testing.T, identity, password, verifier string) (*Server, *Client, error) {
+	s, err := NewServer(identity, verifier)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	groupBits, hashName := s.Params()
+	c, err := NewClient(identity, password, groupBits, hashName)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	A, err := c.Public()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	salt, B, err := s.Challenge()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	M1, err := c.Proof(salt, B)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	M2, err := s.Verify(A, M1)
+	if err != nil {
+		return s, c, err
+	}
+
+	return s, c, c.VerifyServer(M2)
+}
+
+func TestHandshake(t *testing.T) {
+	h, err := New("bob", 2048, "sha256").Hash("hunter2")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	for _, verifier := range []string{rfc5054Verifier, h} {
+		identity, password := "alice", "password123"
+		if verifier == h {
+			identity, password = "bob", "hunter2"
+		}
+
+		s, c, err := handshake(t, identity, password, verifier)
+		if err != nil {
+			t.Fatalf("handshake failed: %v", err)
+		}
+
+		if !s.Done() || !c.Done() || !bytes.Equal(s.Key(), c.Key()) || len(s.Key()) == 0 {
+			t.Errorf("handshake did not complete")
+		}
+
+		if _, _, err := s.Challenge(); err != ErrOutOfOrder {
+			t.Errorf("server reuse allowed: %v", err)
+		}
+
+		s, _, err = handshake(t, identity, password+"x", verifier)
+		if err != abstract.ErrInvalidPassword || s.Done() || s.Key() != nil {
+			t.Errorf("unexpected result with wrong password: %v", err)
+		}
+	}
+}
+
+func TestBadPublic(t *testing.T) {
+	s, err := NewServer("alice", rfc5054Verifier)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := s.Verify([]byte{1}, nil); err != ErrOutOfOrder {
+		t.Errorf("verify before challenge allowed: %v", err)
+	}
+
+	if _, _, err := s.Challenge(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// A = 0 or N would allow the client to force a known session key.
+	if _, err := s.Verify(raw.Group1024.N.Bytes(), []byte("x")); err != raw.ErrInvalidPublicValue {
+		t.Errorf("zero public value accepted: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+// Package raw provides raw implementations of the Windows NT, LM and domain
+// cached credentials (DCC2, also known as MSCASH2) password hashes.
+package raw
+
+import (
+	"crypto/des"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+	"golang.org/x/text/encoding/charmap"
+	pbkdf2 "gopkg.in/hlandau/passlib.v1/hash/pbkdf2/raw"
+)
+
+// The iteration count used by Windows for DCC2 hashes.
+const DefaultDCC2Iterations = 10240
+
+// The maximum length of a password which can be represented by an LM hash.
+const MaxLMPasswordLength = 14
+
+// Indicates that a password hash is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid Windows password hash")
+
+// Indicates that a password cannot be represented by an LM hash, because it is
+// too long or contains characters outside the OEM code page.
+var ErrLMPassword = fmt.Errorf("password cannot be represented by an LM hash")
+
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		b[2*i] = byte(c)
+		b[2*i+1] = byte(c >> 8)
+	}
+
+	return b
+}
+
+// Calculates the NT hash of a password, which is MD4 of the UTF-16LE encoded
+// password.
+func NT(password string) []byte {
+	h := md4.New()
+	h.Write(utf16le(password))
+	return h.Sum(nil)
+}
+
+// Calculates the LM hash of a password.
+//
+// The password is uppercased and encoded using code page 437, as Python
+// passlib does. Passwords longer than MaxLMPasswordLength bytes cannot be
+// represented, and ErrLMPassword is returned.
+func LM(password string) ([]byte, error) {
+	p, err := charmap.CodePage437.NewEncoder().String(strings.ToUpper(password))
+	if err != nil || len(p) > MaxLMPasswordLength {
+		return nil, ErrLMPassword
+	}
+
+	var key [MaxLMPasswordLength]byte
+	copy(key[:], p)
+
+	out := make([]byte, 0, 16)
+	for _, half := range [][]byte{key[0:7], key[7:14]} {
+		c, _ := des.NewCipher(lmKey(half))
+		var block [8]byte
+		c.Encrypt(block[:], []byte("KGS!@#$%"))
+		out = append(out, block[:]...)
+	}
+
+	return out, nil
+}
+
+// Expands 7 bytes into a DES key of 8 bytes by inserting an (ignored) parity
+// bit after every 7 bits.
+func lmKey(b []byte) []byte {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+
+	k := make([]byte, 8)
+	for i := range k {
+		k[i] = byte(v>>uint(49-7*i)) << 1
+	}
+
+	return k
+}
+
+// Calculates the DCC1 (MSCASH) hash, which is MD4 of the NT hash followed by
+// the UTF-16LE encoded, lowercased username.
+func DCC1(password, username string) []byte {
+	h := md4.New()
+	h.Write(NT(password))
+	h.Write(utf16le(strings.ToLower(username)))
+	return h.Sum(nil)
+}
+
+// Calculates the DCC2 (MSCASH2) hash, which is PBKDF2-HMAC-SHA1 of the DCC1
+// hash, salted with the UTF-16LE encoded, lowercased username.
+func DCC2(password, username string, iterations int) []byte {
+	return pbkdf2.Key(DCC1(password, username), utf16le(strings.ToLower(username)), iterations, 16, sha1.New)
+}
+
+func decodeHex16(s string) ([]byte, error) {
+	if len(s) != 32 {
+		return nil, ErrInvalidStub
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidStub
+	}
+
+	return b, nil
+}
+
+// Parses an NT hash, which may be 32 hex digits, optionally prefixed with
+// "$NT$".
+func ParseNT(hash string) ([]byte, error) {
+	return decodeHex16(strings.TrimPrefix(hash, "$NT$"))
+}
+
+// Parses an LM hash, which may be 32 hex digits, optionally prefixed with
+// "$LM$".
+func ParseLM(hash string) ([]byte, error) {
+	return decodeHex16(strings.TrimPrefix(hash, "$LM$"))
+}
+
+// Formats a DCC2 hash in the format used by John the Ripper and hashcat:
+//
+//	$DCC2$iterations#username#hash
+func FormatDCC2(username string, iterations int, hash []byte) string {
+	return fmt.Sprintf("$DCC2$%d#%s#%s", iterations, username, hex.EncodeToString(hash))
+}
+
+// Parses a DCC2 hash in the format produced by FormatDCC2.
+func ParseDCC2(hash string) (username string, iterations int, dcc2 []byte, err error) {
+	if !strings.HasPrefix(hash, "$DCC2$") {
+		err = ErrInvalidStub
+		return
+	}
+
+	parts := strings.Split(hash[6:], "#")
+	if len(parts) != 3 {
+		err = ErrInvalidStub
+		return
+	}
+
+	n, err := strconv.ParseUint(parts[0], 10, 31)
+	if err != nil || n == 0 {
+		err = ErrInvalidStub
+		return
+	}
+
+	if dcc2, err = decodeHex16(parts[2]); err != nil {
+		return
+	}
+
+	return parts[1], int(n), dcc2, nil
+}
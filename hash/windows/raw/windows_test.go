@@ -0,0 +1,53 @@
+package raw
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestNTLM(t *testing.T) {
+	for _, tst := range []struct {
+		password, nt, lm string
+	}{
+		{"", "31d6cfe0d16ae931b73c59d7e0c089c0", "aad3b435b51404eeaad3b435b51404ee"},
+		{"password", "8846f7eaee8fb117ad06bdd830b7586c", "e52cac67419a9a224a3b108f3fa6cb6d"},
+		{"PASSWORD", "7b592e4f8178b4c75788531b2e747687", "e52cac67419a9a224a3b108f3fa6cb6d"},
+	} {
+		if h := hex.EncodeToString(NT(tst.password)); h != tst.nt {
+			t.Errorf("NT mismatch: %q: got %q, expected %q", tst.password, h, tst.nt)
+		}
+
+		lm, err := LM(tst.password)
+		if h := hex.EncodeToString(lm); err != nil || h != tst.lm {
+			t.Errorf("LM mismatch: %q: got %q (%v), expected %q", tst.password, h, err, tst.lm)
+		}
+	}
+
+	if _, err := LM("123456789012345"); err != ErrLMPassword {
+		t.Errorf("unexpected result for long LM password: %v", err)
+	}
+}
+
+func TestDCC2(t *testing.T) {
+	// hashcat mode 2100 example.
+	h := "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f"
+
+	username, iterations, dcc2, err := ParseDCC2(h)
+	if err != nil || username != "tom" || iterations != DefaultDCC2Iterations {
+		t.Fatalf("cannot parse: %v", err)
+	}
+
+	if got := FormatDCC2(username, iterations, DCC2("hashcat", username, iterations)); got != h {
+		t.Errorf("DCC2 mismatch: got %q, expected %q", got, h)
+	}
+
+	if hex.EncodeToString(dcc2) != hex.EncodeToString(DCC2("hashcat", "TOM", iterations)) {
+		t.Errorf("username should be case-insensitive")
+	}
+
+	for _, bad := range []string{"$DCC2$0#tom#e4e938d12fe5974dc42a90120bd9c90f", "$DCC2$10240#tom", "$DCC2$10240#tom#e4e9"} {
+		if _, _, _, err := ParseDCC2(bad); err == nil {
+			t.Errorf("invalid hash accepted: %q", bad)
+		}
+	}
+}
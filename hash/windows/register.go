@@ -0,0 +1,26 @@
+package windows
+
+import (
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+func init() {
+	for _, v := range []struct {
+		name, doc string
+		scheme    *abstract.Scheme
+	}{
+		{"nt", "Windows NT hashes (verify only)", &NTCrypter},
+		{"lm", "Windows LM hashes (verify only)", &LMCrypter},
+		{"dcc2", "Windows DCC2/MSCASH2 hashes (verify only)", &DCC2Crypter},
+	} {
+		scheme := v.scheme
+		registry.Register(&registry.Factory{
+			Name: v.name,
+			Doc:  v.doc,
+			New: func(args registry.Args) (abstract.Scheme, error) {
+				return *scheme, nil
+			},
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package windows implements verification of the NT, LM and DCC2 (MSCASH2)
+// password hashes found in Windows and Active Directory credential dumps.
+//
+// These hashes are obsolete or unsalted, so all schemes in this package are
+// verify-only (see abstract.VerifyOnlyScheme). They are intended for use in a
+// Context with a modern preferred scheme, so that users can be upgraded when
+// they next log in.
+package windows
+
+import (
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/windows/raw"
+)
+
+// An implementation of Scheme verifying NT hashes, which are 32 hex digits
+// optionally prefixed with "$NT$".
+var NTCrypter abstract.Scheme
+
+// An implementation of Scheme verifying LM hashes, which are 32 hex digits
+// optionally prefixed with "$LM$".
+//
+// Unprefixed LM hashes cannot be distinguished from unprefixed NT hashes, so
+// this scheme should not be used in the same Context as NTCrypter unless the
+// prefixes are present.
+var LMCrypter abstract.Scheme
+
+// An implementation of Scheme verifying DCC2 hashes in the format used by John
+// the Ripper and hashcat:
+//
+//	$DCC2$10240#username#hash
+var DCC2Crypter abstract.Scheme
+
+func init() {
+	NTCrypter = &ntCrypter{}
+	LMCrypter = &lmCrypter{}
+	DCC2Crypter = &dcc2Crypter{}
+}
+
+// Common methods of verify-only schemes.
+type verifyOnly struct{}
+
+func (verifyOnly) Hash(password string) (string, error) {
+	return "", abstract.ErrVerifyOnly
+}
+
+func (verifyOnly) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (verifyOnly) VerifyOnly() bool {
+	return true
+}
+
+type ntCrypter struct {
+	verifyOnly
+}
+
+func (c *ntCrypter) SupportsStub(stub string) bool {
+	_, err := raw.ParseNT(stub)
+	return err == nil
+}
+
+func (c *ntCrypter) Verify(password, hash string) error {
+	nt, err := raw.ParseNT(hash)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(nt), string(raw.NT(password))) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *ntCrypter) String() string {
+	return "nthash"
+}
+
+type lmCrypter struct {
+	verifyOnly
+}
+
+func (c *lmCrypter) SupportsStub(stub string) bool {
+	_, err := raw.ParseLM(stub)
+	return err == nil
+}
+
+func (c *lmCrypter) Verify(password, hash string) error {
+	lm, err := raw.ParseLM(hash)
+	if err != nil {
+		return err
+	}
+
+	newLM, err := raw.LM(password)
+	if err != nil || !abstract.SecureCompare(string(lm), string(newLM)) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *lmCrypter) String() string {
+	return "lmhash"
+}
+
+type dcc2Crypter struct {
+	verifyOnly
+}
+
+func (c *dcc2Crypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "$DCC2$")
+}
+
+func (c *dcc2Crypter) Verify(password, hash string) error {
+	username, iterations, dcc2, err := raw.ParseDCC2(hash)
+	if err != nil {
+		return err
+	}
+
+	if !abstract.SecureCompare(string(dcc2), string(raw.DCC2(password, username, iterations))) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *dcc2Crypter) String() string {
+	return "msdcc2"
+}
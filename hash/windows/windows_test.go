@@ -0,0 +1,41 @@
+package windows
+
+import (
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+func TestSchemes(t *testing.T) {
+	for _, tst := range []struct {
+		scheme   abstract.Scheme
+		password string
+		hash     string
+	}{
+		{NTCrypter, "password", "8846f7eaee8fb117ad06bdd830b7586c"},
+		{NTCrypter, "password", "$NT$8846F7EAEE8FB117AD06BDD830B7586C"},
+		{LMCrypter, "password", "e52cac67419a9a224a3b108f3fa6cb6d"},
+		{LMCrypter, "PassWord", "$LM$e52cac67419a9a224a3b108f3fa6cb6d"},
+		{DCC2Crypter, "hashcat", "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f"},
+	} {
+		if !tst.scheme.SupportsStub(tst.hash) {
+			t.Errorf("stub not supported: %q", tst.hash)
+		}
+
+		if err := tst.scheme.Verify(tst.password, tst.hash); err != nil {
+			t.Errorf("err verifying known good hash: %v (%q)", err, tst.hash)
+		}
+
+		if err := tst.scheme.Verify(tst.password+"x", tst.hash); err != abstract.ErrInvalidPassword {
+			t.Errorf("unexpected result verifying wrong password: %v", err)
+		}
+
+		if !abstract.IsVerifyOnly(tst.scheme) || !tst.scheme.NeedsUpdate(tst.hash) {
+			t.Errorf("scheme should be verify-only")
+		}
+
+		if _, err := tst.scheme.Hash(tst.password); err != abstract.ErrVerifyOnly {
+			t.Errorf("unexpected result hashing: %v", err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package raw
+
+import "fmt"
+
+const itoa64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var atoi64Table [256]byte
+
+func init() {
+	for i := range atoi64Table {
+		atoi64Table[i] = 0xFF
+	}
+
+	for i := 0; i < len(itoa64); i++ {
+		atoi64Table[itoa64[i]] = byte(i)
+	}
+}
+
+// Indicates that a crypt base64 string is malformed or not canonically
+// encoded.
+var ErrInvalidBase64 = fmt.Errorf("invalid crypt base64 encoding")
+
+func atoi64(c byte) uint32 {
+	return uint32(atoi64Table[c])
+}
+
+// Encodes a byte string using the little-endian crypt base64 variant used by
+// yescrypt and libxcrypt's scrypt. No padding characters are used.
+func EncodeBase64(src []byte) string {
+	dst := make([]byte, 0, (len(src)*8+5)/6)
+
+	for i := 0; i < len(src); {
+		value, bits := uint32(0), uint32(0)
+		for bits < 24 && i < len(src) {
+			value |= uint32(src[i]) << bits
+			bits += 8
+			i++
+		}
+
+		for b := uint32(0); b < bits; b += 6 {
+			dst = append(dst, itoa64[value&0x3f])
+			value >>= 6
+		}
+	}
+
+	return string(dst)
+}
+
+// Decodes a string produced by EncodeBase64. Non-canonical encodings, such as
+// those with unused bits set, are rejected.
+func DecodeBase64(src string) ([]byte, error) {
+	dst := make([]byte, 0, len(src)*6/8)
+
+	for len(src) > 0 {
+		value, bits := uint32(0), uint32(0)
+		for bits < 24 && len(src) > 0 {
+			c := atoi64(src[0])
+			if c > 63 {
+				return nil, ErrInvalidBase64
+			}
+
+			value |= c << bits
+			bits += 6
+			src = src[1:]
+		}
+
+		if bits < 12 {
+			return nil, ErrInvalidBase64
+		}
+
+		for ; bits >= 8; bits -= 8 {
+			dst = append(dst, byte(value))
+			value >>= 8
+		}
+
+		if value != 0 {
+			return nil, ErrInvalidBase64
+		}
+	}
+
+	return dst, nil
+}
+
+// Encodes an integer using the fixed-width encoding used for the r and p
+// fields of $7$ settings. srcBits is the width of the field in bits.
+func EncodeUint32Fixed(src, srcBits uint32) string {
+	var dst []byte
+	for bits := uint32(0); bits < srcBits; bits += 6 {
+		dst = append(dst, itoa64[src&0x3f])
+		src >>= 6
+	}
+
+	return string(dst)
+}
+
+// Decodes an integer encoded with EncodeUint32Fixed, returning the remainder
+// of the string.
+func DecodeUint32Fixed(src string, srcBits uint32) (value uint32, rest string, err error) {
+	for bits := uint32(0); bits < srcBits; bits += 6 {
+		if len(src) == 0 {
+			return 0, "", ErrInvalidBase64
+		}
+
+		c := atoi64(src[0])
+		if c > 63 {
+			return 0, "", ErrInvalidBase64
+		}
+
+		src = src[1:]
+		value |= c << bits
+	}
+
+	return value, src, nil
+}
+
+// Encodes an integer using the compact variable-length encoding used for the
+// parameter fields of $y$ settings. min is the smallest encodable value.
+func EncodeUint32(src, min uint32) (string, error) {
+	if src < min {
+		return "", ErrInvalidParams
+	}
+
+	src -= min
+
+	start, end, chars, bits := uint32(0), uint32(47), 1, uint32(0)
+	for {
+		count := (end + 1 - start) << bits
+		if src < count {
+			break
+		}
+
+		if start >= 63 {
+			return "", ErrInvalidParams
+		}
+
+		start = end + 1
+		end = start + (62-end)/2
+		src -= count
+		chars++
+		bits += 6
+	}
+
+	dst := []byte{itoa64[start+(src>>bits)]}
+	for chars--; chars > 0; chars-- {
+		bits -= 6
+		dst = append(dst, itoa64[(src>>bits)&0x3f])
+	}
+
+	return string(dst), nil
+}
+
+// Decodes an integer encoded with EncodeUint32, returning the remainder of the
+// string.
+func DecodeUint32(src string, min uint32) (value uint32, rest string, err error) {
+	if len(src) == 0 {
+		return 0, "", ErrInvalidBase64
+	}
+
+	c := atoi64(src[0])
+	if c > 63 {
+		return 0, "", ErrInvalidBase64
+	}
+
+	src = src[1:]
+
+	start, end, chars, bits := uint32(0), uint32(47), 1, uint32(0)
+	value = min
+	for c > end {
+		value += (end + 1 - start) << bits
+		start = end + 1
+		end = start + (62-end)/2
+		chars++
+		bits += 6
+	}
+
+	value += (c - start) << bits
+
+	for chars--; chars > 0; chars-- {
+		if len(src) == 0 {
+			return 0, "", ErrInvalidBase64
+		}
+
+		c = atoi64(src[0])
+		if c > 63 {
+			return 0, "", ErrInvalidBase64
+		}
+
+		src = src[1:]
+		bits -= 6
+		value += c << bits
+	}
+
+	return value, src, nil
+}
@@ -0,0 +1,320 @@
+package raw
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"strings"
+)
+
+// The recommended block count for interactive logins. This is the libxcrypt
+// default.
+const RecommendedN = 4096
+
+// The recommended block size for interactive logins. This is the libxcrypt
+// default.
+const Recommendedr = 32
+
+// Length of the derived key embedded in a $y$ hash.
+const HashLength = 32
+
+// The maximum memory in bytes which a hash may require, beyond which Parse
+// rejects it, so that verifying an untrusted hash cannot exhaust memory. This
+// is twice that required by the highest libxcrypt cost (N=2^18, r=32).
+const MaxMemory = 1 << 31
+
+// The maximum cost of a hash, beyond which Parse rejects it, so that verifying
+// an untrusted hash cannot take excessive time. The cost is 128·N·r·(t+1),
+// which is proportional to the time taken, so t can only be raised above 3 for
+// hashes requiring less than MaxMemory. libxcrypt always uses t=0.
+const MaxCost = 4 * MaxMemory
+
+// Indicates that a password hash or stub is invalid.
+var ErrInvalidStub = fmt.Errorf("invalid yescrypt password stub")
+
+// Encodes the parameter part of a $y$ setting string, e.g. "j9T" for the
+// libxcrypt defaults.
+func EncodeParams(params Params) (string, error) {
+	var flavor uint32
+	switch {
+	case params.Flags < FlagRW:
+		flavor = params.Flags
+	case params.Flags&flagModeMask == FlagRW && params.Flags&^(FlagRW|flagRWFlavorMask) == 0:
+		flavor = FlagRW + (params.Flags-FlagRW)>>2
+	default:
+		return "", ErrInvalidParams
+	}
+
+	if params.N < 2 || params.N&(params.N-1) != 0 {
+		return "", ErrInvalidParams
+	}
+
+	nLog2 := uint32(0)
+	for n := params.N; n > 1; n >>= 1 {
+		nLog2++
+	}
+
+	var s string
+	for _, f := range []struct{ v, min uint32 }{{flavor, 0}, {nLog2, 1}, {params.R, 1}} {
+		e, err := EncodeUint32(f.v, f.min)
+		if err != nil {
+			return "", err
+		}
+
+		s += e
+	}
+
+	have := uint32(0)
+	if params.P != 1 {
+		have |= 1
+	}
+	if params.T != 0 {
+		have |= 2
+	}
+
+	if have != 0 {
+		e, err := EncodeUint32(have, 1)
+		if err != nil {
+			return "", err
+		}
+
+		s += e
+
+		if have&1 != 0 {
+			if e, err = EncodeUint32(params.P, 2); err != nil {
+				return "", err
+			}
+
+			s += e
+		}
+
+		if have&2 != 0 {
+			if e, err = EncodeUint32(params.T, 1); err != nil {
+				return "", err
+			}
+
+			s += e
+		}
+	}
+
+	return s, nil
+}
+
+// Calculates a yescrypt hash in the $y$ format.
+//
+// password should be a UTF-8 plaintext password.
+// salt should be a random salt value in binary form.
+//
+// Returns a modular crypt hash.
+func Crypt(password string, salt []byte, params Params) (string, error) {
+	p, err := EncodeParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	return CryptSetting(password, "$y$"+p+"$"+EncodeBase64(salt))
+}
+
+// Calculates a gost-yescrypt hash in the $gy$ format. The arguments are as for
+// Crypt.
+func CryptGOST(password string, salt []byte, params Params) (string, error) {
+	p, err := EncodeParams(params)
+	if err != nil {
+		return "", err
+	}
+
+	return CryptSetting(password, "$gy$"+p+"$"+EncodeBase64(salt))
+}
+
+// Calculates a yescrypt hash using a $y$ or $gy$ setting string (or full
+// hash). As with libxcrypt, the parameter and salt fields of the setting are
+// preserved verbatim in the output.
+//
+// As with libxcrypt, the salt extends to the last "$" following the
+// parameters, and anything after it (normally the hash) is ignored.
+func CryptSetting(password, setting string) (string, error) {
+	params, salt, _, prefix, err := parse(trimSetting(setting))
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := Key([]byte(password), salt, params, HashLength)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(prefix, "$gy$") {
+		hash = gostHash(password, prefix, hash)
+	}
+
+	return prefix + "$" + EncodeBase64(hash), nil
+}
+
+// Removes the field following the salt of a setting string, if any. The
+// setting is of the form $id$params$salt[$hash].
+func trimSetting(setting string) string {
+	n := 0
+	for i := 0; i < len(setting); i++ {
+		if setting[i] != '$' {
+			continue
+		}
+
+		if n++; n == 3 {
+			if k := strings.LastIndexByte(setting, '$'); k > i {
+				return setting[0:k]
+			}
+
+			break
+		}
+	}
+
+	return setting
+}
+
+// gost-yescrypt postprocesses the yescrypt output with two rounds of
+// HMAC-Streebog-256:
+//
+//	HMAC(HMAC(Streebog(password), setting), yescrypt(password, setting))
+func gostHash(password, setting string, y []byte) []byte {
+	d := newStreebog256()
+	d.Write([]byte(password))
+
+	h := hmac.New(newStreebog256, d.Sum(nil))
+	h.Write([]byte(setting))
+
+	h = hmac.New(newStreebog256, h.Sum(nil))
+	h.Write(y)
+	return h.Sum(nil)
+}
+
+// Parses a yescrypt or gost-yescrypt modular hash or stub string.
+//
+// The format is as follows:
+//
+//	$y$params$salt$hash    // hash
+//	$y$params$salt         // stub
+//	$gy$params$salt$hash   // gost-yescrypt hash
+//	$gy$params$salt        // gost-yescrypt stub
+//
+// The parameters are encoded using the compact libxcrypt encoding, and the
+// salt and hash using the little-endian crypt base64 encoding.
+func Parse(stub string) (params Params, salt, hash []byte, err error) {
+	params, salt, hash, _, err = parse(stub)
+	return
+}
+
+func parse(stub string) (params Params, salt, hash []byte, prefix string, err error) {
+	var s string
+	switch {
+	case strings.HasPrefix(stub, "$y$"):
+		s = stub[3:]
+	case strings.HasPrefix(stub, "$gy$"):
+		s = stub[4:]
+	default:
+		err = ErrInvalidStub
+		return
+	}
+
+	params, rest, err := DecodeParams(s)
+	if err != nil {
+		return
+	}
+
+	// V takes 128·N·r bytes, and S sBytes for each thread.
+	if params.N > 1<<31 || uint64(params.R) > MaxMemory/(128*params.N) ||
+		uint64(params.P) > (MaxMemory-128*params.N*uint64(params.R))/sBytes ||
+		uint64(params.T)+1 > MaxCost/(128*params.N*uint64(params.R)) {
+		err = ErrInvalidParams
+		return
+	}
+
+	if len(rest) == 0 || rest[0] != '$' {
+		err = ErrInvalidStub
+		return
+	}
+
+	rest = rest[1:]
+	saltStr := rest
+	if i := strings.IndexByte(rest, '$'); i >= 0 {
+		saltStr = rest[0:i]
+		if hash, err = DecodeBase64(rest[i+1:]); err != nil {
+			return
+		}
+
+		if len(hash) != HashLength {
+			err = ErrInvalidStub
+			return
+		}
+	}
+
+	if salt, err = DecodeBase64(saltStr); err != nil {
+		return
+	}
+
+	prefix = stub[0 : len(stub)-len(rest)+len(saltStr)]
+	return
+}
+
+// Decodes the parameter part of a $y$ setting string, as encoded by
+// EncodeParams, returning the remainder of s. Unlike Parse, this does not
+// check that the parameters are within MaxMemory and MaxCost.
+func DecodeParams(s string) (params Params, rest string, err error) {
+	var flavor, nLog2 uint32
+
+	if flavor, s, err = DecodeUint32(s, 0); err != nil {
+		return
+	}
+
+	if flavor < FlagRW {
+		params.Flags = flavor
+	} else if flavor <= FlagRW+(flagRWFlavorMask>>2) {
+		params.Flags = FlagRW + (flavor-FlagRW)<<2
+	} else {
+		err = ErrInvalidStub
+		return
+	}
+
+	if nLog2, s, err = DecodeUint32(s, 1); err != nil {
+		return
+	}
+
+	if nLog2 > 63 {
+		err = ErrInvalidStub
+		return
+	}
+
+	params.N = 1 << nLog2
+
+	if params.R, s, err = DecodeUint32(s, 1); err != nil {
+		return
+	}
+
+	params.P = 1
+
+	if len(s) > 0 && s[0] != '$' {
+		var have uint32
+		if have, s, err = DecodeUint32(s, 1); err != nil {
+			return
+		}
+
+		if have&1 != 0 {
+			if params.P, s, err = DecodeUint32(s, 2); err != nil {
+				return
+			}
+		}
+
+		if have&2 != 0 {
+			if params.T, s, err = DecodeUint32(s, 1); err != nil {
+				return
+			}
+		}
+
+		// Hash upgrades (g) and ROMs are not supported.
+		if have&^3 != 0 {
+			err = ErrInvalidParams
+			return
+		}
+	}
+
+	rest = s
+	return
+}
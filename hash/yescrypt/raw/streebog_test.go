@@ -0,0 +1,27 @@
+package raw
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// From GOST R 34.11-2012, appendix A, and libgcrypt.
+var streebogTests = []struct {
+	msg, digest string
+}{
+	{"", "3f539a213e97c802cc229d474c6aa32a825a360b2a933a949fd925208d9ce1bb"},
+	{"012345678901234567890123456789012345678901234567890123456789012", "9d151eefd8590b89daa6ba6cb74af9275dd051026bb149a452fd84e5e57b5500"},
+	{strings.Repeat("a", 64), "c2ce0969b6e468445ecfaed89f614178f89cc37ab59523528a58745007f33ab2"},
+	{strings.Repeat("The quick brown fox jumps over the lazy dog", 3), "aa8ab94ce9259a08d395d95570ed2026ce0363fc6383d676023dd8fff8e61c88"},
+}
+
+func TestStreebog(t *testing.T) {
+	for _, tst := range streebogTests {
+		d := newStreebog256()
+		d.Write([]byte(tst.msg))
+		if out := hex.EncodeToString(d.Sum(nil)); out != tst.digest {
+			t.Errorf("mismatch for %q: got %s, expected %s", tst.msg, out, tst.digest)
+		}
+	}
+}
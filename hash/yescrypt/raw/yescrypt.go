@@ -0,0 +1,476 @@
+// Package raw provides a raw implementation of the yescrypt primitive and of
+// the libxcrypt-compatible $y$ modular crypt format.
+package raw
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Flags which select the yescrypt mode of operation. Only classic scrypt (no
+// flags), FlagWORM and FlagDefaults (the yescrypt read-write mode with the
+// default pwxform settings used by libxcrypt) are supported.
+const (
+	FlagWORM = 0x001
+	FlagRW   = 0x002
+
+	flagRounds6 = 0x004
+	flagGather4 = 0x010
+	flagSimple2 = 0x020
+	flagSBox12K = 0x080
+
+	// The flags used by libxcrypt for all $y$ hashes.
+	FlagDefaults = FlagRW | flagRounds6 | flagGather4 | flagSimple2 | flagSBox12K
+
+	flagModeMask     = 0x003
+	flagRWFlavorMask = 0x3fc
+	flagPrehash      = 0x10000000
+)
+
+// pwxform settings corresponding to FlagDefaults.
+const (
+	pwxSimple = 2
+	pwxGather = 4
+	pwxRounds = 6
+	sWidth    = 8
+
+	pwxBytes = pwxGather * pwxSimple * 8
+	pwxWords = pwxBytes / 4
+	sBytes   = 3 * (1 << sWidth) * pwxSimple * 8
+	sWords   = sBytes / 8
+	sMask    = ((1 << sWidth) - 1) * pwxSimple * 8
+)
+
+// Indicates that the yescrypt parameters are invalid or unsupported.
+var ErrInvalidParams = fmt.Errorf("invalid yescrypt parameters")
+
+// Parameters to yescrypt.
+type Params struct {
+	// Mode flags. Either 0 (classic scrypt), FlagWORM or FlagDefaults.
+	Flags uint32
+
+	// Block count. Must be a power of two greater than 1.
+	N uint64
+
+	// Block size and parallelism.
+	R, P uint32
+
+	// Additional time factor.
+	T uint32
+}
+
+// Derives a key of keyLen bytes from password and salt using yescrypt with
+// the given parameters.
+//
+// With no flags set, this is equivalent to classic scrypt.
+func Key(password, salt []byte, params Params, keyLen int) ([]byte, error) {
+	N, r, p := params.N, params.R, params.P
+
+	if (params.Flags&(FlagRW|flagPrehash)) == FlagRW &&
+		p >= 1 && N/uint64(p) >= 0x100 && N/uint64(p)*uint64(r) >= 0x20000 {
+		dk, err := kdfBody(password, salt, params.Flags|flagPrehash, N>>6, r, p, 0, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		password = dk
+	}
+
+	return kdfBody(password, salt, params.Flags, N, r, p, params.T, keyLen)
+}
+
+func kdfBody(password, salt []byte, flags uint32, N uint64, r, p, t uint32, keyLen int) ([]byte, error) {
+	switch flags & flagModeMask {
+	case 0:
+		if flags != 0 || t != 0 {
+			return nil, ErrInvalidParams
+		}
+	case FlagWORM:
+		if flags != FlagWORM {
+			return nil, ErrInvalidParams
+		}
+	case FlagRW:
+		if flags&^flagPrehash != FlagDefaults {
+			return nil, ErrInvalidParams
+		}
+	default:
+		return nil, ErrInvalidParams
+	}
+
+	if keyLen < 1 || uint64(r)*uint64(p) >= 1<<30 || N > 0xffffffff ||
+		N&(N-1) != 0 || N <= 1 || r < 1 || p < 1 {
+		return nil, ErrInvalidParams
+	}
+
+	if flags&FlagRW != 0 && N/uint64(p) <= 1 {
+		return nil, ErrInvalidParams
+	}
+
+	s := 32 * int(r)
+	V := make([]uint32, s*int(N))
+	XY := make([]uint32, 2*s)
+
+	var S []uint32
+	if flags&FlagRW != 0 {
+		S = make([]uint32, 2*sWords*int(p))
+	}
+
+	if flags != 0 {
+		key := "yescrypt-prehash"
+		if flags&flagPrehash == 0 {
+			key = key[0:8]
+		}
+
+		password = hmacSHA256([]byte(key), password)
+	}
+
+	B := pbkdf2.Key(password, salt, 1, 128*int(r)*int(p), sha256.New)
+
+	if flags != 0 {
+		password = append([]byte(nil), B[0:32]...)
+	}
+
+	if p == 1 || flags&FlagRW != 0 {
+		smix(B, int(r), N, p, t, flags, V, XY, S, password)
+	} else {
+		for i := 0; i < int(p); i++ {
+			smix(B[128*int(r)*i:], int(r), N, 1, t, flags, V, XY, nil, nil)
+		}
+	}
+
+	dkLen := keyLen
+	if flags != 0 && dkLen < 32 {
+		dkLen = 32
+	}
+
+	dk := pbkdf2.Key(password, B, 1, dkLen, sha256.New)
+
+	if flags != 0 && flags&flagPrehash == 0 {
+		clientKey := hmacSHA256(dk[0:32], []byte("Client Key"))
+		storedKey := sha256.Sum256(clientKey)
+		copy(dk, storedKey[:])
+	}
+
+	return dk[0:keyLen], nil
+}
+
+func hmacSHA256(key, msg []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// The state of pwxform for one smix lane.
+type pwxformCtx struct {
+	S0, S1, S2 []uint64
+	w          int
+}
+
+func smix(B []byte, r int, N uint64, p, t uint32, flags uint32, V, XY, S []uint32, passwd []byte) {
+	s := 32 * r
+
+	nChunk := N / uint64(p)
+	nLoopAll := nChunk
+	if flags&FlagRW != 0 {
+		if t <= 1 {
+			if t != 0 {
+				nLoopAll *= 2
+			}
+			nLoopAll = (nLoopAll + 2) / 3
+		} else {
+			nLoopAll *= uint64(t - 1)
+		}
+	} else if t != 0 {
+		if t == 1 {
+			nLoopAll += (nLoopAll + 1) / 2
+		}
+		nLoopAll *= uint64(t)
+	}
+
+	nLoopRW := uint64(0)
+	if flags&FlagRW != 0 {
+		nLoopRW = nLoopAll / uint64(p)
+	}
+
+	nChunk &^= 1
+	nLoopAll = (nLoopAll + 1) &^ 1
+	nLoopRW = (nLoopRW + 1) &^ 1
+
+	ctxs := make([]*pwxformCtx, p)
+	X := XY[0:s]
+
+	for i := uint32(0); i < p; i++ {
+		vChunk := uint64(i) * nChunk
+		np := nChunk
+		if i == p-1 {
+			np = N - vChunk
+		}
+
+		Bp := B[128*r*int(i) : 128*r*(int(i)+1)]
+		Vp := V[s*int(vChunk):]
+
+		if flags&FlagRW != 0 {
+			Si := S[2*sWords*int(i) : 2*sWords*(int(i)+1)]
+			smix1(Bp[0:128], 1, sBytes/128, 0, Si, XY, nil)
+			ctxs[i] = newPwxformCtx(Si)
+
+			if i == 0 {
+				copy(passwd, hmacSHA256(Bp[128*r-64:], passwd))
+			}
+		}
+
+		smix1(Bp, r, np, flags, Vp, XY, ctxs[i])
+
+		decodeBlock(X, Bp)
+		smix2(X, r, p2floor(np), nLoopRW, flags, Vp, XY[s:], ctxs[i])
+		encodeBlock(Bp, X)
+	}
+
+	if nLoopAll > nLoopRW {
+		for i := uint32(0); i < p; i++ {
+			Bp := B[128*r*int(i) : 128*r*(int(i)+1)]
+
+			decodeBlock(X, Bp)
+			smix2(X, r, N, nLoopAll-nLoopRW, flags&^FlagRW, V, XY[s:], ctxs[i])
+			encodeBlock(Bp, X)
+		}
+	}
+}
+
+func newPwxformCtx(S []uint32) *pwxformCtx {
+	S64 := make([]uint64, sWords)
+	for i := range S64 {
+		S64[i] = uint64(S[2*i]) | uint64(S[2*i+1])<<32
+	}
+
+	return &pwxformCtx{
+		S2: S64[0 : sWords/3],
+		S1: S64[sWords/3 : sWords/3*2],
+		S0: S64[sWords/3*2:],
+	}
+}
+
+// Loads 128r bytes into a word array, applying the SIMD shuffle used by the
+// reference implementation. pwxform operates on the shuffled representation.
+func decodeBlock(X []uint32, B []byte) {
+	for k := 0; k < len(B)/64; k++ {
+		for i := 0; i < 16; i++ {
+			X[k*16+i] = binary.LittleEndian.Uint32(B[(k*16+(i*5%16))*4:])
+		}
+	}
+}
+
+// Inverse of decodeBlock.
+func encodeBlock(B []byte, X []uint32) {
+	for k := 0; k < len(B)/64; k++ {
+		for i := 0; i < 16; i++ {
+			binary.LittleEndian.PutUint32(B[(k*16+(i*5%16))*4:], X[k*16+i])
+		}
+	}
+}
+
+func smix1(B []byte, r int, N uint64, flags uint32, V, XY []uint32, ctx *pwxformCtx) {
+	s := 32 * r
+	X := XY[0:s]
+	Y := XY[s : 2*s]
+
+	decodeBlock(X, B)
+
+	for i := uint64(0); i < N; i++ {
+		copy(V[int(i)*s:], X)
+
+		if flags&FlagRW != 0 && i > 1 {
+			j := wrap(integerify(X, r), i)
+			blkxor(X, V[int(j)*s:int(j+1)*s])
+		}
+
+		blockmix(X, Y, r, ctx)
+		copy(X, Y)
+	}
+
+	encodeBlock(B, X)
+}
+
+func smix2(X []uint32, r int, N, nLoop uint64, flags uint32, V, Y []uint32, ctx *pwxformCtx) {
+	s := 32 * r
+	Y = Y[0:s]
+
+	for i := uint64(0); i < nLoop; i++ {
+		j := integerify(X, r) & (N - 1)
+		Vj := V[int(j)*s : int(j+1)*s]
+
+		blkxor(X, Vj)
+		if flags&FlagRW != 0 {
+			copy(Vj, X)
+		}
+
+		blockmix(X, Y, r, ctx)
+		copy(X, Y)
+	}
+}
+
+func blockmix(B, Y []uint32, r int, ctx *pwxformCtx) {
+	if ctx == nil {
+		blockmixSalsa8(B, Y, r)
+	} else {
+		blockmixPwxform(B, Y, r, ctx)
+	}
+}
+
+func blockmixSalsa8(B, Y []uint32, r int) {
+	var X [16]uint32
+	copy(X[:], B[(2*r-1)*16:])
+
+	for i := 0; i < 2*r; i++ {
+		blkxor(X[:], B[i*16:(i+1)*16])
+		salsa20(X[:], 8)
+
+		o := (i/2 + (i&1)*r) * 16
+		copy(Y[o:o+16], X[:])
+	}
+}
+
+func blockmixPwxform(B, Y []uint32, r int, ctx *pwxformCtx) {
+	r1 := 128 * r / pwxBytes
+
+	var X [pwxWords]uint32
+	copy(X[:], B[(r1-1)*pwxWords:])
+
+	for i := 0; i < r1; i++ {
+		if r1 > 1 {
+			blkxor(X[:], B[i*pwxWords:(i+1)*pwxWords])
+		}
+
+		pwxform(&X, ctx)
+		copy(Y[i*pwxWords:], X[:])
+	}
+
+	i := (r1 - 1) * pwxBytes / 64
+	salsa20(Y[i*16:(i+1)*16], 2)
+
+	for i++; i < 2*r; i++ {
+		blkxor(Y[i*16:(i+1)*16], Y[(i-1)*16:i*16])
+		salsa20(Y[i*16:(i+1)*16], 2)
+	}
+}
+
+func pwxform(B *[pwxWords]uint32, ctx *pwxformCtx) {
+	var X [pwxGather][pwxSimple]uint64
+	for j := 0; j < pwxGather; j++ {
+		for k := 0; k < pwxSimple; k++ {
+			o := (j*pwxSimple + k) * 2
+			X[j][k] = uint64(B[o]) | uint64(B[o+1])<<32
+		}
+	}
+
+	S0, S1, S2, w := ctx.S0, ctx.S1, ctx.S2, ctx.w
+
+	for i := 0; i < pwxRounds; i++ {
+		for j := 0; j < pwxGather; j++ {
+			p0 := S0[(uint32(X[j][0])&sMask)/8:]
+			p1 := S1[(uint32(X[j][0]>>32)&sMask)/8:]
+
+			for k := 0; k < pwxSimple; k++ {
+				x := (X[j][k]>>32)*(X[j][k]&0xffffffff) + p0[k]
+				x ^= p1[k]
+				X[j][k] = x
+
+				if i != 0 && i != pwxRounds-1 {
+					S2[w] = x
+					w++
+				}
+			}
+		}
+	}
+
+	ctx.S0, ctx.S1, ctx.S2 = S2, S0, S1
+	ctx.w = w & ((1<<sWidth)*pwxSimple - 1)
+
+	for j := 0; j < pwxGather; j++ {
+		for k := 0; k < pwxSimple; k++ {
+			o := (j*pwxSimple + k) * 2
+			B[o] = uint32(X[j][k])
+			B[o+1] = uint32(X[j][k] >> 32)
+		}
+	}
+}
+
+// Salsa20 core operating on a block in shuffled word order.
+func salsa20(B []uint32, rounds int) {
+	var x [16]uint32
+	for i := 0; i < 16; i++ {
+		x[i*5%16] = B[i]
+	}
+
+	for i := 0; i < rounds; i += 2 {
+		x[4] ^= rotl(x[0]+x[12], 7)
+		x[8] ^= rotl(x[4]+x[0], 9)
+		x[12] ^= rotl(x[8]+x[4], 13)
+		x[0] ^= rotl(x[12]+x[8], 18)
+		x[9] ^= rotl(x[5]+x[1], 7)
+		x[13] ^= rotl(x[9]+x[5], 9)
+		x[1] ^= rotl(x[13]+x[9], 13)
+		x[5] ^= rotl(x[1]+x[13], 18)
+		x[14] ^= rotl(x[10]+x[6], 7)
+		x[2] ^= rotl(x[14]+x[10], 9)
+		x[6] ^= rotl(x[2]+x[14], 13)
+		x[10] ^= rotl(x[6]+x[2], 18)
+		x[3] ^= rotl(x[15]+x[11], 7)
+		x[7] ^= rotl(x[3]+x[15], 9)
+		x[11] ^= rotl(x[7]+x[3], 13)
+		x[15] ^= rotl(x[11]+x[7], 18)
+
+		x[1] ^= rotl(x[0]+x[3], 7)
+		x[2] ^= rotl(x[1]+x[0], 9)
+		x[3] ^= rotl(x[2]+x[1], 13)
+		x[0] ^= rotl(x[3]+x[2], 18)
+		x[6] ^= rotl(x[5]+x[4], 7)
+		x[7] ^= rotl(x[6]+x[5], 9)
+		x[4] ^= rotl(x[7]+x[6], 13)
+		x[5] ^= rotl(x[4]+x[7], 18)
+		x[11] ^= rotl(x[10]+x[9], 7)
+		x[8] ^= rotl(x[11]+x[10], 9)
+		x[9] ^= rotl(x[8]+x[11], 13)
+		x[10] ^= rotl(x[9]+x[8], 18)
+		x[12] ^= rotl(x[15]+x[14], 7)
+		x[13] ^= rotl(x[12]+x[15], 9)
+		x[14] ^= rotl(x[13]+x[12], 13)
+		x[15] ^= rotl(x[14]+x[13], 18)
+	}
+
+	for i := 0; i < 16; i++ {
+		B[i] += x[i*5%16]
+	}
+}
+
+func rotl(x uint32, n uint) uint32 {
+	return (x << n) | (x >> (32 - n))
+}
+
+func blkxor(dst, src []uint32) {
+	for i := range dst {
+		dst[i] ^= src[i]
+	}
+}
+
+func integerify(X []uint32, r int) uint64 {
+	o := (2*r - 1) * 16
+	return uint64(X[o+13])<<32 | uint64(X[o])
+}
+
+func p2floor(x uint64) uint64 {
+	for y := x & (x - 1); y != 0; y = x & (x - 1) {
+		x = y
+	}
+
+	return x
+}
+
+func wrap(x, i uint64) uint64 {
+	n := p2floor(i)
+	return (x & (n - 1)) + (i - n)
+}
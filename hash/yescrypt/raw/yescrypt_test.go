@@ -0,0 +1,132 @@
+package raw
+
+import (
+	"strings"
+	"testing"
+)
+
+// Generated using libxcrypt's crypt(3).
+var tests = []struct {
+	password string
+	hash     string
+}{
+	{"", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$5P1uc1zvKhieqEtKttbwCQrTPXpY1cK9wEnTDKAqLD8"},
+	{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC"},
+	{"U*U*U*U*", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$gWdKBHTN0dDY5OGHtWaKYbUMXcUCQAK37YXRaFA.2qD"},
+	{"", "$y$j75$abcdefgh$dnXG1KuMBmwYrnuKh9nriQRakI62k6mwFrGgxhxYD/2"},
+	{"password", "$y$j75$abcdefgh$hycRI05iyPwUdPZDaw/SJaABdj2h8B1Bgxi1Z/4Xke0"},
+	{"U*U*U*U*", "$y$j75$abcdefgh$D6RJncecA5fh930WxylhG6kANwDvO/fdHYhE83GTcd9"},
+	{"", "$y$jC5$saltsalt$l5RGCbsiV50dKorNcwNFCeCvihisoOz00Bg4SJYvDD5"},
+	{"password", "$y$jC5$saltsalt$lqnlUGUVNHcP4c86dvjKdcnK8wNAOPBD0xotnK.AIN7"},
+	{"U*U*U*U*", "$y$jC5$saltsalt$ywjxjU35INPpCJSKSDqQK72crd0h4j9jxkPKJyDqDHD"},
+	{"", "$y$j95.9$abcdefgh$X3xmshegtZNjWNvkmEniPfk44.PI.A2o4c/Q/c9yYb5"},
+	{"password", "$y$j95.9$abcdefgh$RoNKz4RNZAWSihCJW5CnG03ahKu7GeUV7rPrHdI/5KD"},
+	{"U*U*U*U*", "$y$j95.9$abcdefgh$mu7Fb5/.eAurPzPKY332H3KwOotyxdFHTlL24zlctZ1"},
+	{"", "$y$jA5/7$abcdefgh$OP8gDblnnuiqD0RHgusLpNln0X3ax9.cq357PL0.qj/"},
+	{"password", "$y$jA5/7$abcdefgh$DvM7duN02f5/cDCKS6QQSYaqB4vxGngaP4W5FPvqJ0B"},
+	{"U*U*U*U*", "$y$jA5/7$abcdefgh$ll37IenvreeYdpVC4Ma8ue67WoR3/iG.XMhy9lF5u9/"},
+	{"", "$y$j85/0$abcd$d9pfqykxRdu7o/8tZus97vr56jwN8ElV./swWQKYmN5"},
+	{"password", "$y$j85/0$abcd$JCbOZftG8hCV0.CVRmlu9i6enYrYjfVzjHuA8QHxrd0"},
+	{"U*U*U*U*", "$y$j85/0$abcd$YKA0m4yPYn2cge5To07n1JYt4ebf/YN/XF38uijZK6."},
+	{"", "$y$j9T$$EBiO75E.nlsNSY6EaCJLzant.b1uUsOgoDdj78FKfO7"},
+	{"password", "$y$j9T$$8GphBPUYahATxqgj0nfonf6iSyOHvCy5v.9VnYW6c15"},
+	{"U*U*U*U*", "$y$j9T$$9obIq7yqvZKY3SYzuGRZbefX6sCYuvyVUF7HNTbo/2B"},
+	// WORM and classic scrypt flavours.
+	{"password", "$y$/A2$abcd$4cCfElNgc3ajDUDTiqWhCr47S9M8zzReKSWOTbqzQD7"},
+	{"password", "$y$/A2/7$abcd$SGFMdc0R.1BmzsX/0UUUK7tSMsYLCiKBiU40OyMaKNC"},
+	{"password", "$y$.A2$abcd$JTnymDyGaGOdDg/7aqFU2R0EFfpmUUs.0XlA2Ri2CWD"},
+	{"password", "$y$.75$abcd$g.EVXHytpsfPG8NDph9OJrE9Xi8z1QL1LKjVYVXM0R9"},
+	// gost-yescrypt.
+	{"", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$1l4FiPZDsBJqLHMqIl51VKGAfCw/dvDP8DLxv7jzBT."},
+	{"password", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$Dogv.jai3UfiqXFIeQV0FWiA2xx/QPuuov.EGnMByDD"},
+	{"U*U*U*U*", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$loSk.1d8JWWrcEiyyYcEXXv6aJQUBa3RexRXbvzdCs."},
+	{"", "$gy$j75$abcdefgh$kXu23tO3JrqsPirjFN6nRuYr3dv4cWi2qLiROrZg5FB"},
+	{"password", "$gy$j75$abcdefgh$SiB8.pWE7jyZrxlpFkF/M/mtY4w7Fs26/zSh1ZTO3I4"},
+	{"U*U*U*U*", "$gy$j75$abcdefgh$uNATOxsUnYvUz/VyHR6ter3XH3yKPTI5dEGcNfamzKD"},
+	{"password", "$gy$jC5$saltsalt$hLswM.W6CfNrbW0KWE/GyxPmx6UycRP88sJx.VeSuz5"},
+	{"password", "$gy$j9T$$d2YXc18lv8pd79sLJaa2ly5yldVNTtrfeuRhUTy3QO2"},
+	{strings.Repeat("0123456789", 13), "$gy$j75$abcdefgh$UanCC3YYL8RK7ixX3LNRRr.tAD0YCAUnPEGLqxlds48"},
+}
+
+func TestYescrypt(t *testing.T) {
+	for _, tst := range tests {
+		out, err := CryptSetting(tst.password, tst.hash)
+		if err != nil {
+			t.Errorf("error: %v (%#v)", err, tst.hash)
+		} else if out != tst.hash {
+			t.Errorf("mismatch:\n  got: %#v\n  expected: %#v\n  password: %#v\n",
+				out, tst.hash, tst.password)
+		}
+	}
+}
+
+func TestParams(t *testing.T) {
+	params, salt, _, err := Parse("$y$j9T$F5Jx5fExrKuPp53xLKQ..1")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if params != (Params{Flags: FlagDefaults, N: 4096, R: 32, P: 1}) || len(salt) != 16 {
+		t.Fatalf("unexpected parse result: %+v %d", params, len(salt))
+	}
+
+	for _, s := range []string{"j9T", "j95.9", "jA5/7", "j85/0", "/A2/7", ".A2"} {
+		params, rest, err := DecodeParams(s)
+		if err != nil || rest != "" {
+			t.Fatalf("cannot parse %q: %v", s, err)
+		}
+
+		e, err := EncodeParams(params)
+		if err != nil || e != s {
+			t.Errorf("params do not round trip: %q -> %+v -> %q (%v)", s, params, e, err)
+		}
+	}
+
+	for _, s := range []string{
+		"$y$j9T$F5Jx5fExrKuPp53xLKQ..2",          // non-canonical salt
+		"$y$j9T$abcdef",                          // non-canonical salt
+		"$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahC", // short hash
+		"$y$jD51/$F5Jx5fExrKuPp53xLKQ..1",        // unsupported g
+		"$y$j9TF5Jx5fExrKuPp53xLKQ..1",           // missing separator
+		"$7$CU..../....abcdefgh",                 // wrong scheme
+	} {
+		if _, _, _, err := Parse(s); err == nil {
+			t.Errorf("expected parse error: %q", s)
+		}
+	}
+}
+
+func TestMaxMemory(t *testing.T) {
+	for _, tst := range []struct {
+		params Params
+		ok     bool
+	}{
+		{Params{Flags: FlagDefaults, N: 1 << 18, R: 32, P: 1}, true},
+		{Params{Flags: FlagDefaults, N: 1 << 19, R: 32, P: 1}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 40, R: 8, P: 1}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 12, R: 1 << 20, P: 1}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 12, R: 32, P: 1 << 20}, false},
+		{Params{N: 1 << 31, R: 1 << 24, P: 1}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 18, R: 32, P: 1, T: 7}, true},
+		{Params{Flags: FlagDefaults, N: 1 << 18, R: 32, P: 1, T: 8}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 12, R: 32, P: 1, T: 255}, true},
+		{Params{Flags: FlagDefaults, N: 1 << 12, R: 32, P: 1, T: 1 << 30}, false},
+		{Params{Flags: FlagDefaults, N: 1 << 1, R: 1, P: 1, T: 1<<25 - 1}, true},
+		{Params{Flags: FlagDefaults, N: 1 << 1, R: 1, P: 1, T: 1 << 25}, false},
+	} {
+		s, err := EncodeParams(tst.params)
+		if err != nil {
+			t.Fatalf("cannot encode %+v: %v", tst.params, err)
+		}
+
+		_, _, _, err = Parse("$y$" + s + "$F5Jx5fExrKuPp53xLKQ..1")
+		if (err == nil) != tst.ok {
+			t.Errorf("unexpected result parsing %+v (%q): %v", tst.params, s, err)
+		}
+	}
+
+	// t = 2^30.
+	if _, _, _, err := Parse("$y$j75/zyxvrD$F5Jx5fExrKuPp53xLKQ..1"); err != ErrInvalidParams {
+		t.Errorf("excessive time cost accepted: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package yescrypt
+
+import (
+	"strconv"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+var registryParams = []registry.Param{
+	{Name: "n", Type: registry.Int, Default: strconv.Itoa(raw.RecommendedN), Min: 2, Max: 1 << 30, Doc: "CPU/memory cost (a power of two)"},
+	{Name: "r", Type: registry.Int, Default: strconv.Itoa(raw.Recommendedr), Min: 1, Max: 1<<31 - 1, Doc: "block size"},
+}
+
+func newFunc(gost bool) func(registry.Args) (abstract.Scheme, error) {
+	return func(args registry.Args) (abstract.Scheme, error) {
+		N := args.Int("n")
+		if N&(N-1) != 0 {
+			return nil, registry.ErrInvalidParam
+		}
+
+		return &scheme{nN: uint64(N), r: uint32(args.Int("r")), gost: gost}, nil
+	}
+}
+
+func init() {
+	registry.Register(&registry.Factory{
+		Name:   "yescrypt",
+		Doc:    "yescrypt ($y$)",
+		Params: registryParams,
+		New:    newFunc(false),
+	})
+	registry.Register(&registry.Factory{
+		Name:   "gost-yescrypt",
+		Doc:    "gost-yescrypt ($gy$)",
+		Params: registryParams,
+		New:    newFunc(true),
+	})
+}
@@ -0,0 +1,137 @@
+// Package yescrypt implements the yescrypt password hashing mechanism, wrapped
+// in the $y$ modular crypt format used by libxcrypt.
+//
+// This is the default password hashing scheme for /etc/shadow on many modern
+// Linux distributions. The gost-yescrypt variant ($gy$), which additionally
+// applies HMAC-Streebog to the yescrypt output, is also supported.
+package yescrypt
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/yescrypt/raw"
+)
+
+// An implementation of Scheme performing yescrypt.
+//
+// Uses the recommended values for N and r defined in raw.
+var Crypter abstract.Scheme
+
+// An implementation of Scheme performing gost-yescrypt.
+//
+// Uses the recommended values for N and r defined in raw.
+var GOSTCrypter abstract.Scheme
+
+const saltLength = 16
+
+func init() {
+	Crypter = New(raw.RecommendedN, raw.Recommendedr)
+	GOSTCrypter = NewGOST(raw.RecommendedN, raw.Recommendedr)
+}
+
+// Returns an implementation of Scheme implementing yescrypt with the
+// specified parameters.
+func New(N uint64, r uint32) abstract.Scheme {
+	return &scheme{
+		nN: N,
+		r:  r,
+	}
+}
+
+// Returns an implementation of Scheme implementing gost-yescrypt with the
+// specified parameters.
+func NewGOST(N uint64, r uint32) abstract.Scheme {
+	return &scheme{
+		nN:   N,
+		r:    r,
+		gost: true,
+	}
+}
+
+type scheme struct {
+	nN   uint64
+	r    uint32
+	gost bool
+}
+
+func (c *scheme) SetParams(N uint64, r uint32) error {
+	c.nN = N
+	c.r = r
+	return nil
+}
+
+func (c *scheme) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, c.prefix())
+}
+
+func (c *scheme) Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	if c.gost {
+		return raw.CryptGOST(password, salt, c.params())
+	}
+
+	return raw.Crypt(password, salt, c.params())
+}
+
+func (c *scheme) Verify(password, hash string) (err error) {
+	if !c.SupportsStub(hash) {
+		return abstract.ErrUnsupportedScheme
+	}
+
+	newHash, err := raw.CryptSetting(password, hash)
+	if err == nil && !abstract.SecureCompare(hash, newHash) {
+		err = abstract.ErrInvalidPassword
+	}
+
+	return
+}
+
+func (c *scheme) NeedsUpdate(stub string) bool {
+	if !c.SupportsStub(stub) {
+		return false
+	}
+
+	params, salt, _, err := raw.Parse(stub)
+	if err != nil {
+		return false // ...
+	}
+
+	return c.needsUpdate(params, salt)
+}
+
+func (c *scheme) needsUpdate(params raw.Params, salt []byte) bool {
+	return params.Flags != raw.FlagDefaults || len(salt) < saltLength || params.N < c.nN || params.R < c.r
+}
+
+func (c *scheme) params() raw.Params {
+	return raw.Params{
+		Flags: raw.FlagDefaults,
+		N:     c.nN,
+		R:     c.r,
+		P:     1,
+	}
+}
+
+func (c *scheme) prefix() string {
+	if c.gost {
+		return "$gy$"
+	}
+
+	return "$y$"
+}
+
+func (c *scheme) String() string {
+	if c.gost {
+		return fmt.Sprintf("gost-yescrypt(%d,%d)", c.nN, c.r)
+	}
+
+	return fmt.Sprintf("yescrypt(%d,%d)", c.nN, c.r)
+}
@@ -0,0 +1,182 @@
+package passlib
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// The placeholder output in place of a redacted hash.
+const Redacted = "[redacted]"
+
+// Indicates that a redacted placeholder was decoded as a hash value.
+var ErrRedacted = fmt.Errorf("hash value is redacted")
+
+// Indicates that a hash value cannot be scanned from a database value of the
+// given type.
+var ErrUnsupportedType = fmt.Errorf("unsupported type for hash value")
+
+// A stored password hash, for use as a field type in database models. It
+// implements sql.Scanner and driver.Valuer, so it can be read from and
+// written to a text column directly, and json.Marshaler and
+// encoding.TextMarshaler.
+//
+// To prevent hashes leaking into logs and API responses, the JSON and text
+// encodings and the output of String are Redacted unless Reveal is set. The
+// database value is always the hash itself. An empty hash is stored as NULL.
+type HashValue struct {
+	// The context used to identify the scheme of the hash, verify passwords
+	// and issue upgrade hashes. If nil, DefaultContext is used.
+	Context *Context
+
+	// If true, MarshalJSON and MarshalText output the hash rather than
+	// Redacted.
+	Reveal bool
+
+	hash   string
+	scheme abstract.Scheme
+}
+
+// Creates a hash value holding an existing hash. ctx may be nil.
+func NewHashValue(hash string, ctx *Context) *HashValue {
+	v := &HashValue{Context: ctx}
+	v.Set(hash)
+	return v
+}
+
+func (v *HashValue) context() *Context {
+	if v.Context == nil {
+		return &DefaultContext
+	}
+
+	return v.Context
+}
+
+// Returns the hash, or "" if there is none.
+func (v *HashValue) Hash() string {
+	return v.hash
+}
+
+// Replaces the hash and identifies its scheme.
+func (v *HashValue) Set(hash string) {
+	v.hash = hash
+	v.scheme = nil
+	if hash != "" {
+		v.scheme, _ = v.context().Identify(hash)
+	}
+}
+
+// Hashes password using the preferred scheme of the context and stores the
+// result.
+func (v *HashValue) SetPassword(password string) error {
+	hash, err := v.context().Hash(password)
+	if err != nil {
+		return err
+	}
+
+	v.Set(hash)
+	return nil
+}
+
+// Returns the scheme of the hash, as identified by the context when the hash
+// was set, or nil if the hash is empty or no scheme in the context supports
+// it.
+func (v *HashValue) Scheme() abstract.Scheme {
+	return v.scheme
+}
+
+// Verifies password against the hash. Returns nil err only if the password is
+// valid.
+//
+// If the context issues an upgrade hash, it replaces the stored hash and
+// updated is true; the caller should then save the value.
+func (v *HashValue) Verify(password string) (updated bool, err error) {
+	if v.hash == "" {
+		return false, abstract.ErrInvalidPassword
+	}
+
+	newHash, err := v.context().Verify(password, v.hash)
+	if err != nil {
+		return false, err
+	}
+
+	if newHash != "" {
+		v.Set(newHash)
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// Determines whether the hash needs updating according to the policy of the
+// context.
+func (v *HashValue) NeedsUpdate() bool {
+	return v.hash != "" && v.context().NeedsUpdate(v.hash)
+}
+
+// Implements sql.Scanner. src may be a string, a []byte or nil.
+func (v *HashValue) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case nil:
+		v.Set("")
+	case string:
+		v.Set(s)
+	case []byte:
+		v.Set(string(s))
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, src)
+	}
+
+	return nil
+}
+
+// Implements driver.Valuer.
+func (v HashValue) Value() (driver.Value, error) {
+	if v.hash == "" {
+		return nil, nil
+	}
+
+	return v.hash, nil
+}
+
+func (v HashValue) text() string {
+	if v.Reveal {
+		return v.hash
+	}
+
+	return Redacted
+}
+
+// Implements json.Marshaler. Outputs Redacted unless Reveal is set.
+func (v HashValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(v.text())
+}
+
+// Implements encoding.TextMarshaler. Outputs Redacted unless Reveal is set.
+func (v HashValue) MarshalText() ([]byte, error) {
+	return []byte(v.text()), nil
+}
+
+// Implements encoding.TextUnmarshaler, and so also decodes JSON strings.
+// Returns ErrRedacted if text is Redacted.
+func (v *HashValue) UnmarshalText(text []byte) error {
+	if string(text) == Redacted {
+		return ErrRedacted
+	}
+
+	v.Set(string(text))
+	return nil
+}
+
+// Returns Redacted, so that formatting a hash value does not reveal the hash.
+func (v HashValue) String() string {
+	return Redacted
+}
+
+// Returns Redacted, so that formatting a hash value with %#v does not reveal
+// the hash.
+func (v HashValue) GoString() string {
+	return Redacted
+}
@@ -0,0 +1,93 @@
+package passlib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+)
+
+const testBcryptHash = "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6"
+
+func TestHashValue(t *testing.T) {
+	ctx := &Context{Schemes: []abstract.Scheme{sha2crypt.Crypter512, bcrypt.Crypter}}
+
+	var v HashValue
+	v.Context = ctx
+	if err := v.Scan([]byte(testBcryptHash)); err != nil || v.Hash() != testBcryptHash || v.Scheme() != bcrypt.Crypter {
+		t.Fatalf("cannot scan: %v, %q, %v", err, v.Hash(), v.Scheme())
+	}
+
+	if !v.NeedsUpdate() {
+		t.Errorf("non-preferred scheme does not need update")
+	}
+
+	if _, err := v.Verify("wrong"); err == nil {
+		t.Errorf("wrong password accepted")
+	}
+
+	updated, err := v.Verify("password")
+	if err != nil || !updated || v.Scheme() != sha2crypt.Crypter512 || v.NeedsUpdate() {
+		t.Fatalf("not upgraded: %v, %v, %q", err, updated, v.Hash())
+	}
+
+	if value, err := v.Value(); err != nil || value != v.Hash() {
+		t.Errorf("unexpected value: %v, %v", value, err)
+	}
+
+	// Hashes must not leak through any encoding other than the database value.
+	b, err := json.Marshal(struct{ Password HashValue }{v})
+	if err != nil || string(b) != `{"Password":"[redacted]"}` {
+		t.Errorf("unexpected JSON: %s, %v", b, err)
+	}
+
+	for _, s := range []string{fmt.Sprint(v), fmt.Sprintf("%v %+v %#v %s", v, v, v, &v)} {
+		if strings.Contains(s, "$6$") {
+			t.Errorf("hash leaked: %s", s)
+		}
+	}
+
+	if err := json.Unmarshal(b, &struct{ Password *HashValue }{&v}); !errors.Is(err, ErrRedacted) {
+		t.Errorf("redacted hash decoded: %v", err)
+	}
+
+	v.Reveal = true
+	b, err = json.Marshal(v)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	w := HashValue{Context: ctx}
+	if err := json.Unmarshal(b, &w); err != nil || w.Hash() != v.Hash() || w.Scheme() != sha2crypt.Crypter512 {
+		t.Errorf("cannot decode revealed JSON: %s, %v", b, err)
+	}
+
+	if err := w.Scan(nil); err != nil || w.Hash() != "" || w.Scheme() != nil || w.NeedsUpdate() {
+		t.Errorf("cannot scan NULL: %v", err)
+	}
+
+	if value, err := w.Value(); err != nil || value != nil {
+		t.Errorf("empty hash not stored as NULL: %v, %v", value, err)
+	}
+
+	if _, err := w.Verify(""); err == nil {
+		t.Errorf("empty hash verified")
+	}
+
+	if err := w.Scan(42); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := w.SetPassword("password"); err != nil || w.Scheme() != sha2crypt.Crypter512 {
+		t.Fatalf("cannot set password: %v", err)
+	}
+
+	if updated, err := NewHashValue(w.Hash(), ctx).Verify("password"); err != nil || updated {
+		t.Errorf("cannot verify: %v, %v", err, updated)
+	}
+}
@@ -0,0 +1,105 @@
+package passlib
+
+import (
+	"fmt"
+	"time"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// The kind of an Event.
+type EventKind string
+
+const (
+	// A password was hashed by Context.Hash.
+	EventHash EventKind = "hash"
+
+	// A password was verified successfully.
+	EventVerifySuccess EventKind = "verify-success"
+
+	// A password did not match the hash, or the hash was malformed.
+	EventVerifyFailure EventKind = "verify-failure"
+
+	// An upgrade hash was issued after a successful verification.
+	EventUpgradeIssued EventKind = "upgrade-issued"
+
+	// An upgrade hash could not be hashed after a successful verification. The
+	// Err field of the event holds the error.
+	EventUpgradeFailed EventKind = "upgrade-failed"
+
+	// A hash needing an update was verified successfully, but no upgrade hash
+	// was issued because the caller used VerifyNoUpgrade.
+	EventUpgradeDeferred EventKind = "upgrade-deferred"
+
+	// No scheme in the context supports the hash.
+	EventUnsupportedScheme EventKind = "unsupported-scheme"
+
+	// The hash uses one of the disabled schemes of the context.
+	EventDisabledScheme EventKind = "disabled-scheme"
+
+	// The hash exceeds the limits of the context, and was not verified.
+	EventLimitExceeded EventKind = "limit-exceeded"
+)
+
+// Describes an operation of a Context, for audit logging. Events never carry
+// passwords or hashes.
+type Event struct {
+	Kind EventKind
+
+	// The name of the scheme involved, as returned by its String method, or
+	// "" if there is none. For upgrade events, this is the scheme of the hash
+	// being upgraded; the upgrade hash uses the preferred scheme.
+	Scheme string
+
+	// The time taken by the operation: hashing for EventHash,
+	// EventUpgradeIssued and EventUpgradeFailed, and verification for
+	// EventVerifySuccess, EventVerifyFailure and EventUpgradeDeferred.
+	Elapsed time.Duration
+
+	// The reasons the hash needs updating, for EventVerifySuccess,
+	// EventUpgradeIssued, EventUpgradeFailed and EventUpgradeDeferred.
+	UpdateReasons []UpdateReason
+
+	// The error, for EventVerifyFailure and EventUpgradeFailed, and for
+	// EventHash if hashing failed.
+	Err error
+}
+
+// Receives events from a Context. Notify is called synchronously, possibly
+// from multiple goroutines at once, so it should not block.
+type Hook interface {
+	Notify(e Event)
+}
+
+// Adapts a function to the Hook interface.
+type HookFunc func(e Event)
+
+// Calls f(e).
+func (f HookFunc) Notify(e Event) {
+	f(e)
+}
+
+func (ctx *Context) notify(kind EventKind, scheme abstract.Scheme, elapsed time.Duration, reasons []UpdateReason, err error) {
+	if ctx.Hook == nil {
+		return
+	}
+
+	ctx.Hook.Notify(Event{
+		Kind:          kind,
+		Scheme:        schemeName(scheme),
+		Elapsed:       elapsed,
+		UpdateReasons: reasons,
+		Err:           err,
+	})
+}
+
+func schemeName(scheme abstract.Scheme) string {
+	switch s := scheme.(type) {
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return s.String()
+	default:
+		return fmt.Sprintf("%T", scheme)
+	}
+}
@@ -0,0 +1,95 @@
+package passlib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/windows"
+)
+
+func TestHooks(t *testing.T) {
+	var events []Event
+	c := Context{
+		Schemes:  []abstract.Scheme{sha2crypt.Crypter256, bcrypt.Crypter, windows.NTCrypter},
+		Disabled: []abstract.Scheme{md5crypt.Crypter},
+		ExceedsLimits: func(hash string) bool {
+			return strings.HasPrefix(hash, "$5$rounds=999999999$")
+		},
+		Hook: HookFunc(func(e Event) {
+			events = append(events, e)
+		}),
+	}
+
+	expect := func(kinds ...EventKind) {
+		t.Helper()
+		if len(events) != len(kinds) {
+			t.Fatalf("unexpected events: %+v, expected %v", events, kinds)
+		}
+
+		for i, kind := range kinds {
+			if events[i].Kind != kind {
+				t.Fatalf("unexpected events: %+v, expected %v", events, kinds)
+			}
+		}
+
+		events = nil
+	}
+
+	hash, err := c.Hash("password")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	expect(EventHash)
+
+	c.Verify("password", hash)
+	expect(EventVerifySuccess)
+
+	c.Verify("wrong", hash)
+	expect(EventVerifyFailure)
+
+	const bcryptHash = "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6"
+	c.Verify("password", bcryptHash)
+	e := events[1]
+	expect(EventVerifySuccess, EventUpgradeIssued)
+	if e.Scheme != fmt.Sprint(bcrypt.Crypter) || fmt.Sprint(e.UpdateReasons) != "[not-preferred outdated]" || e.Elapsed <= 0 {
+		t.Errorf("unexpected upgrade event: %+v", e)
+	}
+
+	c.VerifyNoUpgrade("password", bcryptHash)
+	expect(EventVerifySuccess, EventUpgradeDeferred)
+
+	if _, err := c.Verify("password", "$1$ab$oKsM6dtDD2L1bKowOBX.7."); err != abstract.ErrDisabledScheme {
+		t.Errorf("disabled scheme accepted: %v", err)
+	}
+
+	expect(EventDisabledScheme)
+
+	if _, err := c.Verify("password", "$5$rounds=999999999$ab$"); err != abstract.ErrLimitExceeded {
+		t.Errorf("limit not applied: %v", err)
+	}
+
+	expect(EventLimitExceeded)
+
+	c.Verify("password", "$unknown$")
+	expect(EventUnsupportedScheme)
+
+	c.Schemes = []abstract.Scheme{windows.NTCrypter}
+	if _, err := c.Hash("password"); err == nil {
+		t.Fatalf("verify-only context hashed password")
+	}
+
+	expect(EventHash)
+
+	c.Verify("password", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+	e = events[1]
+	expect(EventVerifySuccess, EventUpgradeFailed)
+	if e.Err != abstract.ErrVerifyOnly {
+		t.Errorf("unexpected upgrade failure event: %+v", e)
+	}
+}
@@ -0,0 +1,295 @@
+// Package htpasswd reads and writes Apache htpasswd files and verifies
+// passwords against them.
+//
+// Comments, blank lines and the order of entries are preserved when a file is
+// rewritten, so files maintained by hand or by Apache's htpasswd tool can be
+// managed safely.
+package htpasswd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/descrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/internal/atomicfile"
+)
+
+// Indicates that a user does not have an entry in the file.
+var ErrUnknownUser = fmt.Errorf("unknown htpasswd user")
+
+// Indicates that a user name cannot be stored in an htpasswd file, because it
+// is empty, begins with "#" or contains a colon or line break.
+var ErrInvalidUser = fmt.Errorf("invalid htpasswd user name")
+
+// Indicates that a hash cannot be stored in an htpasswd file, or that a
+// "{SHA}" hash is malformed.
+var ErrInvalidHash = fmt.Errorf("invalid htpasswd hash")
+
+// The context used by files which do not specify one. It hashes with bcrypt
+// and verifies the formats supported by Apache: bcrypt, apr1, sha512-crypt,
+// sha256-crypt, md5-crypt, "{SHA}" and traditional DES-based crypt.
+var DefaultContext passlib.Context
+
+func init() {
+	SHACrypter = &shaCrypter{}
+	DefaultContext.Schemes = []abstract.Scheme{
+		bcrypt.Crypter,
+		md5crypt.APR1Crypter,
+		sha2crypt.Crypter512,
+		sha2crypt.Crypter256,
+		md5crypt.Crypter,
+		SHACrypter,
+		descrypt.Crypter,
+	}
+}
+
+// An htpasswd file. Each entry is a line of the form "user:hash". Other lines
+// are preserved but otherwise ignored. If a user has several entries, the
+// first is used, as by Apache.
+//
+// A File is not safe for concurrent use.
+type File struct {
+	// The context used to hash and verify passwords. If nil, DefaultContext
+	// is used.
+	Context *passlib.Context
+
+	lines []string
+	users map[string]int // user -> index in lines
+}
+
+// Returns an empty file.
+func New() *File {
+	return &File{users: map[string]int{}}
+}
+
+// Parses an htpasswd file.
+func Parse(r io.Reader) (*File, error) {
+	f := New()
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		f.lines = append(f.lines, s.Text())
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	f.index()
+	return f, nil
+}
+
+// Loads the htpasswd file at path.
+func Load(path string) (*File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return Parse(r)
+}
+
+// Locks the htpasswd file at path, loads it, calls fn to modify it and saves
+// it if fn returns nil. A new file is created if none exists. The lock file is
+// path+".lock"; atomicfile.ErrLocked is returned if it already exists.
+func Update(path string, ctx *passlib.Context, fn func(f *File) error) error {
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := Load(path)
+	if os.IsNotExist(err) {
+		f = New()
+	} else if err != nil {
+		return err
+	}
+
+	f.Context = ctx
+	if err := fn(f); err != nil {
+		return err
+	}
+
+	return f.Save(path)
+}
+
+func (f *File) context() *passlib.Context {
+	if f.Context == nil {
+		return &DefaultContext
+	}
+
+	return f.Context
+}
+
+// Rebuilds the user index.
+func (f *File) index() {
+	f.users = map[string]int{}
+	for i, line := range f.lines {
+		if user, _, ok := parseLine(line); ok {
+			if _, dup := f.users[user]; !dup {
+				f.users[user] = i
+			}
+		}
+	}
+}
+
+func parseLine(line string) (user, hash string, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	if line == "" || line[0] == '#' {
+		return "", "", false
+	}
+
+	i := strings.IndexByte(line, ':')
+	if i <= 0 {
+		return "", "", false
+	}
+
+	return line[0:i], line[i+1:], true
+}
+
+// Returns the users in the file, in file order.
+func (f *File) Users() []string {
+	var users []string
+	for i, line := range f.lines {
+		if user, _, ok := parseLine(line); ok && f.users[user] == i {
+			users = append(users, user)
+		}
+	}
+
+	return users
+}
+
+// Returns the hash for a user.
+func (f *File) Get(user string) (hash string, ok bool) {
+	i, ok := f.users[user]
+	if !ok {
+		return "", false
+	}
+
+	_, hash, _ = parseLine(f.lines[i])
+	return hash, true
+}
+
+// Sets the hash for a user. An existing entry is replaced in place; otherwise
+// an entry is appended to the file.
+func (f *File) Set(user, hash string) error {
+	if user == "" || user[0] == '#' || strings.ContainsAny(user, ":\r\n") {
+		return ErrInvalidUser
+	}
+
+	if strings.ContainsAny(hash, "\r\n") {
+		return ErrInvalidHash
+	}
+
+	line := user + ":" + hash
+	if i, ok := f.users[user]; ok {
+		f.lines[i] = line
+		return nil
+	}
+
+	f.users[user] = len(f.lines)
+	f.lines = append(f.lines, line)
+	return nil
+}
+
+// Hashes a password using the preferred scheme of the file's context and
+// sets it as the hash for a user.
+func (f *File) SetPassword(user, password string) error {
+	hash, err := f.context().Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return f.Set(user, hash)
+}
+
+// Removes all entries for a user. Returns false if there were none.
+func (f *File) Delete(user string) bool {
+	if _, ok := f.users[user]; !ok {
+		return false
+	}
+
+	lines := f.lines[:0]
+	for _, line := range f.lines {
+		if u, _, ok := parseLine(line); !ok || u != user {
+			lines = append(lines, line)
+		}
+	}
+
+	f.lines = lines
+	f.index()
+	return true
+}
+
+// Verifies a user's password. Returns nil err only if the password is valid.
+//
+// If the context issues an upgrade hash, the user's entry is replaced with it
+// and updated is true; the caller should then save the file.
+func (f *File) Verify(user, password string) (updated bool, err error) {
+	hash, ok := f.Get(user)
+	if !ok {
+		return false, ErrUnknownUser
+	}
+
+	newHash, err := f.context().Verify(password, hash)
+	if err != nil {
+		return false, err
+	}
+
+	if newHash == "" {
+		return false, nil
+	}
+
+	return true, f.Set(user, newHash)
+}
+
+// Returns the users whose hashes need updating according to the policy of
+// the file's context, in file order. This includes users whose hashes are not
+// supported by the context.
+func (f *File) NeedsUpdate() []string {
+	ctx := f.context()
+
+	var users []string
+	for _, user := range f.Users() {
+		hash, _ := f.Get(user)
+		if scheme, _ := ctx.Identify(hash); scheme == nil || ctx.NeedsUpdate(hash) {
+			users = append(users, user)
+		}
+	}
+
+	return users
+}
+
+// Writes the file in htpasswd format.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, line := range f.lines {
+		m, err := io.WriteString(w, line+"\n")
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Saves the file to path, replacing any existing file atomically and
+// preserving its permissions. A new file is created with mode 0640.
+func (f *File) Save(path string) error {
+	return atomicfile.Write(path, 0640, func(w io.Writer) error {
+		_, err := f.WriteTo(w)
+		return err
+	})
+}
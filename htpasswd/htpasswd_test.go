@@ -0,0 +1,168 @@
+package htpasswd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/internal/atomicfile"
+)
+
+// Every entry has the password "password".
+const testFile = `# managed by hand
+alice:$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6
+bob:$apr1$ab$vZXhMKiOqO1yMl8FLQFrs0
+
+carol:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=
+dave:abJnggxhB/yWI
+erin:$6$rounds=6000$saltsalt$/2IBlcXmbix2Pz9EgdXxip0TT5bhdAIoou2966MTCS0JSxQOJIShgxlWLJ5Av3M2MKGiWxJvU123K4YDk.AKG.
+frank:$1$ab$oKsM6dtDD2L1bKowOBX.7.
+not an entry
+alice:ignored
+`
+
+func testContext() *passlib.Context {
+	schemes := append([]abstract.Scheme{bcrypt.New(4)}, DefaultContext.Schemes[1:]...)
+	return &passlib.Context{Schemes: schemes}
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := []string{"alice", "bob", "carol", "dave", "erin", "frank"}
+	if u := f.Users(); !reflect.DeepEqual(u, users) {
+		t.Errorf("unexpected users: %v", u)
+	}
+
+	var b bytes.Buffer
+	if _, err := f.WriteTo(&b); err != nil || b.String() != testFile {
+		t.Errorf("file not preserved: %q %v", b.String(), err)
+	}
+
+	f.Context = testContext()
+	for _, user := range users {
+		// Only alice's hash is current; the rest are upgraded.
+		if updated, err := f.Verify(user, "password"); err != nil || updated != (user != "alice") {
+			t.Errorf("%s: cannot verify: %v %v", user, updated, err)
+		}
+
+		if _, err := f.Verify(user, "wrong"); err == nil {
+			t.Errorf("%s: wrong password accepted", user)
+		}
+	}
+
+	if _, err := f.Verify("nobody", "password"); err != ErrUnknownUser {
+		t.Errorf("unknown user: %v", err)
+	}
+}
+
+func TestModify(t *testing.T) {
+	f, err := Parse(strings.NewReader(testFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Context = testContext()
+	if u := f.NeedsUpdate(); !reflect.DeepEqual(u, []string{"bob", "carol", "dave", "erin", "frank"}) {
+		t.Errorf("unexpected users needing update: %v", u)
+	}
+
+	// Verifying an outdated hash upgrades it in place.
+	if updated, err := f.Verify("bob", "password"); err != nil || !updated {
+		t.Fatalf("no upgrade: %v %v", updated, err)
+	}
+
+	if hash, _ := f.Get("bob"); !strings.HasPrefix(hash, "$2a$04$") {
+		t.Errorf("unexpected upgraded hash: %q", hash)
+	}
+
+	if err := f.SetPassword("grace", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Verify("grace", "secret"); err != nil {
+		t.Errorf("cannot verify new user: %v", err)
+	}
+
+	if !f.Delete("alice") || f.Delete("alice") {
+		t.Errorf("unexpected result deleting user")
+	}
+
+	if _, ok := f.Get("alice"); ok {
+		t.Errorf("user not deleted")
+	}
+
+	for _, user := range []string{"", "#x", "a:b", "a\nb"} {
+		if err := f.Set(user, "x"); err != ErrInvalidUser {
+			t.Errorf("invalid user accepted: %q", user)
+		}
+	}
+
+	if err := f.Set("x", "a\nb"); err != ErrInvalidHash {
+		t.Errorf("invalid hash accepted")
+	}
+
+	var b bytes.Buffer
+	f.WriteTo(&b)
+	lines := strings.Split(b.String(), "\n")
+	if lines[0] != "# managed by hand" || !strings.HasPrefix(lines[1], "bob:$2a$04$") || lines[2] != "" || lines[7] != "not an entry" || !strings.HasPrefix(lines[8], "grace:") {
+		t.Errorf("unexpected file: %q", b.String())
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "htpasswd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "htpasswd")
+	for _, user := range []string{"alice", "bob"} {
+		err := Update(path, testContext(), func(f *File) error {
+			return f.SetPassword(user, user+"pw")
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if _, err := f.Verify(user, user+"pw"); err != nil {
+			t.Errorf("%s: cannot verify: %v", user, err)
+		}
+	}
+
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0640 {
+		t.Errorf("unexpected mode: %v %v", fi, err)
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file not removed: %v", err)
+	}
+
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	if err := Update(path, nil, func(f *File) error { return nil }); err != atomicfile.ErrLocked {
+		t.Errorf("lock not honoured: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package htpasswd
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// An implementation of Scheme verifying the unsalted "{SHA}" hashes produced
+// by htpasswd -s, which are the base64-encoded SHA-1 digest of the password.
+// This is a verify-only scheme (see abstract.VerifyOnlyScheme).
+var SHACrypter abstract.Scheme
+
+type shaCrypter struct{}
+
+func (c *shaCrypter) SupportsStub(stub string) bool {
+	return strings.HasPrefix(stub, "{SHA}")
+}
+
+func (c *shaCrypter) Hash(password string) (string, error) {
+	return "", abstract.ErrVerifyOnly
+}
+
+func (c *shaCrypter) Verify(password, hash string) error {
+	if !c.SupportsStub(hash) {
+		return ErrInvalidHash
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(hash[5:])
+	if err != nil || len(digest) != sha1.Size {
+		return ErrInvalidHash
+	}
+
+	newDigest := sha1.Sum([]byte(password))
+	if !abstract.SecureCompare(string(digest), string(newDigest[:])) {
+		return abstract.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+func (c *shaCrypter) NeedsUpdate(stub string) bool {
+	return true
+}
+
+func (c *shaCrypter) VerifyOnly() bool {
+	return true
+}
+
+func (c *shaCrypter) String() string {
+	return "ldap-sha1"
+}
@@ -0,0 +1,104 @@
+// Package atomicfile replaces files atomically by writing a temporary file
+// in the same directory and renaming it over the original, and provides
+// simple lock files to serialize updates.
+package atomicfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Replaces the file at path with the output of write. The permissions and, on
+// Unix, the owner and group of an existing file are preserved; a new file is
+// created with the given mode.
+//
+// Readers see either the old or the new contents, never a partial file. The
+// temporary file is removed if write or any other step fails.
+func Write(path string, mode os.FileMode, write func(w io.Writer) error) (err error) {
+	fi, err := os.Stat(path)
+	if err == nil {
+		mode = fi.Mode().Perm()
+	} else if os.IsNotExist(err) {
+		fi = nil
+	} else {
+		return err
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	bw := bufio.NewWriter(f)
+	if err = write(bw); err != nil {
+		return
+	}
+
+	if err = bw.Flush(); err != nil {
+		return
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		return
+	}
+
+	if fi != nil {
+		if err = chown(f, fi); err != nil {
+			return
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		return
+	}
+
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Indicates that a lock file is held by another process.
+var ErrLocked = fmt.Errorf("file is locked")
+
+// Acquires an exclusive lock on path by creating the lock file path+".lock",
+// which contains the process ID. Returns ErrLocked if the lock file already
+// exists. The returned function releases the lock.
+//
+// Stale lock files left by processes which have exited are not removed
+// automatically.
+func Lock(path string) (unlock func() error, err error) {
+	lockPath := path + ".lock"
+	f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return nil, ErrLocked
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, err = fmt.Fprintf(f, "%d\n", os.Getpid())
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+
+	if err != nil {
+		os.Remove(lockPath)
+		return nil, err
+	}
+
+	return func() error {
+		return os.Remove(lockPath)
+	}, nil
+}
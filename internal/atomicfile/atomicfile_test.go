@@ -0,0 +1,91 @@
+package atomicfile
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	err = Write(path, 0600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "one\n")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Fatalf("unexpected mode: %v %v", fi, err)
+	}
+
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Write(path, 0600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "two\n")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0644 {
+		t.Errorf("mode not preserved: %v %v", fi, err)
+	}
+
+	err = Write(path, 0600, func(w io.Writer) error {
+		io.WriteString(w, "partial")
+		return fmt.Errorf("failed")
+	})
+	if err == nil {
+		t.Errorf("error not returned")
+	}
+
+	if b, err := ioutil.ReadFile(path); err != nil || string(b) != "two\n" {
+		t.Errorf("unexpected contents: %q %v", b, err)
+	}
+
+	if names, err := ioutil.ReadDir(dir); err != nil || len(names) != 1 {
+		t.Errorf("temporary file not removed: %v %v", names, err)
+	}
+}
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "file")
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Lock(path); err != ErrLocked {
+		t.Errorf("lock acquired twice: %v", err)
+	}
+
+	if err := unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err = Lock(path)
+	if err != nil {
+		t.Fatalf("cannot reacquire lock: %v", err)
+	}
+	unlock()
+}
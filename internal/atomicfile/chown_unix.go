@@ -0,0 +1,28 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package atomicfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// Gives f the owner and group of the file described by fi, if they differ.
+func chown(f *os.File, fi os.FileInfo) error {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	cur, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if cst, ok := cur.Sys().(*syscall.Stat_t); ok && cst.Uid == st.Uid && cst.Gid == st.Gid {
+		return nil
+	}
+
+	return f.Chown(int(st.Uid), int(st.Gid))
+}
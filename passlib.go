@@ -16,8 +16,11 @@
 package passlib // import "gopkg.in/hlandau/passlib.v1"
 
 import (
+	"time"
+
 	"gopkg.in/hlandau/easymetric.v1/cexp"
 	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/registry"
 )
 
 var cHashCalls = cexp.NewCounter("passlib.ctx.hashCalls")
@@ -34,10 +37,55 @@ type Context struct {
 	//
 	// If left uninitialized, a sensible default set of schemes will be used.
 	//
+	// Verify-only schemes (see abstract.VerifyOnlyScheme) are never used to
+	// hash passwords; the preferred scheme is the first scheme in this slice
+	// which is not verify-only.
+	//
 	// An upgrade hash (see the newHash return value of the Verify method of the
 	// abstract.Scheme interface) will be issued whenever a password is validated
-	// using a scheme which is not the first scheme in this slice.
+	// using a scheme which is not the preferred scheme.
 	Schemes []abstract.Scheme
+
+	// Schemes whose hashes are recognised but no longer accepted, for example
+	// because the scheme has been found to be broken. Verifying a hash which
+	// is supported by none of Schemes but by one of these fails with
+	// abstract.ErrDisabledScheme.
+	Disabled []abstract.Scheme
+
+	// If non-nil, called before verifying a hash; hashes for which it returns
+	// true are not verified, and verification fails with
+	// abstract.ErrLimitExceeded. This protects against denial of service by
+	// hashes with excessive costs. audit.Limits.Exceeded is suitable.
+	ExceedsLimits func(hash string) bool
+
+	// If non-nil, notified of hashing, verification and upgrades, for audit
+	// logging.
+	Hook Hook
+}
+
+// Creates a context from scheme specifications, most preferred first, in the
+// format accepted by registry.ParseSpec, for example:
+//
+//	passlib.NewContext("argon2id:t=3,m=65536", "bcrypt", "pbkdf2-sha512")
+//
+// The schemes are constructed using the factories in package registry.
+func NewContext(specs ...string) (*Context, error) {
+	parsed := make([]registry.Spec, len(specs))
+	for i, s := range specs {
+		spec, err := registry.ParseSpec(s)
+		if err != nil {
+			return nil, err
+		}
+
+		parsed[i] = spec
+	}
+
+	schemes, err := registry.NewSchemes(parsed...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Context{Schemes: schemes}, nil
 }
 
 func (ctx *Context) schemes() []abstract.Scheme {
@@ -48,6 +96,18 @@ func (ctx *Context) schemes() []abstract.Scheme {
 	return ctx.Schemes
 }
 
+// Returns the index of the preferred scheme, or -1 if all schemes are
+// verify-only.
+func (ctx *Context) preferred() int {
+	for i, scheme := range ctx.schemes() {
+		if !abstract.IsVerifyOnly(scheme) {
+			return i
+		}
+	}
+
+	return -1
+}
+
 // Hashes a UTF-8 plaintext password using the context and produces a password hash.
 //
 // If stub is "", one is generated automaticaly for the preferred password hashing
@@ -59,9 +119,23 @@ func (ctx *Context) schemes() []abstract.Scheme {
 // If the context has not been specifically configured, a sensible default policy
 // is used. See the fields of Context.
 func (ctx *Context) Hash(password string) (hash string, err error) {
+	start := time.Now()
+	scheme, hash, err := ctx.hash(password)
+	ctx.notify(EventHash, scheme, time.Since(start), nil, err)
+	return
+}
+
+func (ctx *Context) hash(password string) (scheme abstract.Scheme, hash string, err error) {
 	cHashCalls.Add(1)
 
-	return ctx.schemes()[0].Hash(password)
+	i := ctx.preferred()
+	if i < 0 {
+		return nil, "", abstract.ErrVerifyOnly
+	}
+
+	scheme = ctx.schemes()[i]
+	hash, err = scheme.Hash(password)
+	return
 }
 
 // Verifies a UTF-8 plaintext password using a previously derived password hash
@@ -76,7 +150,8 @@ func (ctx *Context) Hash(password string) (hash string, err error) {
 //
 // You should treat any non-nil err as a password verification error.
 func (ctx *Context) Verify(password, hash string) (newHash string, err error) {
-	return ctx.verify(password, hash, true)
+	r, err := ctx.verify(password, hash, true)
+	return r.NewHash, err
 }
 
 // Like Verify, but does not hash an upgrade password when upgrade is required.
@@ -85,51 +160,118 @@ func (ctx *Context) VerifyNoUpgrade(password, hash string) error {
 	return err
 }
 
-func (ctx *Context) verify(password, hash string, canUpgrade bool) (newHash string, err error) {
+// Like Verify, but returns a VerifyResult describing the verification and any
+// upgrade. Unlike Verify, a failure to hash an upgrade password is reported,
+// in the UpgradeErr field of the result; err is nil if the password is valid
+// regardless of whether the upgrade succeeded.
+//
+// The result is never nil.
+func (ctx *Context) VerifyWithResult(password, hash string) (*VerifyResult, error) {
+	return ctx.verify(password, hash, true)
+}
+
+func (ctx *Context) verify(password, hash string, canUpgrade bool) (r *VerifyResult, err error) {
 	cVerifyCalls.Add(1)
 
+	r = &VerifyResult{}
+	start := time.Now()
+	defer func() {
+		r.Elapsed = time.Since(start)
+	}()
+
+	preferred := ctx.preferred()
 	for i, scheme := range ctx.schemes() {
 		if !scheme.SupportsStub(hash) {
 			continue
 		}
 
+		r.Scheme = scheme
+		if ctx.ExceedsLimits != nil && ctx.ExceedsLimits(hash) {
+			ctx.notify(EventLimitExceeded, scheme, 0, nil, nil)
+			return r, abstract.ErrLimitExceeded
+		}
+
 		err = scheme.Verify(password, hash)
+		elapsed := time.Since(start)
 		if err != nil {
 			cFailedVerifyCalls.Add(1)
-			return "", err
+			ctx.notify(EventVerifyFailure, scheme, elapsed, nil, err)
+			return r, err
 		}
 
 		cSuccessfulVerifyCalls.Add(1)
-		if i != 0 || scheme.NeedsUpdate(hash) {
-			if canUpgrade {
-				cSuccessfulVerifyCallsWithUpgrade.Add(1)
-
-				// If the scheme is not the first scheme, try and rehash with the
-				// preferred scheme.
-				if newHash, err2 := ctx.Hash(password); err2 == nil {
-					return newHash, nil
-				}
-			} else {
-				cSuccessfulVerifyCallsDeferringUpgrade.Add(1)
-			}
+		r.UpdateReasons = updateReasons(scheme, hash, i == preferred)
+		ctx.notify(EventVerifySuccess, scheme, elapsed, r.UpdateReasons, nil)
+		if len(r.UpdateReasons) == 0 {
+			return r, nil
+		}
+
+		if !canUpgrade {
+			cSuccessfulVerifyCallsDeferringUpgrade.Add(1)
+			ctx.notify(EventUpgradeDeferred, scheme, elapsed, r.UpdateReasons, nil)
+			return r, nil
+		}
+
+		cSuccessfulVerifyCallsWithUpgrade.Add(1)
+
+		// If the scheme is not the preferred scheme, try and rehash with the
+		// preferred scheme.
+		hashStart := time.Now()
+		_, newHash, err2 := ctx.hash(password)
+		if err2 != nil {
+			r.UpgradeErr = err2
+			ctx.notify(EventUpgradeFailed, scheme, time.Since(hashStart), r.UpdateReasons, err2)
+			return r, nil
 		}
 
-		return "", nil
+		r.NewHash = newHash
+		ctx.notify(EventUpgradeIssued, scheme, time.Since(hashStart), r.UpdateReasons, nil)
+		return r, nil
 	}
 
-	return "", abstract.ErrUnsupportedScheme
+	for _, scheme := range ctx.Disabled {
+		if scheme.SupportsStub(hash) {
+			r.Scheme = scheme
+			ctx.notify(EventDisabledScheme, scheme, 0, nil, nil)
+			return r, abstract.ErrDisabledScheme
+		}
+	}
+
+	ctx.notify(EventUnsupportedScheme, nil, 0, nil, nil)
+	return r, abstract.ErrUnsupportedScheme
 }
 
 // Determines whether a stub or hash needs updating according to the policy of
 // the context.
 func (ctx *Context) NeedsUpdate(stub string) bool {
+	return len(ctx.UpdateReasons(stub)) > 0
+}
+
+// Returns the reasons a stub or hash needs updating according to the policy of
+// the context, or nil if it does not need updating or no scheme in the
+// context supports it.
+func (ctx *Context) UpdateReasons(stub string) []UpdateReason {
+	preferred := ctx.preferred()
+	for i, scheme := range ctx.schemes() {
+		if scheme.SupportsStub(stub) {
+			return updateReasons(scheme, stub, i == preferred)
+		}
+	}
+
+	return nil
+}
+
+// Returns the scheme in the context which supports the given stub or hash, or
+// nil if there is none. preferred is true if it is the preferred scheme.
+func (ctx *Context) Identify(stub string) (scheme abstract.Scheme, preferred bool) {
+	p := ctx.preferred()
 	for i, scheme := range ctx.schemes() {
 		if scheme.SupportsStub(stub) {
-			return i != 0 || scheme.NeedsUpdate(stub)
+			return scheme, i == p
 		}
 	}
 
-	return false
+	return nil, false
 }
 
 // The default context, which uses sensible defaults. Most users should not
@@ -1,14 +1,19 @@
 package passlib
 
 import (
+	"fmt"
 	"testing"
 
 	"gopkg.in/hlandau/passlib.v1/abstract"
 	"gopkg.in/hlandau/passlib.v1/hash/argon2"
 	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
 	"gopkg.in/hlandau/passlib.v1/hash/bcryptsha256"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/pbkdf2"
 	"gopkg.in/hlandau/passlib.v1/hash/scrypt"
 	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/windows"
+	"gopkg.in/hlandau/passlib.v1/hash/yescrypt"
 )
 
 //import "gopkg.in/hlandau/passlib.v1/hash/scrypt"
@@ -106,6 +111,92 @@ func TestUpgrade(t *testing.T) {
 	}
 }
 
+func TestVerifyOnly(t *testing.T) {
+	c := Context{Schemes: []abstract.Scheme{windows.NTCrypter, sha2crypt.Crypter256}}
+
+	h, err := c.Hash("password")
+	if err != nil || !sha2crypt.Crypter256.SupportsStub(h) {
+		t.Fatalf("verify-only scheme used for hashing: %v (%#v)", err, h)
+	}
+
+	if newHash, err := c.Verify("password", h); err != nil || newHash != "" {
+		t.Fatalf("unexpected upgrade of preferred scheme: %v (%#v)", err, newHash)
+	}
+
+	newHash, err := c.Verify("password", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+	if err != nil || !sha2crypt.Crypter256.SupportsStub(newHash) {
+		t.Fatalf("NT hash not upgraded: %v (%#v)", err, newHash)
+	}
+
+	c = Context{Schemes: []abstract.Scheme{windows.NTCrypter}}
+	if _, err := c.Hash("password"); err != abstract.ErrVerifyOnly {
+		t.Fatalf("unexpected result hashing with verify-only context: %v", err)
+	}
+}
+
+func TestVerifyWithResult(t *testing.T) {
+	c := Context{Schemes: []abstract.Scheme{sha2crypt.Crypter512, bcrypt.Crypter, windows.NTCrypter}}
+
+	r, err := c.VerifyWithResult("password", "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6")
+	if err != nil || r.Scheme != bcrypt.Crypter || !r.NeedsUpdate() || r.UpgradeErr != nil ||
+		!sha2crypt.Crypter512.SupportsStub(r.NewHash) || r.Elapsed <= 0 {
+		t.Fatalf("unexpected result: %v (%#v)", err, r)
+	}
+
+	if fmt.Sprint(r.UpdateReasons) != "[not-preferred outdated]" {
+		t.Errorf("unexpected update reasons: %v", r.UpdateReasons)
+	}
+
+	r, err = c.VerifyWithResult("password", r.NewHash)
+	if err != nil || r.Scheme != sha2crypt.Crypter512 || r.NeedsUpdate() || r.NewHash != "" {
+		t.Errorf("unexpected result for preferred scheme: %v (%#v)", err, r)
+	}
+
+	r, err = c.VerifyWithResult("wrong", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+	if err == nil || r.Scheme != windows.NTCrypter || r.NeedsUpdate() {
+		t.Errorf("unexpected result for wrong password: %v (%#v)", err, r)
+	}
+
+	if r, err := c.VerifyWithResult("password", "$unknown$"); err != abstract.ErrUnsupportedScheme || r.Scheme != nil {
+		t.Errorf("unexpected result for unsupported hash: %v (%#v)", err, r)
+	}
+
+	// Upgrade failures are reported but do not fail verification.
+	c = Context{Schemes: []abstract.Scheme{windows.NTCrypter}}
+	r, err = c.VerifyWithResult("password", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+	if err != nil || r.UpgradeErr != abstract.ErrVerifyOnly || r.NewHash != "" ||
+		fmt.Sprint(r.UpdateReasons) != "[verify-only]" {
+		t.Errorf("unexpected result for failed upgrade: %v (%#v)", err, r)
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	c, err := NewContext("sha512-crypt:rounds=6000", "nt", "bcrypt:cost=4")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	h, err := c.Hash("password")
+	if err != nil || h[0:15] != "$6$rounds=6000$" {
+		t.Fatalf("unexpected hash: %v (%#v)", err, h)
+	}
+
+	newHash, err := c.Verify("password", "$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6")
+	if err != nil || !sha2crypt.Crypter512.SupportsStub(newHash) {
+		t.Fatalf("bcrypt hash not upgraded: %v (%#v)", err, newHash)
+	}
+
+	for _, specs := range [][]string{
+		{"nonexistent"},
+		{"bcrypt:cost=99"},
+		{"argon2id:x=1"},
+	} {
+		if _, err := NewContext(specs...); err == nil {
+			t.Errorf("invalid specification accepted: %v", specs)
+		}
+	}
+}
+
 func kat(t *testing.T, scheme abstract.Scheme, password, hash string) {
 	c := Context{Schemes: []abstract.Scheme{scheme}}
 
@@ -233,6 +324,19 @@ func TestKat(t *testing.T) {
 		kat(t, bcryptsha256.Crypter, v.p, v.h)
 	}
 
+	// Version 2 format. The first is from passlib 1.7.3; the remainder were
+	// generated using libxcrypt's crypt(3) and Python's hmac module.
+	for _, v := range []struct{ p, h string }{
+		{"", "$bcrypt-sha256$v=2,t=2b,r=5$E/e/2AOhqM5W/KJTFQzLce$WFPIZKtDDTriqWwlmRFfHiOTeheAZWe"},
+		{"password", "$bcrypt-sha256$v=2,t=2b,r=5$5Hg1DKFqPE8C2aflZ5vVoe$wOK1VFFtS8IGTrGa7.h5fs0u84qyPbS"},
+		{"t\u00E1\u0411\u2113\u0259", "$bcrypt-sha256$v=2,t=2b,r=5$.US1fQ4TQS.ZTz/uJ5Kyn.$pzzgp40k8reM1CuQb03PvE0IDPQSdV6"},
+		{"abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123qwr", "$bcrypt-sha256$v=2,t=2b,r=5$X1g1nh3g0v4h6970O68cxe$CBF9csfEdW68xv3DwE6xSULXMtqEFP."},
+		{"abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123abc123xyz", "$bcrypt-sha256$v=2,t=2b,r=5$X1g1nh3g0v4h6970O68cxe$zC/1UDUG2ofEXB6Onr2vvyFzfhEOS3S"},
+		{"foobar", "$bcrypt-sha256$v=2,t=2b,r=12$rruXEyrqlhdwQf0tc75cyu$jiVebukoZP3dF9imP8VVKTIb/7.Rh56"},
+	} {
+		kat(t, bcryptsha256.Crypter, v.p, v.h)
+	}
+
 	for _, v := range []struct{ p, h string }{
 		{"", "$s2$16384$8$1$5KHwLMZjMDiuPAhUYK/XcKZW$KZIGWg5XM1Xsh8X/wuBE1+KTeFImkuQn3gZpjUZcqns="},
 		{"foobar", "$s2$16384$8$1$qa9lVfhmTE8F2Jpwya9m7uoE$Q7dSPqhZQCLWpjniaz7RVm+xorpSAPTvOCP2uoZmoiI="},
@@ -246,6 +350,76 @@ func TestKat(t *testing.T) {
 	} {
 		kat(t, argon2.Crypter, v.p, v.h)
 	}
+
+	for _, v := range []struct{ p, h string }{
+		{"", "$argon2id$v=19$m=32768,t=4,p=4$c2FsdHNhbHRzYWx0c2FsdA$iOnY2XcL2dFWUpKU+YfZJmhtu49NdOyv0lkqJtxzfBY"},
+		{"password", "$argon2id$v=19$m=32768,t=4,p=4$c2FsdHNhbHRzYWx0c2FsdA$DDN87dwFaiv+j24L4c+DdIGeP8mW6IkoJBrskYLpHFY"},
+	} {
+		kat(t, argon2.IDCrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"password", "$1$ab$oKsM6dtDD2L1bKowOBX.7."},
+		{"", "$1$12345678$xek.CpjQUVgdf/P2N9KQf/"},
+	} {
+		kat(t, md5crypt.Crypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$5P1uc1zvKhieqEtKttbwCQrTPXpY1cK9wEnTDKAqLD8"},
+		{"password", "$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC"},
+	} {
+		kat(t, yescrypt.Crypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$1l4FiPZDsBJqLHMqIl51VKGAfCw/dvDP8DLxv7jzBT."},
+		{"password", "$gy$j9T$F5Jx5fExrKuPp53xLKQ..1$Dogv.jai3UfiqXFIeQV0FWiA2xx/QPuuov.EGnMByDD"},
+	} {
+		kat(t, yescrypt.GOSTCrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"", "$7$A/....0....SodiumChloride$h0vbPj9o1zvOls0gS/jQvIcBhin8KchL/qzS7UgiD8/"},
+		{"password", "$7$CU..../....abcdefgh$sWsarqbldvBJgryJJYHjYzc1J1T48nJOIdZfeQFpq2A"},
+	} {
+		kat(t, scrypt.Crypter7, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"password", "$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E"},
+		{"", "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$KjMXA.ztMvG1DNU/OJLnH.cwgCb03WVM93yCoDb2OPE"},
+	} {
+		kat(t, scrypt.PasslibCrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"password", "{PKCS5S2}AAECAwQFBgcICQoLDA0OD44+L3PD62OQqBq7yBAcA0OwF6ev//tatl4TTwkJ3Mos"},
+	} {
+		kat(t, pbkdf2.AtlassianCrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		{"password", "grub.pbkdf2.sha512.10000.000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F202122232425262728292A2B2C2D2E2F303132333435363738393A3B3C3D3E3F.DE25072AD1C2279350AA009DE388C0072AFD49313679A3CE2C980BE1F1AFB6084E2FF4E0BF920D3E24902616F118C50CBC79A21C877C08A5FDE691F177769D7A"},
+	} {
+		kat(t, pbkdf2.GRUBCrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		// From passlib 1.7
+		{"password", "$p5k2$1$$h1TDLGSw9ST8UMAPeIE13i0t12c="},
+		{"hashy the \u2603", "$p5k2$1000$ZxK4ZBJCfQg=$jJZVscWtO--p1-xIZl6jhO2LKR0="},
+	} {
+		kat(t, pbkdf2.CTACrypter, v.p, v.h)
+	}
+
+	for _, v := range []struct{ p, h string }{
+		// From passlib 1.7
+		{"cloadm", "$p5k2$$exec$r1EWMCMk7Rlv3L/RNcFXviDefYa0hlql"},
+		{"gnu", "$p5k2$c$u9HvcT4d$Sd1gwSVCLZYAuqZ25piRnbBEoAesaa/g"},
+	} {
+		kat(t, pbkdf2.DlitzCrypter, v.p, v.h)
+	}
 }
 
 // © 2008-2012 Assurance Technologies LLC.  (Python passlib)  BSD License
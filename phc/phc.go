@@ -0,0 +1,251 @@
+// Package phc implements parsing and encoding of the PHC string format, which
+// is used by argon2 and several other password hashing schemes.
+//
+// The format is as follows:
+//
+//	$id[$v=version][$param=value(,param=value)*][$salt[$hash]]
+//
+// The salt and hash are encoded using standard base64 without padding. Only
+// canonical encodings are accepted.
+package phc
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Indicates that a string is not a valid PHC string.
+var ErrInvalid = fmt.Errorf("invalid PHC string")
+
+// Indicates that a parameter required by a scheme is not present.
+var ErrMissingParam = fmt.Errorf("missing PHC parameter")
+
+// Indicates that a parameter value is malformed.
+var ErrInvalidParam = fmt.Errorf("invalid PHC parameter value")
+
+// Indicates that a salt or hash is not canonically base64-encoded.
+var ErrInvalidBase64 = fmt.Errorf("invalid or non-canonical PHC base64 encoding")
+
+// A parameter name and its value.
+type Param struct {
+	Name, Value string
+}
+
+// A parsed PHC string.
+type Hash struct {
+	// The algorithm identifier, e.g. "argon2i".
+	ID string
+
+	// The version, if HasVersion is true.
+	Version    int
+	HasVersion bool
+
+	// The parameters in the order they appear.
+	Params []Param
+
+	// The salt and hash in binary form. These are nil if absent.
+	Salt []byte
+	Hash []byte
+}
+
+// Parses a PHC string. The string may be a full hash or a stub without a hash
+// (or without a salt).
+func Parse(s string) (*Hash, error) {
+	if len(s) == 0 || s[0] != '$' {
+		return nil, ErrInvalid
+	}
+
+	parts := strings.Split(s[1:], "$")
+	h := &Hash{ID: parts[0]}
+	if !validName(h.ID) {
+		return nil, ErrInvalid
+	}
+
+	parts = parts[1:]
+
+	if len(parts) > 0 && strings.HasPrefix(parts[0], "v=") && strings.IndexByte(parts[0], ',') < 0 {
+		v, err := parseDecimal(parts[0][2:], 31)
+		if err != nil {
+			return nil, ErrInvalid
+		}
+
+		h.Version, h.HasVersion = int(v), true
+		parts = parts[1:]
+	}
+
+	if len(parts) > 0 && strings.IndexByte(parts[0], '=') >= 0 {
+		for _, kv := range strings.Split(parts[0], ",") {
+			i := strings.IndexByte(kv, '=')
+			if i < 0 {
+				return nil, ErrInvalid
+			}
+
+			p := Param{Name: kv[0:i], Value: kv[i+1:]}
+			if !validName(p.Name) || !validValue(p.Value) {
+				return nil, ErrInvalid
+			}
+
+			if _, ok := h.Param(p.Name); ok {
+				return nil, ErrInvalid
+			}
+
+			h.Params = append(h.Params, p)
+		}
+
+		parts = parts[1:]
+	}
+
+	var err error
+	if len(parts) > 0 {
+		if h.Salt, err = DecodeBase64(parts[0]); err != nil {
+			return nil, err
+		}
+
+		parts = parts[1:]
+	}
+
+	if len(parts) > 0 {
+		if h.Hash, err = DecodeBase64(parts[0]); err != nil {
+			return nil, err
+		}
+
+		parts = parts[1:]
+	}
+
+	if len(parts) > 0 {
+		return nil, ErrInvalid
+	}
+
+	return h, nil
+}
+
+// Encodes the PHC string. If Salt is nil, the hash is also omitted.
+func (h *Hash) String() string {
+	s := "$" + h.ID
+
+	if h.HasVersion {
+		s += "$v=" + strconv.Itoa(h.Version)
+	}
+
+	for i, p := range h.Params {
+		if i == 0 {
+			s += "$"
+		} else {
+			s += ","
+		}
+
+		s += p.Name + "=" + p.Value
+	}
+
+	if h.Salt != nil {
+		s += "$" + EncodeBase64(h.Salt)
+
+		if h.Hash != nil {
+			s += "$" + EncodeBase64(h.Hash)
+		}
+	}
+
+	return s
+}
+
+// Returns the value of the named parameter, if present.
+func (h *Hash) Param(name string) (string, bool) {
+	for _, p := range h.Params {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Returns the value of the named parameter as an unsigned decimal integer of
+// at most bitSize bits. Returns ErrMissingParam if the parameter is absent.
+func (h *Hash) ParamUint(name string, bitSize int) (uint64, error) {
+	v, ok := h.Param(name)
+	if !ok {
+		return 0, ErrMissingParam
+	}
+
+	return parseDecimal(v, bitSize)
+}
+
+// Appends a parameter with an unsigned decimal integer value.
+func (h *Hash) AddParamUint(name string, value uint64) {
+	h.Params = append(h.Params, Param{Name: name, Value: strconv.FormatUint(value, 10)})
+}
+
+// Returns true if the parameters present are exactly those named, in any
+// order.
+func (h *Hash) HasOnlyParams(names ...string) bool {
+	if len(h.Params) != len(names) {
+		return false
+	}
+
+	for _, n := range names {
+		if _, ok := h.Param(n); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Encodes binary data using standard base64 without padding.
+func EncodeBase64(b []byte) string {
+	return base64.RawStdEncoding.EncodeToString(b)
+}
+
+// Decodes standard base64 without padding. Non-canonical encodings, such as
+// those with unused bits set, are rejected.
+func DecodeBase64(s string) ([]byte, error) {
+	b, err := base64.RawStdEncoding.DecodeString(s)
+	if err != nil || EncodeBase64(b) != s {
+		return nil, ErrInvalidBase64
+	}
+
+	return b, nil
+}
+
+// Decimal values must not have leading zeroes or a sign.
+func parseDecimal(s string, bitSize int) (uint64, error) {
+	if s == "" || (s[0] == '0' && len(s) > 1) || s[0] == '+' {
+		return 0, ErrInvalidParam
+	}
+
+	v, err := strconv.ParseUint(s, 10, bitSize)
+	if err != nil {
+		return 0, ErrInvalidParam
+	}
+
+	return v, nil
+}
+
+func validName(s string) bool {
+	if len(s) == 0 || len(s) > 32 {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validValue(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') &&
+			!(c >= '0' && c <= '9') && c != '/' && c != '+' && c != '.' && c != '-' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,70 @@
+package phc
+
+import "testing"
+
+func TestRoundTrip(t *testing.T) {
+	for _, s := range []string{
+		"$argon2i$v=19$m=32768,t=4,p=4$c29tZXNhbHRzb21lYWxrdA$HcTlbOnOAzJ2dUrlgHnNwC0yallJ/Gl2NbAWqg4IukA",
+		"$argon2i$v=19$m=32768,t=4,p=4$c29tZXNhbHRzb21lYWxrdA",
+		"$scrypt$ln=16,r=8,p=1$aM15713r3Xsvxbi31lqr1Q$nFNh2CVHVjNldFVKDHDlm4CbdRSCdEBsjjJxD+iCs5E",
+		"$foo$bar=baz",
+		"$foo$v=1",
+		"$foo",
+		"$foo$c2FsdA",
+	} {
+		h, err := Parse(s)
+		if err != nil {
+			t.Errorf("cannot parse %q: %v", s, err)
+			continue
+		}
+
+		if h.String() != s {
+			t.Errorf("does not round trip: %q -> %q", s, h.String())
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	h, err := Parse("$argon2i$v=19$m=32768,t=4,p=4$c29tZXNhbHRzb21lYWxrdA")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if h.ID != "argon2i" || !h.HasVersion || h.Version != 19 || string(h.Salt) != "somesaltsomealkt" || h.Hash != nil {
+		t.Errorf("unexpected parse result: %+v", h)
+	}
+
+	if m, err := h.ParamUint("m", 32); err != nil || m != 32768 {
+		t.Errorf("unexpected m: %v %v", m, err)
+	}
+
+	if _, err := h.ParamUint("x", 32); err != ErrMissingParam {
+		t.Errorf("expected missing parameter: %v", err)
+	}
+
+	if !h.HasOnlyParams("p", "t", "m") || h.HasOnlyParams("m", "t") {
+		t.Errorf("HasOnlyParams gave wrong result")
+	}
+
+	for _, s := range []string{
+		"",
+		"argon2i$v=19",
+		"$Argon2i$v=19",                     // uppercase identifier
+		"$argon2i$v=019",                    // leading zero
+		"$argon2i$m=1,m=2",                  // duplicate parameter
+		"$argon2i$m=1,t",                    // missing value
+		"$argon2i$m=a$b",                    // invalid value character
+		"$argon2i$m=1$c2FsdA==",             // padding
+		"$argon2i$m=1$c2FsdB",               // non-canonical
+		"$argon2i$m=1$c2Fsd\nA",             // newline
+		"$argon2i$m=1$c2FsdA$c2FsdA$c2FsdA", // trailing field
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("expected parse error: %q", s)
+		}
+	}
+
+	if _, err := (&Hash{Params: []Param{{"n", "01"}}}).ParamUint("n", 32); err != ErrInvalidParam {
+		t.Errorf("expected invalid parameter: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package registry_test
+
+import (
+	"testing"
+
+	_ "gopkg.in/hlandau/passlib.v1/hash/argon2"
+	_ "gopkg.in/hlandau/passlib.v1/hash/aspnet"
+	_ "gopkg.in/hlandau/passlib.v1/hash/balloon"
+	_ "gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/bcryptsha256"
+	_ "gopkg.in/hlandau/passlib.v1/hash/cisco"
+	_ "gopkg.in/hlandau/passlib.v1/hash/descrypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/mysql"
+	_ "gopkg.in/hlandau/passlib.v1/hash/pbkdf2"
+	_ "gopkg.in/hlandau/passlib.v1/hash/postgres"
+	_ "gopkg.in/hlandau/passlib.v1/hash/scram"
+	_ "gopkg.in/hlandau/passlib.v1/hash/scrypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	_ "gopkg.in/hlandau/passlib.v1/hash/spring"
+	_ "gopkg.in/hlandau/passlib.v1/hash/windows"
+	_ "gopkg.in/hlandau/passlib.v1/hash/yescrypt"
+	"gopkg.in/hlandau/passlib.v1/registry"
+)
+
+// Every factory with no required parameters must accept its defaults.
+func TestFactoryDefaults(t *testing.T) {
+	for _, name := range registry.Names() {
+		required := false
+		for _, p := range registry.Lookup(name).Params {
+			required = required || p.Required
+		}
+
+		if required {
+			continue
+		}
+
+		scheme, err := registry.New(name, nil)
+		if err != nil || scheme == nil {
+			t.Errorf("%s: cannot construct with defaults: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"argon2id", "pbkdf2-sha512", "bcrypt", "nt", "balloon-m", "scrypt"} {
+		if registry.Lookup(name) == nil {
+			t.Errorf("%s: not registered", name)
+		}
+	}
+}
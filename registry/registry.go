@@ -0,0 +1,228 @@
+// Package registry provides a registry of named password hashing scheme
+// factories, so that schemes can be constructed from names and parameters
+// given in configuration.
+//
+// Each hash package registers its factories when it is imported. The schemes
+// in passlib's default scheme lists are always available when the passlib
+// package is imported; other schemes, including those provided by third-party
+// packages, are available once their package has been imported, for example
+// with a blank import:
+//
+//	import _ "gopkg.in/hlandau/passlib.v1/hash/balloon"
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// Indicates that no factory is registered under the requested name.
+var ErrUnknownScheme = fmt.Errorf("unknown password hashing scheme")
+
+// Indicates that a parameter is unknown, missing or has an invalid value.
+var ErrInvalidParam = fmt.Errorf("invalid password hashing scheme parameter")
+
+// The type of a parameter value.
+type ParamType int
+
+const (
+	// A decimal integer.
+	Int ParamType = iota
+
+	// An arbitrary string.
+	String
+)
+
+// Describes a parameter accepted by a factory.
+type Param struct {
+	// The name of the parameter, e.g. "rounds".
+	Name string
+
+	// The type of the parameter value.
+	Type ParamType
+
+	// If true, the parameter must be specified. Otherwise, Default is used if
+	// it is not specified.
+	Required bool
+	Default  string
+
+	// For Int parameters, the inclusive range of valid values. Not checked if
+	// both are zero.
+	Min, Max int64
+
+	// For String parameters, the valid values. Not checked if empty.
+	Choices []string
+
+	// A short description of the parameter.
+	Doc string
+}
+
+// A named factory which constructs a Scheme from parameters.
+type Factory struct {
+	// The name under which the factory is registered, e.g. "pbkdf2-sha512".
+	Name string
+
+	// A short description of the scheme.
+	Doc string
+
+	// The parameters accepted by the factory.
+	Params []Param
+
+	// Constructs the scheme. args contains a value for every parameter in
+	// Params, which has already been validated, except for optional String
+	// parameters with no default which were not specified.
+	New func(args Args) (abstract.Scheme, error)
+}
+
+// Parameter values passed to a factory.
+type Args map[string]string
+
+// Returns the value of an Int parameter.
+func (a Args) Int(name string) int {
+	v, _ := strconv.Atoi(a[name])
+	return v
+}
+
+// Returns the value of a String parameter.
+func (a Args) String(name string) string {
+	return a[name]
+}
+
+var (
+	mutex     sync.RWMutex
+	factories = map[string]*Factory{}
+)
+
+// Registers a factory. Panics if the name is empty or a factory is already
+// registered under the same name. This is intended to be called from the init
+// function of the package providing the scheme.
+func Register(f *Factory) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if f.Name == "" || f.New == nil {
+		panic("passlib/registry: invalid factory")
+	}
+
+	if _, ok := factories[f.Name]; ok {
+		panic("passlib/registry: factory registered twice: " + f.Name)
+	}
+
+	factories[f.Name] = f
+}
+
+// Returns the factory registered under the given name, or nil.
+func Lookup(name string) *Factory {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	return factories[name]
+}
+
+// Returns the names of all registered factories in sorted order.
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Constructs a scheme using the factory registered under the given name.
+// params may be nil.
+func New(name string, params map[string]string) (abstract.Scheme, error) {
+	f := Lookup(name)
+	if f == nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, name)
+	}
+
+	args, err := f.args(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.New(args)
+}
+
+func (f *Factory) param(name string) *Param {
+	for i := range f.Params {
+		if f.Params[i].Name == name {
+			return &f.Params[i]
+		}
+	}
+
+	return nil
+}
+
+func (f *Factory) args(params map[string]string) (Args, error) {
+	for name := range params {
+		if f.param(name) == nil {
+			return nil, fmt.Errorf("%w: %s: unknown parameter %q", ErrInvalidParam, f.Name, name)
+		}
+	}
+
+	args := Args{}
+	for i := range f.Params {
+		p := &f.Params[i]
+
+		v, ok := params[p.Name]
+		if !ok {
+			if p.Required {
+				return nil, fmt.Errorf("%w: %s: missing parameter %q", ErrInvalidParam, f.Name, p.Name)
+			}
+
+			if p.Type == String && p.Default == "" {
+				continue
+			}
+
+			v = p.Default
+		}
+
+		if err := p.check(v); err != nil {
+			return nil, fmt.Errorf("%w: %s: %s=%q: %v", ErrInvalidParam, f.Name, p.Name, v, err)
+		}
+
+		args[p.Name] = v
+	}
+
+	return args, nil
+}
+
+func (p *Param) check(v string) error {
+	switch p.Type {
+	case Int:
+		n, err := strconv.ParseInt(v, 10, 0)
+		if err != nil {
+			return fmt.Errorf("not an integer")
+		}
+
+		if (p.Min != 0 || p.Max != 0) && (n < p.Min || n > p.Max) {
+			return fmt.Errorf("must be between %d and %d", p.Min, p.Max)
+		}
+
+	case String:
+		if len(p.Choices) == 0 {
+			return nil
+		}
+
+		for _, c := range p.Choices {
+			if v == c {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("must be one of %s", strings.Join(p.Choices, ", "))
+	}
+
+	return nil
+}
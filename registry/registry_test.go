@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+type testScheme struct {
+	abstract.Scheme
+	args Args
+}
+
+func init() {
+	Register(&Factory{
+		Name: "test",
+		Params: []Param{
+			{Name: "rounds", Type: Int, Default: "10", Min: 1, Max: 100},
+			{Name: "alg", Type: String, Default: "a", Choices: []string{"a", "b"}},
+			{Name: "list", Type: String},
+			{Name: "key", Type: String, Required: true},
+		},
+		New: func(args Args) (abstract.Scheme, error) {
+			return &testScheme{args: args}, nil
+		},
+	})
+}
+
+func TestParseSpec(t *testing.T) {
+	for _, v := range []struct {
+		s    string
+		spec Spec
+	}{
+		{"bcrypt", Spec{Name: "bcrypt"}},
+		{"argon2id:t=3,m=65536", Spec{Name: "argon2id", Params: map[string]string{"t": "3", "m": "65536"}}},
+		{"scram:algs=sha-1,sha-256,rounds=10", Spec{Name: "scram", Params: map[string]string{"algs": "sha-1,sha-256", "rounds": "10"}}},
+		{"x:k=a=b", Spec{Name: "x", Params: map[string]string{"k": "a=b"}}},
+	} {
+		spec, err := ParseSpec(v.s)
+		if err != nil || !reflect.DeepEqual(spec, v.spec) {
+			t.Errorf("mismatch: %q: %v %#v", v.s, err, spec)
+		}
+	}
+
+	if s := (Spec{Name: "argon2id", Params: map[string]string{"t": "3", "m": "65536"}}).String(); s != "argon2id:m=65536,t=3" {
+		t.Errorf("unexpected spec string: %s", s)
+	}
+
+	for _, s := range []string{"", ":t=1", "x:a", "x:t=1,t=2", "x:=1"} {
+		if _, err := ParseSpec(s); err == nil {
+			t.Errorf("invalid spec accepted: %q", s)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	s, err := New("test", map[string]string{"key": "k", "list": "x,y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Args{"rounds": "10", "alg": "a", "list": "x,y", "key": "k"}
+	if args := s.(*testScheme).args; !reflect.DeepEqual(args, expected) || args.Int("rounds") != 10 {
+		t.Errorf("unexpected args: %#v", args)
+	}
+
+	s, err = New("test", map[string]string{"key": "k", "rounds": "100", "alg": "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args := s.(*testScheme).args; args.Int("rounds") != 100 || args.String("alg") != "b" {
+		t.Errorf("unexpected args: %#v", args)
+	}
+
+	if _, ok := s.(*testScheme).args["list"]; ok {
+		t.Errorf("unspecified optional parameter present")
+	}
+
+	for _, params := range []map[string]string{
+		nil,
+		{"key": "k", "rounds": "0"},
+		{"key": "k", "rounds": "101"},
+		{"key": "k", "rounds": "x"},
+		{"key": "k", "alg": "c"},
+		{"key": "k", "unknown": "1"},
+	} {
+		if _, err := New("test", params); !errors.Is(err, ErrInvalidParam) {
+			t.Errorf("invalid parameters accepted: %v: %v", params, err)
+		}
+	}
+
+	if _, err := New("nonexistent", nil); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("unexpected error for unknown scheme: %v", err)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	if Lookup("test") == nil || Lookup("nonexistent") != nil {
+		t.Errorf("unexpected lookup result")
+	}
+
+	found := false
+	for _, name := range Names() {
+		found = found || name == "test"
+	}
+
+	if !found {
+		t.Errorf("registered name not listed")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("duplicate registration did not panic")
+		}
+	}()
+
+	Register(&Factory{Name: "test", New: Lookup("test").New})
+}
@@ -0,0 +1,105 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// A scheme name and parameters, as found in configuration.
+type Spec struct {
+	Name   string
+	Params map[string]string
+}
+
+// Parses a scheme specification string.
+//
+// The format is as follows:
+//
+//	name
+//	name:param=value,param=value,...
+//
+// A comma-separated item which does not contain '=' is appended to the value
+// of the preceding parameter, so that list-valued parameters can be given,
+// e.g. "scram:rounds=100000,algs=sha-1,sha-256".
+func ParseSpec(s string) (Spec, error) {
+	name, rest := s, ""
+	if i := strings.IndexByte(s, ':'); i >= 0 {
+		name, rest = s[0:i], s[i+1:]
+	}
+
+	spec := Spec{Name: strings.TrimSpace(name)}
+	if spec.Name == "" {
+		return Spec{}, fmt.Errorf("%w: %q", ErrUnknownScheme, s)
+	}
+
+	if rest == "" {
+		return spec, nil
+	}
+
+	spec.Params = map[string]string{}
+
+	last := ""
+	for _, item := range strings.Split(rest, ",") {
+		i := strings.IndexByte(item, '=')
+		if i < 0 {
+			if last == "" {
+				return Spec{}, fmt.Errorf("%w: malformed specification %q", ErrInvalidParam, s)
+			}
+
+			spec.Params[last] += "," + item
+			continue
+		}
+
+		last = strings.TrimSpace(item[0:i])
+		if _, ok := spec.Params[last]; ok || last == "" {
+			return Spec{}, fmt.Errorf("%w: malformed specification %q", ErrInvalidParam, s)
+		}
+
+		spec.Params[last] = item[i+1:]
+	}
+
+	return spec, nil
+}
+
+// Formats the specification in the format accepted by ParseSpec. Parameters
+// are sorted by name.
+func (s Spec) String() string {
+	names := make([]string, 0, len(s.Params))
+	for name := range s.Params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(s.Name)
+	for i, name := range names {
+		if i == 0 {
+			b.WriteByte(':')
+		} else {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(name + "=" + s.Params[name])
+	}
+
+	return b.String()
+}
+
+// Constructs a scheme for each specification, in order.
+func NewSchemes(specs ...Spec) ([]abstract.Scheme, error) {
+	schemes := make([]abstract.Scheme, 0, len(specs))
+	for _, spec := range specs {
+		scheme, err := New(spec.Name, spec.Params)
+		if err != nil {
+			return nil, err
+		}
+
+		schemes = append(schemes, scheme)
+	}
+
+	return schemes, nil
+}
@@ -0,0 +1,76 @@
+package shadow
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// An entry in a passwd file.
+type PasswdEntry struct {
+	Name string
+
+	// The password field, which is normally "x", indicating that the password
+	// is in the shadow file.
+	Password string
+
+	UID   int
+	GID   int
+	GECOS string
+	Home  string
+	Shell string
+}
+
+// Returns true if the password for the entry is held in the shadow file.
+func (e *PasswdEntry) Shadowed() bool {
+	return e.Password == "x"
+}
+
+// Parses a passwd file. Lines which are not valid entries, such as NIS "+"
+// lines, are ignored.
+func ParsePasswd(r io.Reader) ([]*PasswdEntry, error) {
+	var entries []*PasswdEntry
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		fields := strings.Split(s.Text(), ":")
+		if len(fields) != 7 || fields[0] == "" {
+			continue
+		}
+
+		uid, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+
+		gid, err := strconv.Atoi(fields[3])
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, &PasswdEntry{
+			Name:     fields[0],
+			Password: fields[1],
+			UID:      uid,
+			GID:      gid,
+			GECOS:    fields[4],
+			Home:     fields[5],
+			Shell:    fields[6],
+		})
+	}
+
+	return entries, s.Err()
+}
+
+// Loads the passwd file at path.
+func LoadPasswd(path string) ([]*PasswdEntry, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return ParsePasswd(r)
+}
@@ -0,0 +1,350 @@
+// Package shadow reads and updates shadow(5) files and reads passwd(5) files.
+//
+// Files are read from and written to arbitrary paths, so that the package can
+// be used on mounted images and tested in temporary directories. Updates take
+// a lock file and replace the file atomically.
+package shadow
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/bcrypt"
+	"gopkg.in/hlandau/passlib.v1/hash/md5crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/yescrypt"
+	"gopkg.in/hlandau/passlib.v1/internal/atomicfile"
+)
+
+// Indicates that a user does not have an entry in the file.
+var ErrUnknownUser = fmt.Errorf("unknown shadow user")
+
+// Indicates that an account is locked or has no usable password, because its
+// password field begins with "!" or "*".
+var ErrLocked = fmt.Errorf("shadow account is locked")
+
+// Indicates that an account has an empty password field. Whether such
+// accounts may log in without a password is a matter of local policy, so
+// Verify always fails for them.
+var ErrEmptyPassword = fmt.Errorf("shadow account has an empty password")
+
+// Indicates that a field cannot be stored in a shadow file because it
+// contains a colon or line break.
+var ErrInvalidField = fmt.Errorf("invalid shadow field")
+
+// The context used by files which do not specify one. It hashes with
+// sha512-crypt, which is supported by all current C libraries, and verifies
+// sha-crypt, yescrypt, bcrypt and md5-crypt hashes.
+var DefaultContext passlib.Context
+
+func init() {
+	DefaultContext.Schemes = []abstract.Scheme{
+		sha2crypt.Crypter512,
+		yescrypt.Crypter,
+		sha2crypt.Crypter256,
+		bcrypt.Crypter,
+		md5crypt.Crypter,
+	}
+}
+
+// An entry in a shadow file. Numeric fields are -1 if empty. Dates are in
+// days since 1970-01-01.
+type Entry struct {
+	Name string
+
+	// The password field, which is a hash, possibly prefixed with "!" if the
+	// account is locked, or "*" or "!" alone if the account has no password.
+	Password string
+
+	LastChange     int64
+	MinAge         int64
+	MaxAge         int64
+	WarnPeriod     int64
+	InactivePeriod int64
+	Expire         int64
+
+	// The reserved ninth field.
+	Reserved string
+}
+
+// Returns true if the account is locked or has no usable password.
+func (e *Entry) Locked() bool {
+	return strings.HasPrefix(e.Password, "!") || strings.HasPrefix(e.Password, "*")
+}
+
+// Returns the password hash without any lock prefix.
+func (e *Entry) Hash() string {
+	return strings.TrimLeft(e.Password, "!")
+}
+
+// Formats the entry as a line of a shadow file, without a line break.
+func (e *Entry) String() string {
+	fields := []string{
+		e.Name,
+		e.Password,
+		formatInt(e.LastChange),
+		formatInt(e.MinAge),
+		formatInt(e.MaxAge),
+		formatInt(e.WarnPeriod),
+		formatInt(e.InactivePeriod),
+		formatInt(e.Expire),
+		e.Reserved,
+	}
+
+	return strings.Join(fields, ":")
+}
+
+func formatInt(v int64) string {
+	if v < 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(v, 10)
+}
+
+func parseInt(s string) (int64, bool) {
+	if s == "" {
+		return -1, true
+	}
+
+	v, err := strconv.ParseInt(s, 10, 64)
+	return v, err == nil && v >= 0
+}
+
+// Parses a line of a shadow file. Returns ok == false if it does not have
+// nine fields or a numeric field is invalid.
+func parseEntry(line string) (e *Entry, ok bool) {
+	fields := strings.Split(line, ":")
+	if len(fields) != 9 || fields[0] == "" {
+		return nil, false
+	}
+
+	e = &Entry{
+		Name:     fields[0],
+		Password: fields[1],
+		Reserved: fields[8],
+	}
+
+	for i, p := range []*int64{&e.LastChange, &e.MinAge, &e.MaxAge, &e.WarnPeriod, &e.InactivePeriod, &e.Expire} {
+		if *p, ok = parseInt(fields[i+2]); !ok {
+			return nil, false
+		}
+	}
+
+	return e, true
+}
+
+type line struct {
+	raw   string // used if entry is nil
+	entry *Entry
+}
+
+// A shadow file. Lines which are not valid entries are preserved but
+// otherwise ignored.
+//
+// A File is not safe for concurrent use.
+type File struct {
+	// The context used to hash and verify passwords. If nil, DefaultContext
+	// is used.
+	Context *passlib.Context
+
+	lines []line
+}
+
+// Parses a shadow file.
+func Parse(r io.Reader) (*File, error) {
+	f := &File{}
+
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		e, _ := parseEntry(s.Text())
+		f.lines = append(f.lines, line{raw: s.Text(), entry: e})
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// Loads the shadow file at path.
+func Load(path string) (*File, error) {
+	r, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return Parse(r)
+}
+
+// Locks the shadow file at path, loads it, calls fn to modify it and saves it
+// if fn returns nil. The lock file is path+".lock"; atomicfile.ErrLocked is
+// returned if it already exists.
+func Update(path string, ctx *passlib.Context, fn func(f *File) error) error {
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := Load(path)
+	if err != nil {
+		return err
+	}
+
+	f.Context = ctx
+	if err := fn(f); err != nil {
+		return err
+	}
+
+	return f.Save(path)
+}
+
+func (f *File) context() *passlib.Context {
+	if f.Context == nil {
+		return &DefaultContext
+	}
+
+	return f.Context
+}
+
+// Returns the entries in the file, in file order. Changes to the entries are
+// reflected when the file is written.
+func (f *File) Entries() []*Entry {
+	var entries []*Entry
+	for _, l := range f.lines {
+		if l.entry != nil {
+			entries = append(entries, l.entry)
+		}
+	}
+
+	return entries
+}
+
+// Returns the entry for a user, or nil if there is none. Changes to the entry
+// are reflected when the file is written.
+func (f *File) Lookup(name string) *Entry {
+	for _, l := range f.lines {
+		if l.entry != nil && l.entry.Name == name {
+			return l.entry
+		}
+	}
+
+	return nil
+}
+
+// Verifies a user's password. Returns nil err only if the password is valid.
+//
+// If the context issues an upgrade hash, the user's password field is
+// replaced with it and updated is true; the caller should then save the file.
+func (f *File) Verify(name, password string) (updated bool, err error) {
+	e := f.Lookup(name)
+	switch {
+	case e == nil:
+		return false, ErrUnknownUser
+	case e.Locked():
+		return false, ErrLocked
+	case e.Password == "":
+		return false, ErrEmptyPassword
+	}
+
+	newHash, err := f.context().Verify(password, e.Password)
+	if err != nil {
+		return false, err
+	}
+
+	if newHash == "" {
+		return false, nil
+	}
+
+	e.Password = newHash
+	return true, nil
+}
+
+// Sets the password hash for a user and updates the date of the last
+// password change. A locked account remains locked: the new hash is prefixed
+// with "!", which also replaces a "*" in the password field.
+func (f *File) SetHash(name, hash string) error {
+	e := f.Lookup(name)
+	if e == nil {
+		return ErrUnknownUser
+	}
+
+	if strings.ContainsAny(hash, ":\r\n") {
+		return ErrInvalidField
+	}
+
+	prefix := ""
+	if e.Locked() {
+		prefix = "!"
+	}
+
+	e.Password = prefix + hash
+	e.LastChange = time.Now().Unix() / 86400
+	return nil
+}
+
+// Hashes a password using the preferred scheme of the file's context and
+// sets it for a user as by SetHash.
+func (f *File) SetPassword(name, password string) error {
+	hash, err := f.context().Hash(password)
+	if err != nil {
+		return err
+	}
+
+	return f.SetHash(name, hash)
+}
+
+// Returns the names of users whose hashes need updating according to the
+// policy of the file's context, in file order. Locked accounts and accounts
+// with empty passwords are not included.
+func (f *File) NeedsUpdate() []string {
+	ctx := f.context()
+
+	var names []string
+	for _, e := range f.Entries() {
+		if !e.Locked() && e.Password != "" && ctx.NeedsUpdate(e.Password) {
+			names = append(names, e.Name)
+		}
+	}
+
+	return names
+}
+
+// Writes the file in shadow format.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	var n int64
+	for _, l := range f.lines {
+		s := l.raw
+		if l.entry != nil {
+			s = l.entry.String()
+		}
+
+		m, err := io.WriteString(w, s+"\n")
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Saves the file to path, replacing any existing file atomically and
+// preserving its permissions and, on Unix, its owner and group. A new file is
+// created with mode 0640.
+func (f *File) Save(path string) error {
+	return atomicfile.Write(path, 0640, func(w io.Writer) error {
+		_, err := f.WriteTo(w)
+		return err
+	})
+}
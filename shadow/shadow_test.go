@@ -0,0 +1,220 @@
+package shadow
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/internal/atomicfile"
+)
+
+// Every account with a hash has the password "password".
+const testShadow = `root:!$6$rounds=6000$saltsalt$/2IBlcXmbix2Pz9EgdXxip0TT5bhdAIoou2966MTCS0JSxQOJIShgxlWLJ5Av3M2MKGiWxJvU123K4YDk.AKG.:19000:0:99999:7:::
+daemon:*:19000:0:99999:7:::
+alice:$6$rounds=6000$saltsaltsaltsalt$49YBLw1rAB9uprCni31ei9cqsoCNhqLJmXkeWcKCBkunDoN.gCJjlXFSNebwARc53R7cM7.v2GMV7vwo7Aqb50:19000:0:99999:7:30:20000:
+bob:$y$j9T$F5Jx5fExrKuPp53xLKQ..1$tnSYvahCwPBHKZUspmcxMfb0.WiB9W.zEaKlOBL35rC:19000::::::
+carol:$1$ab$oKsM6dtDD2L1bKowOBX.7.:19000:0:99999:7:::
+dave:$5$rounds=1000$saltsalt$azOwbpkvuuBKkE82dQPwTsQE8JyT9Fflpr9aKid3aT9:19000:0:99999:7:::
+erin:$2a$04$5BJqKfqMQvV7nS.yUguNcuILW9FGllxJaVx5khwqoFfXDmHlUe9M6:19000:0:99999:7:::
+frank::19000:0:99999:7:::
++nis
+`
+
+func testContext() *passlib.Context {
+	schemes := append([]abstract.Scheme{sha2crypt.NewCrypter512(6000)}, DefaultContext.Schemes[1:]...)
+	return &passlib.Context{Schemes: schemes}
+}
+
+func TestParse(t *testing.T) {
+	f, err := Parse(strings.NewReader(testShadow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b bytes.Buffer
+	if _, err := f.WriteTo(&b); err != nil || b.String() != testShadow {
+		t.Errorf("file not preserved: %q %v", b.String(), err)
+	}
+
+	if len(f.Entries()) != 8 {
+		t.Errorf("unexpected number of entries: %d", len(f.Entries()))
+	}
+
+	e := f.Lookup("alice")
+	expected := &Entry{
+		Name:           "alice",
+		Password:       e.Password,
+		LastChange:     19000,
+		MinAge:         0,
+		MaxAge:         99999,
+		WarnPeriod:     7,
+		InactivePeriod: 30,
+		Expire:         20000,
+	}
+	if !reflect.DeepEqual(e, expected) {
+		t.Errorf("unexpected entry: %+v", e)
+	}
+
+	if e := f.Lookup("bob"); e.MinAge != -1 || e.Expire != -1 {
+		t.Errorf("empty fields not parsed: %+v", e)
+	}
+
+	if !f.Lookup("root").Locked() || !f.Lookup("daemon").Locked() || f.Lookup("alice").Locked() {
+		t.Errorf("unexpected lock state")
+	}
+
+	if h := f.Lookup("root").Hash(); !strings.HasPrefix(h, "$6$") {
+		t.Errorf("lock prefix not removed")
+	}
+
+	if f.Lookup("nis") != nil || f.Lookup("+nis") != nil {
+		t.Errorf("invalid line parsed as entry")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	f, err := Parse(strings.NewReader(testShadow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Context = testContext()
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		if _, err := f.Verify(name, "wrong"); err == nil {
+			t.Errorf("%s: wrong password accepted", name)
+		}
+
+		// Only alice's hash is current; the rest are upgraded.
+		if updated, err := f.Verify(name, "password"); err != nil || updated != (name != "alice") {
+			t.Errorf("%s: cannot verify: %v %v", name, updated, err)
+		}
+
+		if !strings.HasPrefix(f.Lookup(name).Password, "$6$rounds=6000$") {
+			t.Errorf("%s: hash not upgraded", name)
+		}
+	}
+
+	for name, expected := range map[string]error{"root": ErrLocked, "daemon": ErrLocked, "frank": ErrEmptyPassword, "nobody": ErrUnknownUser} {
+		if _, err := f.Verify(name, "password"); err != expected {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	f, err := Parse(strings.NewReader(testShadow))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.Context = testContext()
+	if u := f.NeedsUpdate(); !reflect.DeepEqual(u, []string{"bob", "carol", "dave", "erin"}) {
+		t.Errorf("unexpected users needing update: %v", u)
+	}
+
+	for _, name := range []string{"root", "daemon", "frank"} {
+		if err := f.SetPassword(name, "secret"); err != nil {
+			t.Fatal(err)
+		}
+
+		if f.Lookup(name).LastChange <= 19000 {
+			t.Errorf("%s: last change not updated", name)
+		}
+	}
+
+	// root and daemon remain locked.
+	for _, name := range []string{"root", "daemon"} {
+		if !strings.HasPrefix(f.Lookup(name).Password, "!$6$") {
+			t.Errorf("%s: lock removed: %q", name, f.Lookup(name).Password)
+		}
+
+		if _, err := f.Verify(name, "secret"); err != ErrLocked {
+			t.Errorf("%s: locked account verified: %v", name, err)
+		}
+	}
+
+	if _, err := f.Verify("frank", "secret"); err != nil {
+		t.Errorf("cannot verify new password: %v", err)
+	}
+
+	if err := f.SetHash("alice", "a:b"); err != ErrInvalidField {
+		t.Errorf("invalid hash accepted")
+	}
+
+	if err := f.SetHash("nobody", "x"); err != ErrUnknownUser {
+		t.Errorf("unknown user accepted")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shadow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "shadow")
+	if err := ioutil.WriteFile(path, []byte(testShadow), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err = Update(path, testContext(), func(f *File) error {
+		return f.SetPassword("alice", "secret")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.Verify("alice", "secret"); err != nil {
+		t.Errorf("password not saved: %v", err)
+	}
+
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("mode not preserved: %v %v", fi, err)
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file not removed: %v", err)
+	}
+
+	unlock, err := atomicfile.Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	if err := Update(path, nil, func(f *File) error { return nil }); err != atomicfile.ErrLocked {
+		t.Errorf("lock not honoured: %v", err)
+	}
+}
+
+func TestParsePasswd(t *testing.T) {
+	entries, err := ParsePasswd(strings.NewReader("root:x:0:0:root:/root:/bin/bash\n+nis\nalice:*:1000:100:Alice,,,:/home/alice:/bin/sh\nbad:x:a:0:::\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*PasswdEntry{
+		{Name: "root", Password: "x", UID: 0, GID: 0, GECOS: "root", Home: "/root", Shell: "/bin/bash"},
+		{Name: "alice", Password: "*", UID: 1000, GID: 100, GECOS: "Alice,,,", Home: "/home/alice", Shell: "/bin/sh"},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+
+	if !entries[0].Shadowed() || entries[1].Shadowed() {
+		t.Errorf("unexpected shadowed state")
+	}
+}
@@ -0,0 +1,94 @@
+//go:build go1.21
+// +build go1.21
+
+// Package sloghook provides a passlib.Hook which logs events to a log/slog
+// Logger, for audit trails.
+//
+//	ctx := &passlib.Context{Hook: sloghook.New(logger)}
+//
+// Events never contain passwords or hashes.
+package sloghook
+
+import (
+	"context"
+	"log/slog"
+
+	"gopkg.in/hlandau/passlib.v1"
+)
+
+// The level at which each kind of event is logged by default. Successful
+// operations are logged at Info (hashing at Debug), rejected hashes and
+// passwords at Warn, and failed upgrades at Error.
+var DefaultLevels = map[passlib.EventKind]slog.Level{
+	passlib.EventHash:              slog.LevelDebug,
+	passlib.EventVerifySuccess:     slog.LevelInfo,
+	passlib.EventVerifyFailure:     slog.LevelWarn,
+	passlib.EventUpgradeIssued:     slog.LevelInfo,
+	passlib.EventUpgradeFailed:     slog.LevelError,
+	passlib.EventUpgradeDeferred:   slog.LevelInfo,
+	passlib.EventUnsupportedScheme: slog.LevelWarn,
+	passlib.EventDisabledScheme:    slog.LevelWarn,
+	passlib.EventLimitExceeded:     slog.LevelWarn,
+}
+
+// A passlib.Hook which logs events.
+type Hook struct {
+	// The logger to use. If nil, slog.Default() is used.
+	Logger *slog.Logger
+
+	// The level at which each kind of event is logged, overriding
+	// DefaultLevels. Kinds in neither map are logged at Info.
+	Levels map[passlib.EventKind]slog.Level
+}
+
+// Returns a hook logging events to logger at DefaultLevels.
+func New(logger *slog.Logger) *Hook {
+	return &Hook{Logger: logger}
+}
+
+func (h *Hook) level(kind passlib.EventKind) slog.Level {
+	if l, ok := h.Levels[kind]; ok {
+		return l
+	}
+
+	if l, ok := DefaultLevels[kind]; ok {
+		return l
+	}
+
+	return slog.LevelInfo
+}
+
+// Logs the event with the message "passlib: " followed by its kind, and the
+// attributes "scheme", "elapsed", and where applicable "reasons" and "error".
+func (h *Hook) Notify(e passlib.Event) {
+	logger := h.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	ctx := context.Background()
+	level := h.level(e.Kind)
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("scheme", e.Scheme),
+		slog.Duration("elapsed", e.Elapsed),
+	}
+
+	if len(e.UpdateReasons) > 0 {
+		reasons := make([]string, len(e.UpdateReasons))
+		for i, r := range e.UpdateReasons {
+			reasons[i] = string(r)
+		}
+
+		attrs = append(attrs, slog.Any("reasons", reasons))
+	}
+
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+
+	logger.LogAttrs(ctx, level, "passlib: "+string(e.Kind), attrs...)
+}
@@ -0,0 +1,71 @@
+//go:build go1.21
+// +build go1.21
+
+package sloghook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"gopkg.in/hlandau/passlib.v1"
+	"gopkg.in/hlandau/passlib.v1/abstract"
+	"gopkg.in/hlandau/passlib.v1/hash/sha2crypt"
+	"gopkg.in/hlandau/passlib.v1/hash/windows"
+)
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	ctx := &passlib.Context{
+		Schemes:  []abstract.Scheme{sha2crypt.Crypter256},
+		Disabled: []abstract.Scheme{windows.NTCrypter},
+		Hook:     New(logger),
+	}
+
+	hash, err := ctx.Hash("hunter2")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ctx.Verify("hunter2", hash)
+	ctx.Verify("wrong", hash)
+	ctx.Verify("hunter2", "$NT$8846f7eaee8fb117ad06bdd830b7586c")
+
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("cannot decode %q: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	expected := []struct{ level, msg string }{
+		{"DEBUG", "passlib: hash"},
+		{"INFO", "passlib: verify-success"},
+		{"WARN", "passlib: verify-failure"},
+		{"WARN", "passlib: disabled-scheme"},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("unexpected log:\n%s", buf.String())
+	}
+
+	for i, e := range expected {
+		if entries[i]["level"] != e.level || entries[i]["msg"] != e.msg {
+			t.Errorf("unexpected entry %d: %v", i, entries[i])
+		}
+	}
+
+	if entries[2]["scheme"] != fmt.Sprint(sha2crypt.Crypter256) || entries[2]["error"] == nil {
+		t.Errorf("unexpected attributes: %v", entries[2])
+	}
+
+	if strings.Contains(buf.String(), hash) || strings.Contains(buf.String(), "hunter2") {
+		t.Errorf("password or hash logged:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package passlib
+
+import (
+	"time"
+
+	"gopkg.in/hlandau/passlib.v1/abstract"
+)
+
+// A reason for which a hash needs updating.
+type UpdateReason string
+
+const (
+	// The hash uses a verify-only scheme.
+	UpdateVerifyOnly UpdateReason = "verify-only"
+
+	// The hash uses a scheme other than the preferred scheme of the context.
+	UpdateNotPreferred UpdateReason = "not-preferred"
+
+	// The scheme of the hash considers its parameters (e.g. cost or salt
+	// length) outdated.
+	UpdateOutdated UpdateReason = "outdated"
+)
+
+// Describes the outcome of Context.VerifyWithResult.
+type VerifyResult struct {
+	// The scheme which supports the hash, including a disabled scheme, or nil
+	// if no scheme in the context supports it.
+	Scheme abstract.Scheme
+
+	// The reasons the hash needs updating, if the password was valid. Empty if
+	// no update is needed.
+	UpdateReasons []UpdateReason
+
+	// The upgrade hash, if an update was needed and hashing succeeded.
+	NewHash string
+
+	// The error from hashing the upgrade hash, if an update was needed and
+	// hashing failed. The stored hash remains valid in this case, but will not
+	// be upgraded.
+	UpgradeErr error
+
+	// The time taken to verify the password, including hashing any upgrade
+	// hash.
+	Elapsed time.Duration
+}
+
+// Returns true if the hash needs updating.
+func (r *VerifyResult) NeedsUpdate() bool {
+	return len(r.UpdateReasons) > 0
+}
+
+func updateReasons(scheme abstract.Scheme, hash string, preferred bool) []UpdateReason {
+	if abstract.IsVerifyOnly(scheme) {
+		return []UpdateReason{UpdateVerifyOnly}
+	}
+
+	var reasons []UpdateReason
+	if !preferred {
+		reasons = append(reasons, UpdateNotPreferred)
+	}
+
+	if scheme.NeedsUpdate(hash) {
+		reasons = append(reasons, UpdateOutdated)
+	}
+
+	return reasons
+}
+
+// Like Verify, but returns a VerifyResult. See Context.VerifyWithResult.
+func VerifyWithResult(password, hash string) (*VerifyResult, error) {
+	return DefaultContext.VerifyWithResult(password, hash)
+}